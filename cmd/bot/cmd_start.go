@@ -11,7 +11,11 @@ import (
 	"runtime"
 
 	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/container"
 	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/Jacobbrewer1/satisfactory/pkg/secrets"
 	svc "github.com/Jacobbrewer1/satisfactory/pkg/services/bot"
 	uhttp "github.com/Jacobbrewer1/satisfactory/pkg/utils/http"
 	"github.com/Jacobbrewer1/vaulty"
@@ -111,6 +115,8 @@ func (s *startCmd) setup(ctx context.Context, r *mux.Router) (service svc.Servic
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
+	v.SetDefault("bot.server_control.docker_socket", container.DefaultDockerSocket)
+
 	if !v.IsSet("vault") {
 		return nil, errors.New("vault configuration not found")
 	}
@@ -145,7 +151,81 @@ func (s *startCmd) setup(ctx context.Context, r *mux.Router) (service svc.Servic
 		return nil, fmt.Errorf("error creating redis pool: %w", err)
 	}
 
-	service = svc.NewService(vs.Data[v.GetString("vault.bot.secret_key")].(string))
+	opts := make([]svc.ServiceOption, 0)
+	if v.IsSet("bot.guild_id") {
+		opts = append(opts, svc.WithGuild(v.GetString("bot.guild_id")))
+	}
+
+	if v.GetBool("bot.remove_global_commands") {
+		opts = append(opts, svc.WithGlobalCommandRemoval())
+	}
+
+	if v.IsSet("bot.access") {
+		policies := make(map[string]*svc.AccessPolicy)
+		if err := v.UnmarshalKey("bot.access", &policies); err != nil {
+			return nil, fmt.Errorf("error reading access policies: %w", err)
+		}
+		opts = append(opts, svc.WithAccessPolicies(policies))
+	}
+
+	if v.IsSet("bot.server_api.address") {
+		store := secrets.NewVaultStore(vc, v.GetString("vault.kvv2_mount"))
+
+		apiToken, err := secrets.Value(ctx, store, v.GetString("bot.server_api.token_path"), "token")
+		if err != nil {
+			return nil, fmt.Errorf("error getting server api token: %w", err)
+		}
+
+		apiOpts := make([]satisfactory.APIOption, 0)
+		if v.GetBool("bot.server_api.insecure_skip_verify") {
+			apiOpts = append(apiOpts, satisfactory.WithInsecureSkipVerify())
+		}
+
+		opts = append(opts,
+			svc.WithServerAPI(satisfactory.NewAPIClient(v.GetString("bot.server_api.address"), apiToken, apiOpts...)),
+			svc.WithCredentials(store, v.GetString("bot.credentials.secret_path")),
+		)
+	}
+
+	if v.IsSet("bot.console") {
+		policy := new(svc.ConsolePolicy)
+		if err := v.UnmarshalKey("bot.console", policy); err != nil {
+			return nil, fmt.Errorf("error reading console policy: %w", err)
+		}
+		opts = append(opts, svc.WithConsolePolicy(policy))
+	}
+
+	if v.IsSet("bot.saves.max_attachment_size") {
+		opts = append(opts, svc.WithMaxAttachmentSize(int(v.GetSizeInBytes("bot.saves.max_attachment_size"))))
+	}
+
+	if v.IsSet("bot.server_control.container") {
+		controller := container.NewDockerController(
+			v.GetString("bot.server_control.container"),
+			container.WithSocket(v.GetString("bot.server_control.docker_socket")),
+			container.WithStopTimeout(v.GetDuration("bot.server_control.stop_timeout")),
+		)
+		opts = append(opts, svc.WithServerControl(controller, v.GetStringSlice("bot.server_control.roles")))
+	}
+
+	destinations, err := alerts.DestinationNames(v)
+	if err != nil {
+		return nil, fmt.Errorf("error reading alert destinations: %w", err)
+	}
+	opts = append(opts, svc.WithAlertDestinations(destinations))
+
+	if v.IsSet("bot.alerts.channel_id") {
+		opts = append(opts,
+			svc.WithAlertChannel(v.GetString("bot.alerts.channel_id"), v.GetString("bot.alerts.queue")),
+			svc.WithEscalation(v.GetString("bot.alerts.escalation_role_id"), v.GetDuration("bot.alerts.ack_timeout")),
+		)
+	}
+
+	if v.IsSet("bot.status_panel.channel_id") {
+		opts = append(opts, svc.WithStatusPanel(v.GetString("bot.status_panel.channel_id")))
+	}
+
+	service = svc.NewService(vs.Data[v.GetString("vault.bot.secret_key")].(string), opts...)
 
 	r.HandleFunc("/metrics", uhttp.InternalOnly(promhttp.Handler())).Methods(http.MethodGet)
 	r.HandleFunc("/health", uhttp.InternalOnly(healthHandler())).Methods(http.MethodGet)
@@ -13,7 +13,10 @@ import (
 	"github.com/Jacobbrewer1/goredis"
 	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
 	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/Jacobbrewer1/satisfactory/pkg/secrets"
 	svc "github.com/Jacobbrewer1/satisfactory/pkg/services/watcher"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
 	uhttp "github.com/Jacobbrewer1/satisfactory/pkg/utils/http"
 	"github.com/Jacobbrewer1/vaulty"
 	"github.com/google/subcommands"
@@ -28,6 +31,9 @@ type startCmd struct {
 
 	// configLocation is the location of the config file
 	configLocation string
+
+	// alertsDone is closed once the alert manager has stopped, after sending the alerts it still held.
+	alertsDone chan struct{}
 }
 
 func (s *startCmd) Name() string {
@@ -82,7 +88,6 @@ func (s *startCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{
 		err := srv.ListenAndServe()
 		if errors.Is(err, http.ErrServerClosed) {
 			slog.Info("Server closed gracefully")
-			os.Exit(0)
 		} else if err != nil {
 			slog.Error("Error serving requests", slog.String(logging.KeyError, err.Error()))
 			os.Exit(1)
@@ -91,7 +96,12 @@ func (s *startCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{
 
 	<-ctx.Done()
 	slog.Info("Shutting down application")
-	if err := srv.Shutdown(ctx); err != nil {
+	err = srv.Shutdown(ctx)
+
+	// The alert manager sends what it still holds, such as the email digest, before the process exits.
+	<-s.alertsDone
+
+	if err != nil {
 		slog.Error("Error shutting down application", slog.String(logging.KeyError, err.Error()))
 		return subcommands.ExitFailure
 	}
@@ -140,11 +150,105 @@ func (s *startCmd) setup(ctx context.Context, r *mux.Router) (service svc.Servic
 		return nil, fmt.Errorf("error creating redis pool: %w", err)
 	}
 
-	am := alerts.NewDiscordManager(vs.Data[v.GetString("vault.bot.alerts_url_key")].(string))
-	service = svc.NewService(ctx, am, v.GetString("redis.info_list_name"), v.GetString("redis.details_list_name"))
+	v.SetDefault("watcher.staleness.docker_info", defaultStaleDeadline)
+	v.SetDefault("watcher.staleness.server_details", defaultStaleDeadline)
+
+	opts := []svc.ServiceOption{
+		svc.WithStaleDeadline(state.SourceDockerInfo, v.GetDuration("watcher.staleness.docker_info")),
+		svc.WithStaleDeadline(state.SourceServerDetails, v.GetDuration("watcher.staleness.server_details")),
+		svc.WithServerLogsListName(v.GetString("redis.logs_list_name")),
+	}
+
+	if v.IsSet("watcher.tick_rate_threshold") {
+		opts = append(opts, svc.WithTickRateThreshold(v.GetFloat64("watcher.tick_rate_threshold")))
+	}
+
+	if v.IsSet("satisfactory.query_address") {
+		opts = append(opts, svc.WithQueryClient(
+			satisfactory.NewQueryClient(v.GetString("satisfactory.query_address")),
+			v.GetDuration("watcher.version_poll_interval"),
+		))
+	}
+
+	if v.IsSet("watcher.save_retention") {
+		retention, err := saveRetention(ctx, v, vc)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring save retention: %w", err)
+		}
+		opts = append(opts, retention)
+	}
+
+	am, err := alertManager(v, vs.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error creating alert manager: %w", err)
+	}
+
+	history := alerts.NewHistoryStore(v.GetDuration("alerts.history_retention"))
+
+	// Deliver the alerts from a redis outbox so a slow or rate limited destination never holds up the watcher.
+	am = alerts.NewOutbox(am, alerts.WithHistory(history), alerts.WithSource(appName))
+	s.alertsDone = make(chan struct{})
+	go func(am alerts.Manager) {
+		defer close(s.alertsDone)
+		am.Run(ctx)
+	}(am)
+
+	service = svc.NewService(
+		ctx,
+		am,
+		v.GetString("redis.info_list_name"),
+		v.GetString("redis.details_list_name"),
+		opts...,
+	)
 
 	r.HandleFunc("/metrics", uhttp.InternalOnly(promhttp.Handler())).Methods(http.MethodGet)
 	r.HandleFunc("/health", uhttp.InternalOnly(healthHandler())).Methods(http.MethodGet)
+	r.HandleFunc("/alerts/history", uhttp.InternalOnly(alerts.HistoryHandler(history))).Methods(http.MethodGet)
+	r.HandleFunc("/alerts/alertmanager", uhttp.InternalOnly(alerts.AlertmanagerHandler(am))).Methods(http.MethodPost)
 
 	return service, nil
 }
+
+// saveRetention creates the option pruning old saves through the server API, with the API token stored in vault.
+func saveRetention(ctx context.Context, v *viper.Viper, vc vaulty.ClientHandler) (svc.ServiceOption, error) {
+	policy := svc.RetentionPolicy{}
+	if err := v.UnmarshalKey("watcher.save_retention", &policy); err != nil {
+		return nil, fmt.Errorf("error reading retention policy: %w", err)
+	}
+
+	store := secrets.NewVaultStore(vc, v.GetString("vault.kvv2_mount"))
+	apiToken, err := secrets.Value(ctx, store, v.GetString("watcher.server_api.token_path"), "token")
+	if err != nil {
+		return nil, fmt.Errorf("error getting server api token: %w", err)
+	}
+
+	apiOpts := make([]satisfactory.APIOption, 0)
+	if v.GetBool("watcher.server_api.insecure_skip_verify") {
+		apiOpts = append(apiOpts, satisfactory.WithInsecureSkipVerify())
+	}
+
+	client := satisfactory.NewAPIClient(v.GetString("watcher.server_api.address"), apiToken, apiOpts...)
+	return svc.WithSaveRetention(client, policy, v.GetDuration("watcher.save_retention_interval")), nil
+}
+
+// alertManager creates the alert destinations from the config, falling back to the discord webhook stored in vault.
+// Mutes set from the bot apply to every destination.
+func alertManager(v *viper.Viper, secrets map[string]any) (alerts.Manager, error) {
+	lookup := func(key string) (string, bool) {
+		got, ok := secrets[key].(string)
+		return got, ok
+	}
+
+	muteStore := alerts.WithMuteStore(alerts.NewMuteStore())
+
+	if v.IsSet("alerts.notifiers") {
+		return alerts.FromViper(v, lookup, muteStore)
+	}
+
+	webhookURL, ok := lookup(v.GetString("vault.bot.alerts_url_key"))
+	if !ok {
+		return nil, errors.New("alerts url not found in vault")
+	}
+
+	return alerts.NewManager([]alerts.Notifier{alerts.NewDiscordManager(webhookURL)}, muteStore), nil
+}
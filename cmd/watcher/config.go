@@ -1,6 +1,11 @@
 package main
 
+import "time"
+
 const (
 	// appName is the name of the application.
 	appName = "watcher"
+
+	// defaultStaleDeadline is how long an ingest source can go without data when no deadline is configured.
+	defaultStaleDeadline = 10 * time.Minute
 )
@@ -0,0 +1,251 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// acksKey is the redis hash of the open acknowledgeable alerts, keyed by the alert ID.
+	acksKey = "alerts:acks"
+
+	// ackClaimKeyPrefix prefixes the redis key claimed by the first user to acknowledge an alert.
+	ackClaimKeyPrefix = "alerts:acks:claim:"
+
+	// ackClaimTTL is how long the claim of an acknowledgement is kept.
+	ackClaimTTL = 7 * 24 * time.Hour
+
+	// maxAckUpdateAttempts is how many times an update is tried when the acknowledgement keeps changing under it.
+	maxAckUpdateAttempts = 3
+
+	// swapAckScript stores the acknowledgement of an alert only if it hasn't changed since it was read, so an update
+	// can't bring back a resolved alert or overwrite an acknowledgement made at the same time. It returns 1 if the
+	// acknowledgement was stored.
+	swapAckScript = `if redis.call("HGET", KEYS[1], ARGV[1]) == ARGV[2] then
+	redis.call("HSET", KEYS[1], ARGV[1], ARGV[3])
+	return 1
+end
+return 0`
+)
+
+var (
+	// ErrAlertNotFound is returned when an alert is not open, such as when it has already been resolved.
+	ErrAlertNotFound = errors.New("alert not found")
+)
+
+// Acknowledgement is the acknowledgement state of an alert posted by the bot.
+type Acknowledgement struct {
+	// Alert is the posted alert.
+	Alert *Alert `json:"alert"`
+
+	// ChannelID is the ID of the channel the alert was posted in.
+	ChannelID string `json:"channel_id"`
+
+	// MessageID is the ID of the message of the alert.
+	MessageID string `json:"message_id"`
+
+	// PostedAt is when the alert was posted.
+	PostedAt time.Time `json:"posted_at"`
+
+	// AckedBy is who acknowledged the alert. It is empty while the alert is not acknowledged.
+	AckedBy string `json:"acked_by,omitempty"`
+
+	// AckedAt is when the alert was acknowledged.
+	AckedAt time.Time `json:"acked_at,omitempty"`
+
+	// Escalations is the number of times the alert was escalated.
+	Escalations int `json:"escalations"`
+
+	// LastNotifiedAt is when the alert was last posted or escalated.
+	LastNotifiedAt time.Time `json:"last_notified_at"`
+}
+
+// AckStore stores the acknowledgement state of the open alerts.
+type AckStore interface {
+	// Open stores the acknowledgement of a newly posted alert.
+	Open(ctx context.Context, ack *Acknowledgement) error
+
+	// Get returns the acknowledgement of the alert. It returns ErrAlertNotFound if the alert is not open.
+	Get(ctx context.Context, alertID string) (*Acknowledgement, error)
+
+	// Acknowledge records that the user took the alert. Only the first user to acknowledge an alert takes it, so the
+	// returned acknowledgement names who did.
+	Acknowledge(ctx context.Context, alertID, user string, at time.Time) (*Acknowledgement, error)
+
+	// Escalated records that the alert was escalated at the time.
+	Escalated(ctx context.Context, alertID string, at time.Time) error
+
+	// Resolve closes the alert and returns its final acknowledgement.
+	Resolve(ctx context.Context, alertID string) (*Acknowledgement, error)
+
+	// Unacknowledged returns the open alerts that nobody has acknowledged.
+	Unacknowledged(ctx context.Context) ([]*Acknowledgement, error)
+}
+
+type ackStore struct{}
+
+// NewAckStore creates a new AckStore backed by redis.
+func NewAckStore() AckStore {
+	return new(ackStore)
+}
+
+func (s *ackStore) Open(ctx context.Context, ack *Acknowledgement) error {
+	return s.put(ctx, ack)
+}
+
+func (s *ackStore) Get(ctx context.Context, alertID string) (*Acknowledgement, error) {
+	ack, _, err := s.get(ctx, alertID)
+	return ack, err
+}
+
+// get returns the acknowledgement of the alert and how it is stored.
+func (s *ackStore) get(ctx context.Context, alertID string) (*Acknowledgement, []byte, error) {
+	raw, err := redisgo.Bytes(goredis.DoCtx(ctx, "HGET", acksKey, alertID))
+	if errors.Is(err, redisgo.ErrNil) {
+		return nil, nil, ErrAlertNotFound
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("get acknowledgement: %w", err)
+	}
+
+	ack := new(Acknowledgement)
+	if err := json.Unmarshal(raw, ack); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal acknowledgement: %w", err)
+	}
+
+	return ack, raw, nil
+}
+
+func (s *ackStore) Acknowledge(ctx context.Context, alertID, user string, at time.Time) (*Acknowledgement, error) {
+	ack, err := s.Get(ctx, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claim the alert so that only the first of several simultaneous acknowledgements wins.
+	_, err = redisgo.String(goredis.DoCtx(ctx, "SET", ackClaimKeyPrefix+alertID, user, "NX", "EX", int(ackClaimTTL.Seconds())))
+	if errors.Is(err, redisgo.ErrNil) {
+		ack.AckedBy, err = s.claimedBy(ctx, alertID)
+		if err != nil {
+			return nil, err
+		}
+		return ack, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("claim acknowledgement: %w", err)
+	}
+
+	ack.AckedBy = user
+	ack.AckedAt = at.UTC()
+	if err := s.put(ctx, ack); err != nil {
+		return nil, err
+	}
+
+	return ack, nil
+}
+
+func (s *ackStore) Escalated(ctx context.Context, alertID string, at time.Time) error {
+	for range maxAckUpdateAttempts {
+		// A resolved alert is not found, so it is never stored again.
+		ack, raw, err := s.get(ctx, alertID)
+		if err != nil {
+			return err
+		}
+
+		ack.Escalations++
+		ack.LastNotifiedAt = at.UTC()
+		updated, err := json.Marshal(ack)
+		if err != nil {
+			return fmt.Errorf("marshal acknowledgement: %w", err)
+		}
+
+		swapped, err := redisgo.Bool(goredis.DoCtx(ctx, "EVAL", swapAckScript, 1, acksKey, alertID, raw, updated))
+		if err != nil {
+			return fmt.Errorf("store escalation: %w", err)
+		} else if swapped {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("store escalation: the acknowledgement changed %d times", maxAckUpdateAttempts)
+}
+
+func (s *ackStore) Resolve(ctx context.Context, alertID string) (*Acknowledgement, error) {
+	ack, err := s.Get(ctx, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := redisgo.Int(goredis.DoCtx(ctx, "HDEL", acksKey, alertID))
+	if err != nil {
+		return nil, fmt.Errorf("remove acknowledgement: %w", err)
+	} else if removed == 0 {
+		// Someone else resolved the alert first.
+		return nil, ErrAlertNotFound
+	}
+
+	if _, err := goredis.DoCtx(ctx, "DEL", ackClaimKeyPrefix+alertID); err != nil {
+		return nil, fmt.Errorf("remove acknowledgement claim: %w", err)
+	}
+
+	return ack, nil
+}
+
+func (s *ackStore) Unacknowledged(ctx context.Context) ([]*Acknowledgement, error) {
+	raw, err := redisgo.ByteSlices(goredis.DoCtx(ctx, "HVALS", acksKey))
+	if err != nil {
+		return nil, fmt.Errorf("get acknowledgements: %w", err)
+	}
+
+	acks := make([]*Acknowledgement, 0, len(raw))
+	for _, r := range raw {
+		ack := new(Acknowledgement)
+		if err := json.Unmarshal(r, ack); err != nil {
+			return nil, fmt.Errorf("unmarshal acknowledgement: %w", err)
+		}
+
+		if ack.AckedBy != "" {
+			continue
+		}
+
+		// The claim is the source of truth, as it is taken before the acknowledgement is stored.
+		claimedBy, err := s.claimedBy(ctx, ack.Alert.ID)
+		if err != nil {
+			return nil, err
+		} else if claimedBy != "" {
+			continue
+		}
+
+		acks = append(acks, ack)
+	}
+
+	return acks, nil
+}
+
+// claimedBy returns who claimed the acknowledgement of the alert. It returns an empty string if nobody has.
+func (s *ackStore) claimedBy(ctx context.Context, alertID string) (string, error) {
+	claimedBy, err := redisgo.String(goredis.DoCtx(ctx, "GET", ackClaimKeyPrefix+alertID))
+	if errors.Is(err, redisgo.ErrNil) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("get acknowledgement claim: %w", err)
+	}
+	return claimedBy, nil
+}
+
+func (s *ackStore) put(ctx context.Context, ack *Acknowledgement) error {
+	raw, err := json.Marshal(ack)
+	if err != nil {
+		return fmt.Errorf("marshal acknowledgement: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "HSET", acksKey, ack.Alert.ID, raw); err != nil {
+		return fmt.Errorf("store acknowledgement: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// nilReply makes the mock pool return a nil reply, as redis does for a missing key.
+func nilReply(context.Context, string, ...any) (any, error) {
+	return nil, nil
+}
+
+type AckStoreSuite struct {
+	suite.Suite
+
+	pool  *goredis.MockPool
+	store AckStore
+	open  []byte
+}
+
+func TestAckStoreSuite(t *testing.T) {
+	suite.Run(t, new(AckStoreSuite))
+}
+
+func (s *AckStoreSuite) SetupTest() {
+	s.pool = goredis.NewMockPool(s.T())
+	s.Require().NoError(goredis.NewPool(
+		goredis.WithInitializedPool(s.pool),
+		goredis.WithAddress("localhost:6379"),
+		goredis.WithNetwork("tcp"),
+	))
+
+	s.store = NewAckStore()
+
+	alert := NewAlert(SeverityCritical, "Server down", "")
+	alert.ID = "1"
+
+	var err error
+	s.open, err = json.Marshal(&Acknowledgement{
+		Alert:     alert,
+		ChannelID: "channel",
+		MessageID: "message",
+	})
+	s.Require().NoError(err)
+}
+
+func (s *AckStoreSuite) TestAcknowledge() {
+	at := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	var stored *Acknowledgement
+	s.pool.On("DoCtx", mock.Anything, "HGET", acksKey, "1").Return(s.open, nil)
+	s.pool.On("DoCtx", mock.Anything, "SET", ackClaimKeyPrefix+"1", "alice", "NX", "EX", mock.Anything).Return("OK", nil)
+	s.pool.On("DoCtx", mock.Anything, "HSET", acksKey, "1", mock.Anything).
+		Run(func(args mock.Arguments) {
+			stored = new(Acknowledgement)
+			s.Require().NoError(json.Unmarshal(args.Get(4).([]byte), stored))
+		}).
+		Return(int64(0), nil)
+
+	ack, err := s.store.Acknowledge(context.Background(), "1", "alice", at)
+	s.Require().NoError(err)
+	s.Equal("alice", ack.AckedBy)
+
+	s.Require().NotNil(stored)
+	s.Equal("alice", stored.AckedBy)
+	s.Equal(at, stored.AckedAt)
+}
+
+func (s *AckStoreSuite) TestAcknowledgeAlreadyClaimed() {
+	s.pool.On("DoCtx", mock.Anything, "HGET", acksKey, "1").Return(s.open, nil)
+	s.pool.On("DoCtx", mock.Anything, "SET", ackClaimKeyPrefix+"1", "bob", "NX", "EX", mock.Anything).Return(nilReply, nil)
+	s.pool.On("DoCtx", mock.Anything, "GET", ackClaimKeyPrefix+"1").Return([]byte("alice"), nil)
+
+	ack, err := s.store.Acknowledge(context.Background(), "1", "bob", time.Now())
+	s.Require().NoError(err)
+
+	// Bob is told that Alice already took the alert, and nothing is overwritten.
+	s.Equal("alice", ack.AckedBy)
+	s.pool.AssertNotCalled(s.T(), "DoCtx", mock.Anything, "HSET", acksKey, "1", mock.Anything)
+}
+
+func (s *AckStoreSuite) TestAcknowledgeResolved() {
+	s.pool.On("DoCtx", mock.Anything, "HGET", acksKey, "1").Return(nilReply, nil)
+
+	_, err := s.store.Acknowledge(context.Background(), "1", "alice", time.Now())
+	s.Require().ErrorIs(err, ErrAlertNotFound)
+}
+
+func (s *AckStoreSuite) TestEscalated() {
+	at := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	var stored *Acknowledgement
+	s.pool.On("DoCtx", mock.Anything, "HGET", acksKey, "1").Return(s.open, nil)
+	s.pool.On("DoCtx", mock.Anything, "EVAL", swapAckScript, 1, acksKey, "1", s.open, mock.Anything).
+		Run(func(args mock.Arguments) {
+			stored = new(Acknowledgement)
+			s.Require().NoError(json.Unmarshal(args.Get(7).([]byte), stored))
+		}).
+		Return(int64(1), nil)
+
+	s.Require().NoError(s.store.Escalated(context.Background(), "1", at))
+
+	s.Require().NotNil(stored)
+	s.Equal(1, stored.Escalations)
+	s.Equal(at, stored.LastNotifiedAt)
+	s.pool.AssertNotCalled(s.T(), "DoCtx", mock.Anything, "HSET", acksKey, "1", mock.Anything)
+}
+
+func (s *AckStoreSuite) TestEscalatedResolvedMeanwhile() {
+	// The alert is resolved between reading and storing it, so the swap fails and it is not brought back.
+	s.pool.On("DoCtx", mock.Anything, "HGET", acksKey, "1").Return(s.open, nil).Once()
+	s.pool.On("DoCtx", mock.Anything, "EVAL", swapAckScript, 1, acksKey, "1", s.open, mock.Anything).Return(int64(0), nil).Once()
+	s.pool.On("DoCtx", mock.Anything, "HGET", acksKey, "1").Return(nilReply, nil).Once()
+
+	s.Require().ErrorIs(s.store.Escalated(context.Background(), "1", time.Now()), ErrAlertNotFound)
+	s.pool.AssertNotCalled(s.T(), "DoCtx", mock.Anything, "HSET", acksKey, "1", mock.Anything)
+}
+
+func (s *AckStoreSuite) TestBotNotifierQueuesAlert() {
+	alert := NewAlert(SeverityCritical, "Server down", "")
+
+	var queued *Alert
+	s.pool.On("DoCtx", mock.Anything, "RPUSH", DefaultBotQueue, mock.Anything).
+		Run(func(args mock.Arguments) {
+			queued = new(Alert)
+			s.Require().NoError(json.Unmarshal(args.Get(3).([]byte), queued))
+		}).
+		Return(int64(1), nil)
+
+	s.Require().NoError(NewBotNotifier("bot", "").Notify(context.Background(), alert))
+	s.Require().NotNil(queued)
+	s.Equal(alert.ID, queued.ID)
+	s.Equal("Server down", queued.Title)
+}
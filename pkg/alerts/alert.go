@@ -0,0 +1,211 @@
+package alerts
+
+import (
+	"fmt"
+	"time"
+)
+
+// Severity is how important an alert is.
+type Severity string
+
+const (
+	// SeverityOK is used for alerts that report a recovery.
+	SeverityOK Severity = "ok"
+
+	// SeverityInfo is used for alerts that are informational.
+	SeverityInfo Severity = "info"
+
+	// SeverityWarning is used for alerts that may need attention.
+	SeverityWarning Severity = "warning"
+
+	// SeverityCritical is used for alerts that need immediate attention.
+	SeverityCritical Severity = "critical"
+)
+
+// severityRanks orders the severities from least to most important.
+var severityRanks = map[Severity]int{
+	SeverityOK:       0,
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+// ParseSeverity returns the severity with the given name.
+func ParseSeverity(s string) (Severity, error) {
+	if _, ok := severityRanks[Severity(s)]; !ok {
+		return "", fmt.Errorf("unknown severity: %s", s)
+	}
+	return Severity(s), nil
+}
+
+// AtLeast returns true if the severity is as important as the threshold or more important.
+func (s Severity) AtLeast(threshold Severity) bool {
+	return severityRanks[s] >= severityRanks[threshold]
+}
+
+// Colour returns the colour used to render the severity.
+func (s Severity) Colour() int {
+	switch s {
+	case SeverityOK:
+		return 0x2ECC71
+	case SeverityWarning:
+		return 0xF1C40F
+	case SeverityCritical:
+		return 0xE74C3C
+	default:
+		return 0x3498DB
+	}
+}
+
+// Category is what an alert is about. Alerts are routed and muted by category.
+type Category string
+
+const (
+	// CategoryState is used for alerts about the state of the server container.
+	CategoryState Category = "state"
+
+	// CategorySession is used for alerts about the active game session.
+	CategorySession Category = "session"
+
+	// CategoryGame is used for alerts about the game running or being paused.
+	CategoryGame Category = "game"
+
+	// CategoryStaleness is used for alerts about data not being received.
+	CategoryStaleness Category = "staleness"
+
+	// CategoryVersion is used for alerts about server updates.
+	CategoryVersion Category = "version"
+
+	// CategoryPerformance is used for alerts about the performance of the server.
+	CategoryPerformance Category = "performance"
+
+	// CategoryHost is used for alerts about the host, received from Prometheus Alertmanager.
+	CategoryHost Category = "host"
+)
+
+// Categories are all the alert categories.
+var Categories = []Category{
+	CategoryState,
+	CategorySession,
+	CategoryGame,
+	CategoryStaleness,
+	CategoryVersion,
+	CategoryPerformance,
+	CategoryHost,
+}
+
+// Alert is a structured alert.
+type Alert struct {
+	// ID is the unique ID of the alert.
+	ID string `json:"id,omitempty"`
+
+	// Title is the title of the alert.
+	Title string `json:"title"`
+
+	// Description is the body of the alert.
+	Description string `json:"description,omitempty"`
+
+	// Severity is how important the alert is.
+	Severity Severity `json:"severity"`
+
+	// Category is what the alert is about.
+	Category Category `json:"category,omitempty"`
+
+	// Source is what raised the alert, such as the watcher.
+	Source string `json:"source,omitempty"`
+
+	// Fields are additional name and value pairs shown with the alert.
+	Fields []*Field `json:"fields,omitempty"`
+
+	// Footer is the text shown at the bottom of the alert.
+	Footer string `json:"footer,omitempty"`
+
+	// Timestamp is when the alert happened.
+	Timestamp time.Time `json:"timestamp"`
+
+	// ThumbnailURL is the URL of an image shown with the alert.
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+
+	// Template is the name of the template the title, description, footer and fields are rendered from.
+	Template string `json:"template,omitempty"`
+
+	// Data is the data the template is executed with.
+	Data map[string]any `json:"data,omitempty"`
+
+	// IncidentID is the ID of the incident the alert reports on. Destinations that support it show every alert of an
+	// incident as a single message that is edited as the incident progresses.
+	IncidentID string `json:"incident_id,omitempty"`
+
+	// IncidentStatus is the stage of the incident the alert reports.
+	IncidentStatus IncidentStatus `json:"incident_status,omitempty"`
+}
+
+// Field is a name and value pair shown with an alert.
+type Field struct {
+	// Name is the name of the field.
+	Name string `json:"name"`
+
+	// Value is the value of the field.
+	Value string `json:"value"`
+
+	// Inline is true if the field can be shown next to other inline fields.
+	Inline bool `json:"inline,omitempty"`
+}
+
+// NewAlert creates a new Alert that happened now.
+func NewAlert(severity Severity, title, description string) *Alert {
+	return &Alert{
+		ID:          newID(),
+		Title:       title,
+		Description: description,
+		Severity:    severity,
+		Timestamp:   time.Now().UTC(),
+	}
+}
+
+// NewTemplatedAlert creates a new Alert that happened now and is rendered from the named template for each
+// destination.
+func NewTemplatedAlert(severity Severity, template string, data map[string]any) *Alert {
+	return &Alert{
+		ID:        newID(),
+		Severity:  severity,
+		Template:  template,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// WithField adds a field to the alert and returns the alert.
+func (a *Alert) WithField(name, value string, inline bool) *Alert {
+	a.Fields = append(a.Fields, &Field{
+		Name:   name,
+		Value:  value,
+		Inline: inline,
+	})
+	return a
+}
+
+// WithCategory sets the category of the alert and returns the alert.
+func (a *Alert) WithCategory(category Category) *Alert {
+	a.Category = category
+	return a
+}
+
+// WithSource sets what raised the alert and returns the alert.
+func (a *Alert) WithSource(source string) *Alert {
+	a.Source = source
+	return a
+}
+
+// WithIncident makes the alert report the stage of the incident and returns the alert.
+func (a *Alert) WithIncident(incidentID string, status IncidentStatus) *Alert {
+	a.IncidentID = incidentID
+	a.IncidentStatus = status
+	return a
+}
+
+// WithFooter sets the footer of the alert and returns the alert.
+func (a *Alert) WithFooter(footer string) *Alert {
+	a.Footer = footer
+	return a
+}
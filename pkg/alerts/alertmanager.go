@@ -0,0 +1,197 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// alertmanagerSource is the source of the alerts received from Prometheus Alertmanager.
+	alertmanagerSource = "alertmanager"
+
+	// alertmanagerIncidentPrefix prefixes the incident ID of an Alertmanager alert group.
+	alertmanagerIncidentPrefix = "alertmanager:"
+
+	// alertmanagerStatusFiring is the status of a firing Alertmanager alert or group.
+	alertmanagerStatusFiring = "firing"
+
+	// alertmanagerStatusResolved is the status of a resolved Alertmanager alert or group.
+	alertmanagerStatusResolved = "resolved"
+
+	// maxAlertmanagerAlerts is the number of alerts of a group listed in the description.
+	maxAlertmanagerAlerts = 10
+)
+
+// AlertmanagerPayload is the body of a Prometheus Alertmanager webhook. Each payload is a group of alerts.
+//
+// See: https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type AlertmanagerPayload struct {
+	// Version is the version of the payload format.
+	Version string `json:"version"`
+
+	// GroupKey identifies the group the alerts belong to.
+	GroupKey string `json:"groupKey"`
+
+	// TruncatedAlerts is the number of alerts left out of the payload.
+	TruncatedAlerts int `json:"truncatedAlerts"`
+
+	// Status is "firing" while any alert of the group is firing and "resolved" once all are resolved.
+	Status string `json:"status"`
+
+	// Receiver is the name of the receiver the payload was sent to.
+	Receiver string `json:"receiver"`
+
+	// GroupLabels are the labels the alerts are grouped by.
+	GroupLabels map[string]string `json:"groupLabels"`
+
+	// CommonLabels are the labels all the alerts have.
+	CommonLabels map[string]string `json:"commonLabels"`
+
+	// CommonAnnotations are the annotations all the alerts have.
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+
+	// ExternalURL is the URL of the Alertmanager.
+	ExternalURL string `json:"externalURL"`
+
+	// Alerts are the alerts of the group.
+	Alerts []*AlertmanagerAlert `json:"alerts"`
+}
+
+// AlertmanagerAlert is a single alert of an Alertmanager webhook payload.
+type AlertmanagerAlert struct {
+	// Status is "firing" or "resolved".
+	Status string `json:"status"`
+
+	// Labels are the labels of the alert.
+	Labels map[string]string `json:"labels"`
+
+	// Annotations are the annotations of the alert.
+	Annotations map[string]string `json:"annotations"`
+
+	// StartsAt is when the alert started firing.
+	StartsAt time.Time `json:"startsAt"`
+
+	// EndsAt is when the alert was resolved.
+	EndsAt time.Time `json:"endsAt"`
+
+	// GeneratorURL is the URL of the expression that raised the alert.
+	GeneratorURL string `json:"generatorURL"`
+
+	// Fingerprint identifies the alert.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Validate checks the payload can be turned into an alert.
+func (p *AlertmanagerPayload) Validate() error {
+	switch {
+	case p.Version != "4":
+		return fmt.Errorf("unsupported alertmanager payload version: %q", p.Version)
+	case p.GroupKey == "":
+		return errors.New("missing group key")
+	case p.Status != alertmanagerStatusFiring && p.Status != alertmanagerStatusResolved:
+		return fmt.Errorf("unknown alertmanager status: %q", p.Status)
+	}
+	return nil
+}
+
+// Alert returns the group as a single alert. Every payload of a group reports on the same incident, so destinations
+// that support it show the group as one message that ends with the resolved notice.
+func (p *AlertmanagerPayload) Alert() *Alert {
+	firing := 0
+	for _, a := range p.Alerts {
+		if a.Status == alertmanagerStatusFiring {
+			firing++
+		}
+	}
+
+	severity := SeverityOK
+	status := IncidentResolved
+	if p.Status == alertmanagerStatusFiring {
+		status = IncidentOpen
+
+		var err error
+		severity, err = ParseSeverity(strings.ToLower(p.CommonLabels["severity"]))
+		if err != nil || severity == SeverityOK {
+			severity = SeverityWarning
+		}
+	}
+
+	title := p.CommonAnnotations["summary"]
+	if title == "" {
+		title = p.GroupLabels["alertname"]
+	}
+	if title == "" {
+		title = p.CommonLabels["alertname"]
+	}
+	if p.Status == alertmanagerStatusResolved {
+		title = "Resolved: " + title
+	}
+
+	alert := NewAlert(severity, title, p.description()).
+		WithCategory(CategoryHost).
+		WithSource(alertmanagerSource).
+		WithIncident(alertmanagerIncidentPrefix+p.GroupKey, status).
+		WithField("Firing", fmt.Sprintf("%d/%d", firing, len(p.Alerts)+p.TruncatedAlerts), true)
+
+	if instance := p.CommonLabels["instance"]; instance != "" {
+		alert.WithField("Instance", instance, true)
+	}
+
+	if p.Receiver != "" {
+		alert.WithFooter(p.Receiver)
+	}
+
+	return alert
+}
+
+// description lists the alerts of the group, firing alerts first.
+func (p *AlertmanagerPayload) description() string {
+	sb := new(strings.Builder)
+	if desc := p.CommonAnnotations["description"]; desc != "" {
+		sb.WriteString(desc)
+	}
+
+	list := make([]*AlertmanagerAlert, len(p.Alerts))
+	copy(list, p.Alerts)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Status == alertmanagerStatusFiring && list[j].Status != alertmanagerStatusFiring
+	})
+
+	for i, a := range list {
+		if i == maxAlertmanagerAlerts {
+			fmt.Fprintf(sb, "\n… and %d more", len(list)-i+p.TruncatedAlerts)
+			return sb.String()
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+
+		icon := "🔥"
+		if a.Status == alertmanagerStatusResolved {
+			icon = "✅"
+		}
+
+		summary := a.Annotations["summary"]
+		if summary == "" {
+			summary = a.Labels["alertname"]
+		}
+
+		fmt.Fprintf(sb, "%s %s", icon, summary)
+		if instance := a.Labels["instance"]; instance != "" && instance != p.CommonLabels["instance"] {
+			fmt.Fprintf(sb, " on `%s`", instance)
+		}
+		if a.Status == alertmanagerStatusFiring && !a.StartsAt.IsZero() {
+			fmt.Fprintf(sb, " since %s", a.StartsAt.UTC().Format("2006-01-02 15:04 MST"))
+		}
+	}
+
+	if p.TruncatedAlerts > 0 {
+		fmt.Fprintf(sb, "\n… and %d more", p.TruncatedAlerts)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,88 @@
+package alerts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const firingPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"HostHighCpuLoad\"}",
+  "truncatedAlerts": 0,
+  "status": "firing",
+  "receiver": "discord",
+  "groupLabels": {"alertname": "HostHighCpuLoad"},
+  "commonLabels": {"alertname": "HostHighCpuLoad", "severity": "critical"},
+  "commonAnnotations": {"summary": "Host CPU load is high"},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {
+      "status": "resolved",
+      "labels": {"alertname": "HostHighCpuLoad", "instance": "node-2"},
+      "annotations": {"summary": "CPU load above 90%"},
+      "startsAt": "2024-01-01T11:00:00Z",
+      "endsAt": "2024-01-01T11:30:00Z",
+      "fingerprint": "b"
+    },
+    {
+      "status": "firing",
+      "labels": {"alertname": "HostHighCpuLoad", "instance": "node-1"},
+      "annotations": {"summary": "CPU load above 90%"},
+      "startsAt": "2024-01-01T12:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "fingerprint": "a"
+    }
+  ]
+}`
+
+func TestAlertmanagerHandler(t *testing.T) {
+	notifier := &recordingNotifier{name: "outbox"}
+	handler := AlertmanagerHandler(notifier)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/alerts/alertmanager", strings.NewReader(firingPayload)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Len(t, notifier.alerts, 1)
+	firing := notifier.alerts[0]
+	require.Equal(t, "Host CPU load is high", firing.Title)
+	require.Equal(t, SeverityCritical, firing.Severity)
+	require.Equal(t, CategoryHost, firing.Category)
+	require.Equal(t, alertmanagerSource, firing.Source)
+	require.Equal(t, `alertmanager:{}:{alertname="HostHighCpuLoad"}`, firing.IncidentID)
+	require.Equal(t, IncidentOpen, firing.IncidentStatus)
+	require.Equal(t, "1/2", firing.Fields[0].Value)
+	require.Equal(t, "🔥 CPU load above 90% on `node-1` since 2024-01-01 12:00 UTC\n✅ CPU load above 90% on `node-2`", firing.Description)
+
+	resolvedPayload := strings.ReplaceAll(firingPayload, `"status": "firing"`, `"status": "resolved"`)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/alerts/alertmanager", strings.NewReader(resolvedPayload)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Len(t, notifier.alerts, 2)
+	resolved := notifier.alerts[1]
+	require.Equal(t, "Resolved: Host CPU load is high", resolved.Title)
+	require.Equal(t, SeverityOK, resolved.Severity)
+	require.Equal(t, firing.IncidentID, resolved.IncidentID)
+	require.Equal(t, IncidentResolved, resolved.IncidentStatus)
+	require.Equal(t, "0/2", resolved.Fields[0].Value)
+}
+
+func TestAlertmanagerHandler_InvalidPayload(t *testing.T) {
+	notifier := &recordingNotifier{name: "outbox"}
+	handler := AlertmanagerHandler(notifier)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/alerts/alertmanager", strings.NewReader(`{"version":"3"}`)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/alerts/alertmanager", strings.NewReader(`not json`)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	require.Empty(t, notifier.alerts)
+}
@@ -0,0 +1,47 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Jacobbrewer1/goredis"
+)
+
+// DefaultBotQueue is the redis list the bot reads the alerts it posts from.
+const DefaultBotQueue = "alerts:bot"
+
+type botNotifier struct {
+	name  string
+	queue string
+}
+
+// NewBotNotifier creates a new notifier that hands alerts to the bot through the redis list. The bot posts them with
+// buttons to acknowledge and resolve them.
+func NewBotNotifier(name, queue string) Notifier {
+	if queue == "" {
+		queue = DefaultBotQueue
+	}
+
+	return &botNotifier{
+		name:  name,
+		queue: queue,
+	}
+}
+
+func (b *botNotifier) Name() string {
+	return b.name
+}
+
+func (b *botNotifier) Notify(ctx context.Context, alert *Alert) error {
+	raw, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshal alert: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "RPUSH", b.queue, raw); err != nil {
+		return fmt.Errorf("queue alert for bot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,358 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// NotifierTypeDiscord is the type of discord webhook destinations.
+	NotifierTypeDiscord = "discord"
+
+	// NotifierTypeSlack is the type of slack incoming webhook destinations.
+	NotifierTypeSlack = "slack"
+
+	// NotifierTypeWebhook is the type of generic JSON webhook destinations.
+	NotifierTypeWebhook = "webhook"
+
+	// NotifierTypeEmail is the type of SMTP email destinations.
+	NotifierTypeEmail = "email"
+
+	// NotifierTypeBot is the type of destinations that hand alerts to the bot to post with acknowledge buttons.
+	NotifierTypeBot = "bot"
+)
+
+const (
+	// digestHourly is the configured value of an hourly email digest.
+	digestHourly = "hourly"
+
+	// digestDaily is the configured value of a daily email digest.
+	digestDaily = "daily"
+)
+
+// SecretLookup returns the secret stored under the key.
+type SecretLookup func(key string) (string, bool)
+
+// NotifierConfig is the configuration of a single destination.
+type NotifierConfig struct {
+	// Name is the unique name of the destination.
+	Name string `mapstructure:"name"`
+
+	// Type is the type of the destination.
+	Type string `mapstructure:"type"`
+
+	// URL is the webhook URL.
+	URL string `mapstructure:"url"`
+
+	// URLSecret is the key of the secret holding the webhook URL. It is used when URL is empty.
+	URLSecret string `mapstructure:"url_secret"`
+
+	// SigningSecret is the key of the secret used to sign generic webhook requests.
+	SigningSecret string `mapstructure:"signing_secret"`
+
+	// Template is the body template of a generic webhook.
+	Template string `mapstructure:"template"`
+
+	// Headers are additional headers sent with generic webhook requests.
+	Headers map[string]string `mapstructure:"headers"`
+
+	// SMTP is the configuration of an email destination.
+	SMTP *SMTPConfig `mapstructure:"smtp"`
+
+	// QuietHours is the daily period in which the destination only receives critical alerts.
+	QuietHours *QuietHoursConfig `mapstructure:"quiet_hours"`
+
+	// Locale is the locale the alerts sent to the destination are rendered in, such as "de".
+	Locale string `mapstructure:"locale"`
+
+	// Queue is the redis list a bot destination hands alerts to the bot through.
+	Queue string `mapstructure:"queue"`
+}
+
+// QuietHoursConfig is the configuration of the quiet hours of a destination.
+type QuietHoursConfig struct {
+	// Start is the "15:04" formatted time the quiet hours start.
+	Start string `mapstructure:"start"`
+
+	// End is the "15:04" formatted time the quiet hours end.
+	End string `mapstructure:"end"`
+
+	// Timezone is the IANA name of the time zone of the start and end, such as "Europe/Berlin".
+	Timezone string `mapstructure:"timezone"`
+}
+
+// RouteConfig is the configuration of a route.
+type RouteConfig struct {
+	// Destinations are the names of the notifiers the matched alerts are sent to.
+	Destinations []string `mapstructure:"destinations"`
+
+	// Categories are the categories of the alerts that are matched.
+	Categories []string `mapstructure:"categories"`
+
+	// MinSeverity is the least important severity that is matched.
+	MinSeverity string `mapstructure:"min_severity"`
+}
+
+// SMTPConfig is the configuration of an email destination.
+type SMTPConfig struct {
+	// Host is the host of the SMTP server.
+	Host string `mapstructure:"host"`
+
+	// Port is the port of the SMTP server.
+	Port int `mapstructure:"port"`
+
+	// Username is the username to authenticate with.
+	Username string `mapstructure:"username"`
+
+	// PasswordSecret is the key of the secret holding the password.
+	PasswordSecret string `mapstructure:"password_secret"`
+
+	// From is the sender address.
+	From string `mapstructure:"from"`
+
+	// To are the recipient addresses.
+	To []string `mapstructure:"to"`
+
+	// RequireStartTLS fails delivery if the server does not support STARTTLS.
+	RequireStartTLS bool `mapstructure:"require_starttls"`
+
+	// Digest is how often the digest is sent. It is "hourly", "daily" or a duration.
+	Digest string `mapstructure:"digest"`
+}
+
+// DestinationNames returns the names of the destinations configured under "alerts.notifiers", or the name of the
+// discord webhook destination used when none are configured.
+func DestinationNames(v *viper.Viper) ([]string, error) {
+	if !v.IsSet("alerts.notifiers") {
+		return []string{defaultDestination}, nil
+	}
+
+	configs := make([]*NotifierConfig, 0)
+	if err := v.UnmarshalKey("alerts.notifiers", &configs); err != nil {
+		return nil, fmt.Errorf("unmarshal notifier config: %w", err)
+	}
+
+	names := make([]string, 0, len(configs))
+	for _, c := range configs {
+		names = append(names, c.Name)
+	}
+	return names, nil
+}
+
+// FromViper creates a Manager from the destinations configured under "alerts.notifiers", the routes configured
+// under "alerts.routes" and the templates configured under "alerts.templates.<locale>.<name>". Every alert is sent to
+// every destination when no routes are configured.
+func FromViper(v *viper.Viper, secrets SecretLookup, opts ...ManagerOption) (Manager, error) {
+	configs := make([]*NotifierConfig, 0)
+	if err := v.UnmarshalKey("alerts.notifiers", &configs); err != nil {
+		return nil, fmt.Errorf("unmarshal notifier config: %w", err)
+	} else if len(configs) == 0 {
+		return nil, errors.New("no notifiers configured")
+	}
+
+	names := make(map[string]struct{}, len(configs))
+	notifiers := make([]Notifier, 0, len(configs))
+	for i, c := range configs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("notifier %d has no name", i)
+		} else if _, ok := names[c.Name]; ok {
+			return nil, fmt.Errorf("duplicate notifier name: %s", c.Name)
+		}
+		names[c.Name] = struct{}{}
+
+		n, err := newNotifier(c, secrets)
+		if err != nil {
+			return nil, fmt.Errorf("create notifier %s: %w", c.Name, err)
+		}
+		notifiers = append(notifiers, n)
+
+		if c.QuietHours != nil {
+			qh, err := NewQuietHours(c.QuietHours.Start, c.QuietHours.End, c.QuietHours.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("quiet hours of notifier %s: %w", c.Name, err)
+			}
+			opts = append(opts, WithQuietHours(c.Name, qh))
+		}
+
+		if c.Locale != "" {
+			// Viper lower cases the locales the templates are configured under.
+			opts = append(opts, WithLocale(c.Name, strings.ToLower(c.Locale)))
+		}
+	}
+
+	templates, err := templatesFromViper(v)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithTemplates(templates))
+
+	routeConfigs := make([]*RouteConfig, 0)
+	if err := v.UnmarshalKey("alerts.routes", &routeConfigs); err != nil {
+		return nil, fmt.Errorf("unmarshal route config: %w", err)
+	}
+
+	routes := make([]*Route, 0, len(routeConfigs))
+	for i, c := range routeConfigs {
+		r, err := newRoute(c, names)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %w", i, err)
+		}
+		routes = append(routes, r)
+	}
+
+	if len(routes) > 0 {
+		opts = append(opts, WithRoutes(routes...))
+	}
+
+	return NewManager(notifiers, opts...), nil
+}
+
+// templatesFromViper returns the built-in templates with the configured templates added.
+func templatesFromViper(v *viper.Viper) (*Templates, error) {
+	configs := make(map[string]map[string]*MessageTemplate)
+	if err := v.UnmarshalKey("alerts.templates", &configs); err != nil {
+		return nil, fmt.Errorf("unmarshal template config: %w", err)
+	}
+
+	templates := NewTemplates()
+	for locale, named := range configs {
+		for name, mt := range named {
+			if err := templates.Add(locale, name, mt); err != nil {
+				return nil, fmt.Errorf("template %s.%s: %w", locale, name, err)
+			}
+		}
+	}
+
+	return templates, nil
+}
+
+func newRoute(c *RouteConfig, names map[string]struct{}) (*Route, error) {
+	if len(c.Destinations) == 0 {
+		return nil, errors.New("no destinations configured")
+	}
+
+	for _, d := range c.Destinations {
+		if _, ok := names[d]; !ok {
+			return nil, fmt.Errorf("unknown destination: %s", d)
+		}
+	}
+
+	r := &Route{
+		Destinations: c.Destinations,
+		Categories:   make([]Category, 0, len(c.Categories)),
+	}
+
+	for _, cat := range c.Categories {
+		if !slices.Contains(Categories, Category(cat)) {
+			return nil, fmt.Errorf("unknown category: %s", cat)
+		}
+		r.Categories = append(r.Categories, Category(cat))
+	}
+
+	if c.MinSeverity != "" {
+		severity, err := ParseSeverity(c.MinSeverity)
+		if err != nil {
+			return nil, err
+		}
+		r.MinSeverity = severity
+	}
+
+	return r, nil
+}
+
+func newNotifier(c *NotifierConfig, secrets SecretLookup) (Notifier, error) {
+	switch c.Type {
+	case NotifierTypeEmail:
+		return newEmailNotifier(c, secrets)
+	case NotifierTypeBot:
+		return NewBotNotifier(c.Name, c.Queue), nil
+	}
+
+	url := c.URL
+	if url == "" && c.URLSecret != "" {
+		got, ok := secrets(c.URLSecret)
+		if !ok {
+			return nil, fmt.Errorf("secret not found: %s", c.URLSecret)
+		}
+		url = got
+	}
+
+	if url == "" {
+		return nil, errors.New("no url configured")
+	}
+
+	switch c.Type {
+	case NotifierTypeDiscord:
+		return NewDiscordNotifier(c.Name, url), nil
+	case NotifierTypeSlack:
+		return NewSlackNotifier(c.Name, url), nil
+	case NotifierTypeWebhook:
+		signingSecret := ""
+		if c.SigningSecret != "" {
+			got, ok := secrets(c.SigningSecret)
+			if !ok {
+				return nil, fmt.Errorf("secret not found: %s", c.SigningSecret)
+			}
+			signingSecret = got
+		}
+		return NewWebhookNotifier(c.Name, url, c.Template, signingSecret, c.Headers)
+	default:
+		return nil, fmt.Errorf("unknown notifier type: %s", c.Type)
+	}
+}
+
+func newEmailNotifier(c *NotifierConfig, secrets SecretLookup) (Notifier, error) {
+	if c.SMTP == nil {
+		return nil, errors.New("no smtp configuration")
+	}
+
+	switch {
+	case c.SMTP.Host == "":
+		return nil, errors.New("no smtp host configured")
+	case c.SMTP.From == "":
+		return nil, errors.New("no sender configured")
+	case len(c.SMTP.To) == 0:
+		return nil, errors.New("no recipients configured")
+	}
+
+	cfg := &EmailConfig{
+		Host:            c.SMTP.Host,
+		Port:            c.SMTP.Port,
+		Username:        c.SMTP.Username,
+		From:            c.SMTP.From,
+		To:              c.SMTP.To,
+		RequireStartTLS: c.SMTP.RequireStartTLS,
+	}
+
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+
+	if c.SMTP.PasswordSecret != "" {
+		got, ok := secrets(c.SMTP.PasswordSecret)
+		if !ok {
+			return nil, fmt.Errorf("secret not found: %s", c.SMTP.PasswordSecret)
+		}
+		cfg.Password = got
+	}
+
+	switch c.SMTP.Digest {
+	case "", digestDaily:
+		cfg.DigestInterval = DigestDaily
+	case digestHourly:
+		cfg.DigestInterval = DigestHourly
+	default:
+		interval, err := time.ParseDuration(c.SMTP.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest interval: %w", err)
+		}
+		cfg.DigestInterval = interval
+	}
+
+	return NewEmailNotifier(c.Name, cfg), nil
+}
@@ -0,0 +1,294 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/bwmarrin/discordgo"
+)
+
+type DiscordManager interface {
+	Notifier
+
+	// SendDiscordAlert sends a plain text message.
+	SendDiscordAlert(message string) error
+
+	// SendAlert sends the alert rendered as an embed.
+	SendAlert(alert *Alert) error
+}
+
+// defaultDestination is the name of the discord webhook destination used when no destinations are configured.
+const defaultDestination = "discord"
+
+// errUnknownMessage is returned when the message being edited was deleted.
+var errUnknownMessage = errors.New("unknown message")
+
+type discordPayload struct {
+	Content string                    `json:"content,omitempty"`
+	Embeds  []*discordgo.MessageEmbed `json:"embeds,omitempty"`
+}
+
+type discordManager struct {
+	name       string
+	webhookURL string
+	client     *http.Client
+	incidents  IncidentStore
+
+	// mut guards blockedUntil.
+	mut sync.Mutex
+
+	// blockedUntil is when the rate limit bucket of the webhook resets after it was exhausted.
+	blockedUntil time.Time
+}
+
+func NewDiscordManager(webhookURL string) DiscordManager {
+	return NewDiscordNotifier(defaultDestination, webhookURL)
+}
+
+// NewDiscordNotifier creates a new notifier that posts to a discord webhook.
+func NewDiscordNotifier(name, webhookURL string) DiscordManager {
+	return &discordManager{
+		name:       name,
+		webhookURL: webhookURL,
+		client:     newHTTPClient(),
+		incidents:  NewIncidentStore(),
+	}
+}
+
+func (d *discordManager) Name() string {
+	return d.name
+}
+
+func (d *discordManager) SendDiscordAlert(message string) error {
+	return d.send(context.Background(), http.MethodPost, d.webhookURL, &discordPayload{
+		Content: message,
+	}, nil)
+}
+
+func (d *discordManager) SendAlert(alert *Alert) error {
+	return d.Notify(context.Background(), alert)
+}
+
+func (d *discordManager) Notify(ctx context.Context, alert *Alert) error {
+	if alert.IncidentID != "" {
+		return d.notifyIncident(ctx, alert)
+	}
+
+	return d.send(ctx, http.MethodPost, d.webhookURL, &discordPayload{
+		Embeds: []*discordgo.MessageEmbed{DiscordEmbed(alert)},
+	}, nil)
+}
+
+// notifyIncident posts the first alert of an incident and edits that message with every later alert, until the
+// incident is resolved.
+func (d *discordManager) notifyIncident(ctx context.Context, alert *Alert) error {
+	incident, err := d.incidents.Get(ctx, d.name, alert.IncidentID)
+	if err != nil {
+		return fmt.Errorf("get incident: %w", err)
+	}
+
+	embed := DiscordEmbed(alert)
+
+	if incident == nil {
+		if alert.IncidentStatus == IncidentResolved {
+			// The incident was never shown, so there is nothing to edit.
+			return d.send(ctx, http.MethodPost, d.webhookURL, &discordPayload{
+				Embeds: []*discordgo.MessageEmbed{embed},
+			}, nil)
+		}
+
+		postURL, err := withQuery(d.webhookURL, "wait", "true")
+		if err != nil {
+			return fmt.Errorf("build webhook url: %w", err)
+		}
+
+		msg := new(discordgo.Message)
+		if err := d.send(ctx, http.MethodPost, postURL, &discordPayload{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		}, msg); err != nil {
+			return err
+		}
+
+		err = d.incidents.Put(ctx, d.name, &Incident{
+			ID:        alert.IncidentID,
+			MessageID: msg.ID,
+			OpenedAt:  alert.Timestamp,
+			UpdatedAt: alert.Timestamp,
+		})
+		if err != nil {
+			// The message was posted, so don't fail the delivery and have it posted again.
+			slog.Error("Error storing incident", slog.String("incident", alert.IncidentID), slog.String(logging.KeyError, err.Error()))
+		}
+		return nil
+	}
+
+	if alert.IncidentStatus == IncidentResolved {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Duration",
+			Value:  alert.Timestamp.Sub(incident.OpenedAt).Round(time.Second).String(),
+			Inline: true,
+		})
+	}
+
+	editURL, err := webhookMessageURL(d.webhookURL, incident.MessageID)
+	if err != nil {
+		return fmt.Errorf("build webhook message url: %w", err)
+	}
+
+	err = d.send(ctx, http.MethodPatch, editURL, &discordPayload{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	}, nil)
+	if errors.Is(err, errUnknownMessage) {
+		// The message was deleted, so forget it and show the incident in a new message.
+		slog.Warn("Incident message was deleted, posting it again", slog.String("incident", alert.IncidentID))
+		if err := d.incidents.Close(ctx, d.name, alert.IncidentID); err != nil {
+			return fmt.Errorf("forget deleted incident message: %w", err)
+		}
+		return d.notifyIncident(ctx, alert)
+	} else if err != nil {
+		return err
+	}
+
+	if alert.IncidentStatus == IncidentResolved {
+		return d.incidents.Close(ctx, d.name, alert.IncidentID)
+	}
+
+	incident.UpdatedAt = alert.Timestamp
+	return d.incidents.Put(ctx, d.name, incident)
+}
+
+// send sends the payload to the URL, decoding the response into out when it is not nil.
+func (d *discordManager) send(ctx context.Context, method, target string, bdy *discordPayload, out any) error {
+	d.mut.Lock()
+	wait := time.Until(d.blockedUntil)
+	d.mut.Unlock()
+	if wait > 0 {
+		return &RateLimitError{RetryAfter: wait}
+	}
+
+	bdyBytes, err := json.Marshal(bdy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discord payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewBuffer(bdyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create discord request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send discord request: %w", err)
+	}
+	defer closeBody(resp)
+
+	d.trackRateLimit(resp)
+
+	if err := rateLimited(resp); err != nil {
+		return err
+	} else if resp.StatusCode == http.StatusNotFound && isUnknownMessage(resp) {
+		return fmt.Errorf("discord request failed: %s: %w", resp.Status, errUnknownMessage)
+	} else if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discord request failed: %s", resp.Status)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode discord response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// isUnknownMessage returns true if the response says the message doesn't exist, rather than the webhook.
+func isUnknownMessage(resp *http.Response) bool {
+	apiErr := new(discordgo.APIErrorMessage)
+	if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil {
+		return false
+	}
+	return apiErr.Code == discordgo.ErrCodeUnknownMessage
+}
+
+// withQuery returns the URL with the query parameter set.
+func withQuery(rawURL, key, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// webhookMessageURL returns the URL of a message sent by the webhook, keeping any query parameters of the webhook URL.
+func webhookMessageURL(webhookURL, messageID string) (string, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", err
+	}
+
+	u = u.JoinPath("messages", messageID)
+	return u.String(), nil
+}
+
+// trackRateLimit stops requests being sent until the rate limit bucket resets once the response says it is exhausted.
+func (d *discordManager) trackRateLimit(resp *http.Response) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" && resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+
+	d.mut.Lock()
+	d.blockedUntil = time.Now().Add(retryAfter(resp))
+	d.mut.Unlock()
+}
+
+// DiscordEmbed renders the alert as a discord embed.
+func DiscordEmbed(alert *Alert) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Type:        discordgo.EmbedTypeRich,
+		Title:       alert.Title,
+		Description: alert.Description,
+		Color:       alert.Severity.Colour(),
+	}
+
+	if !alert.Timestamp.IsZero() {
+		embed.Timestamp = alert.Timestamp.Format(time.RFC3339)
+	}
+
+	if alert.Footer != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text: alert.Footer,
+		}
+	}
+
+	if alert.ThumbnailURL != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{
+			URL: alert.ThumbnailURL,
+		}
+	}
+
+	for _, f := range alert.Fields {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   f.Name,
+			Value:  f.Value,
+			Inline: f.Inline,
+		})
+	}
+
+	return embed
+}
@@ -0,0 +1,204 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscordNotifier_Notify(t *testing.T) {
+	var got discordPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &got))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	alert := NewAlert(SeverityCritical, "Server state changed", "`running` → `exited`").
+		WithField("Status", "Exited (1)", true).
+		WithFooter("satisfactory")
+	alert.Timestamp = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	alert.ThumbnailURL = "https://example.com/thumb.png"
+
+	err := NewDiscordNotifier("discord", srv.URL).Notify(context.Background(), alert)
+	require.NoError(t, err)
+
+	require.Empty(t, got.Content)
+	require.Equal(t, []*discordgo.MessageEmbed{
+		{
+			Type:        discordgo.EmbedTypeRich,
+			Title:       "Server state changed",
+			Description: "`running` → `exited`",
+			Color:       SeverityCritical.Colour(),
+			Timestamp:   "2024-01-01T12:00:00Z",
+			Footer:      &discordgo.MessageEmbedFooter{Text: "satisfactory"},
+			Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: "https://example.com/thumb.png"},
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Status", Value: "Exited (1)", Inline: true},
+			},
+		},
+	}, got.Embeds)
+}
+
+func TestDiscordManager_SendDiscordAlert(t *testing.T) {
+	var got discordPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	err := NewDiscordManager(srv.URL).SendDiscordAlert("hello")
+	require.NoError(t, err)
+	require.Equal(t, "hello", got.Content)
+	require.Empty(t, got.Embeds)
+}
+
+func TestDiscordNotifier_NotifyFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := NewDiscordNotifier("discord", srv.URL).Notify(context.Background(), NewAlert(SeverityInfo, "title", ""))
+	require.EqualError(t, err, "discord request failed: 400 Bad Request")
+}
+
+func TestDiscordNotifier_RateLimited(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset-After", "60")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := NewDiscordNotifier("discord", srv.URL)
+	require.NoError(t, n.Notify(context.Background(), NewAlert(SeverityInfo, "title", "")))
+
+	// The bucket is exhausted, so the next alert is not sent until it resets.
+	err := n.Notify(context.Background(), NewAlert(SeverityInfo, "title", ""))
+	rateLimitErr := new(RateLimitError)
+	require.ErrorAs(t, err, &rateLimitErr)
+	require.InDelta(t, time.Minute, rateLimitErr.RetryAfter, float64(time.Second))
+	require.Equal(t, 1, requests)
+}
+
+func TestDiscordNotifier_TooManyRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	err := NewDiscordNotifier("discord", srv.URL).Notify(context.Background(), NewAlert(SeverityInfo, "title", ""))
+	rateLimitErr := new(RateLimitError)
+	require.ErrorAs(t, err, &rateLimitErr)
+	require.Equal(t, 2*time.Second, rateLimitErr.RetryAfter)
+}
+
+// memoryIncidents is an IncidentStore kept in memory.
+type memoryIncidents map[string]*Incident
+
+func (m memoryIncidents) Get(_ context.Context, destination, incidentID string) (*Incident, error) {
+	return m[destination+"/"+incidentID], nil
+}
+
+func (m memoryIncidents) Put(_ context.Context, destination string, incident *Incident) error {
+	m[destination+"/"+incident.ID] = incident
+	return nil
+}
+
+func (m memoryIncidents) Close(_ context.Context, destination, incidentID string) error {
+	delete(m, destination+"/"+incidentID)
+	return nil
+}
+
+func TestDiscordNotifier_Incident(t *testing.T) {
+	var requests []string
+	var last discordPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+
+		last = discordPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&last))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"id":"123"}`))
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	incidents := make(memoryIncidents)
+	notifier := NewDiscordNotifier("discord", srv.URL+"/webhooks/1/token").(*discordManager)
+	notifier.incidents = incidents
+
+	openedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	notify := func(title string, status IncidentStatus, at time.Time) {
+		alert := NewAlert(SeverityCritical, title, "").WithIncident("container-down", status)
+		alert.Timestamp = at
+		require.NoError(t, notifier.Notify(context.Background(), alert))
+	}
+
+	notify("Server exited", IncidentOpen, openedAt)
+	require.Equal(t, []string{"POST /webhooks/1/token?wait=true"}, requests)
+	require.Equal(t, "123", incidents["discord/container-down"].MessageID)
+
+	notify("Server restarting", IncidentOpen, openedAt.Add(time.Minute))
+	require.Equal(t, "PATCH /webhooks/1/token/messages/123?", requests[1])
+	require.Equal(t, "Server restarting", last.Embeds[0].Title)
+
+	notify("Server running", IncidentResolved, openedAt.Add(5*time.Minute))
+	require.Equal(t, "PATCH /webhooks/1/token/messages/123?", requests[2])
+	require.Len(t, requests, 3)
+
+	fields := last.Embeds[0].Fields
+	require.Equal(t, "Duration", fields[len(fields)-1].Name)
+	require.Equal(t, "5m0s", fields[len(fields)-1].Value)
+	require.Empty(t, incidents)
+}
+
+func TestDiscordNotifier_IncidentMessageDeleted(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPatch {
+			w.WriteHeader(http.StatusNotFound)
+			_, err := w.Write([]byte(`{"message":"Unknown Message","code":10008}`))
+			require.NoError(t, err)
+			return
+		}
+
+		_, err := w.Write([]byte(`{"id":"456"}`))
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	incidents := memoryIncidents{
+		"discord/container-down": {ID: "container-down", MessageID: "123"},
+	}
+	notifier := NewDiscordNotifier("discord", srv.URL+"/webhooks/1/token").(*discordManager)
+	notifier.incidents = incidents
+
+	alert := NewAlert(SeverityCritical, "Server restarting", "").WithIncident("container-down", IncidentOpen)
+	require.NoError(t, notifier.Notify(context.Background(), alert))
+
+	require.Equal(t, []string{"PATCH /webhooks/1/token/messages/123", "POST /webhooks/1/token"}, requests)
+	require.Equal(t, "456", incidents["discord/container-down"].MessageID, "the incident must move to the new message")
+}
@@ -0,0 +1,383 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// DigestHourly sends the digest every hour.
+	DigestHourly = time.Hour
+
+	// DigestDaily sends the digest every day.
+	DigestDaily = 24 * time.Hour
+
+	// defaultSMTPTimeout is how long sending an email can take when the context has no deadline.
+	defaultSMTPTimeout = 30 * time.Second
+
+	// digestKeyPrefix prefixes the redis list of the alerts waiting for the digest of each email destination, oldest
+	// first, so they survive a restart.
+	digestKeyPrefix = "alerts:digest:"
+)
+
+var (
+	// ErrStartTLSUnsupported is returned when STARTTLS is required but the server does not support it.
+	ErrStartTLSUnsupported = errors.New("smtp server does not support STARTTLS")
+)
+
+// EmailConfig is the configuration of an email destination.
+type EmailConfig struct {
+	// Host is the host of the SMTP server.
+	Host string
+
+	// Port is the port of the SMTP server.
+	Port int
+
+	// Username is the username to authenticate with. No authentication is done when it is empty.
+	Username string
+
+	// Password is the password to authenticate with.
+	Password string
+
+	// From is the sender address.
+	From string
+
+	// To are the recipient addresses.
+	To []string
+
+	// RequireStartTLS fails delivery if the server does not support STARTTLS. STARTTLS is always used when the server
+	// supports it.
+	RequireStartTLS bool
+
+	// DigestInterval is how often the digest of the alerts below critical severity is sent.
+	DigestInterval time.Duration
+}
+
+// EmailNotifier sends critical alerts as they happen and batches everything else into a digest.
+type EmailNotifier interface {
+	Notifier
+
+	// Run sends the digest every digest interval until the context is done, when the remaining alerts are sent.
+	Run(ctx context.Context)
+
+	// Flush sends the digest of the pending alerts now. The alerts are kept for the next digest if it can't be sent.
+	Flush(ctx context.Context) error
+}
+
+type emailNotifier struct {
+	name      string
+	cfg       *EmailConfig
+	tlsConfig *tls.Config
+	now       func() time.Time
+
+	// mut stops two digests being sent at once, which would send the pending alerts twice.
+	mut sync.Mutex
+}
+
+// NewEmailNotifier creates a new notifier that sends email over SMTP.
+func NewEmailNotifier(name string, cfg *EmailConfig) EmailNotifier {
+	if cfg.DigestInterval <= 0 {
+		cfg.DigestInterval = DigestDaily
+	}
+
+	return &emailNotifier{
+		name: name,
+		cfg:  cfg,
+		tlsConfig: &tls.Config{
+			ServerName: cfg.Host,
+			MinVersion: tls.VersionTLS12,
+		},
+		now: time.Now,
+	}
+}
+
+func (e *emailNotifier) Name() string {
+	return e.name
+}
+
+func (e *emailNotifier) Notify(ctx context.Context, alert *Alert) error {
+	if alert.Severity != SeverityCritical {
+		raw, err := json.Marshal(alert)
+		if err != nil {
+			return fmt.Errorf("marshal alert: %w", err)
+		}
+
+		if _, err := goredis.DoCtx(ctx, "RPUSH", digestKeyPrefix+e.name, raw); err != nil {
+			return fmt.Errorf("store digest alert: %w", err)
+		}
+		return nil
+	}
+
+	msg, err := e.message(fmt.Sprintf("[%s] %s", strings.ToUpper(string(alert.Severity)), alert.Title), []*Alert{alert})
+	if err != nil {
+		return fmt.Errorf("build message: %w", err)
+	}
+
+	return e.send(ctx, msg)
+}
+
+func (e *emailNotifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.DigestInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The parent context is done, so give the final digest its own deadline.
+			flushCtx, cancel := context.WithTimeout(context.Background(), defaultHTTPTimeout)
+			if err := e.Flush(flushCtx); err != nil {
+				slog.Error("Error sending final email digest", slog.String(logging.KeyError, err.Error()))
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := e.Flush(ctx); err != nil {
+				slog.Error("Error sending email digest", slog.String(logging.KeyError, err.Error()))
+			}
+		}
+	}
+}
+
+func (e *emailNotifier) Flush(ctx context.Context) error {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	key := digestKeyPrefix + e.name
+	raw, err := redisgo.ByteSlices(goredis.DoCtx(ctx, "LRANGE", key, 0, -1))
+	if err != nil {
+		return fmt.Errorf("get digest alerts: %w", err)
+	} else if len(raw) == 0 {
+		return nil
+	}
+
+	pending := make([]*Alert, 0, len(raw))
+	for _, r := range raw {
+		alert := new(Alert)
+		if err := json.Unmarshal(r, alert); err != nil {
+			slog.Error("Error unmarshalling digest alert", slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+		pending = append(pending, alert)
+	}
+
+	if len(pending) > 0 {
+		msg, err := e.message(fmt.Sprintf("Satisfactory digest: %d %s", len(pending), plural(len(pending), "alert", "alerts")), pending)
+		if err != nil {
+			return fmt.Errorf("build digest: %w", err)
+		}
+
+		// The alerts stay in the list until they are sent, so they are in the next digest if this one fails.
+		if err := e.send(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	// Only the sent alerts are removed, the alerts added while the digest was sent wait for the next one.
+	if _, err := goredis.DoCtx(ctx, "LTRIM", key, len(raw), -1); err != nil {
+		return fmt.Errorf("remove sent digest alerts: %w", err)
+	}
+
+	return nil
+}
+
+// send delivers the message to every recipient.
+func (e *emailNotifier) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+
+	dialer := new(net.Dialer)
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("dial smtp server: %w", err)
+	}
+	// Closing the client closes the connection too, but the client isn't created on every path.
+	defer func(conn net.Conn) {
+		if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			slog.Debug("Error closing smtp connection", slog.String(logging.KeyError, err.Error()))
+		}
+	}(conn)
+
+	// A server that stops responding would otherwise hold up delivery forever.
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultSMTPTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("set deadline: %w", err)
+	}
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("create smtp client: %w", err)
+	}
+	defer func(c *smtp.Client) {
+		if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			slog.Debug("Error closing smtp client", slog.String(logging.KeyError, err.Error()))
+		}
+	}(c)
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(e.tlsConfig); err != nil {
+			return fmt.Errorf("start tls: %w", err)
+		}
+	} else if e.cfg.RequireStartTLS {
+		return ErrStartTLSUnsupported
+	}
+
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("authenticate: %w", err)
+		}
+	}
+
+	if err := c.Mail(e.cfg.From); err != nil {
+		return fmt.Errorf("set sender: %w", err)
+	}
+
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("add recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("start data: %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("finish data: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// message builds a multipart email holding a plain text and an HTML rendering of the alerts.
+func (e *emailNotifier) message(subject string, alerts []*Alert) ([]byte, error) {
+	data := &emailData{
+		Subject: subject,
+		Alerts:  alerts,
+	}
+
+	text := new(bytes.Buffer)
+	if err := emailTextTemplate.Execute(text, data); err != nil {
+		return nil, fmt.Errorf("render text: %w", err)
+	}
+
+	html := new(bytes.Buffer)
+	if err := emailHTMLTemplate.Execute(html, data); err != nil {
+		return nil, fmt.Errorf("render html: %w", err)
+	}
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{contentType: "text/plain; charset=UTF-8", content: text.Bytes()},
+		{contentType: "text/html; charset=UTF-8", content: html.Bytes()},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create part: %w", err)
+		}
+		if _, err := pw.Write(part.content); err != nil {
+			return nil, fmt.Errorf("write part: %w", err)
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("close multipart writer: %w", err)
+	}
+
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: multipart/alternative; boundary=%q\r\n", mw.Boundary())
+	fmt.Fprintf(msg, "\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+// emailData is the data the email templates are executed with.
+type emailData struct {
+	Subject string
+	Alerts  []*Alert
+}
+
+// plural returns the singular form if n is one and the plural form otherwise.
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+var emailTextTemplate = template.Must(template.New("text").Parse(`{{ .Subject }}
+{{ range .Alerts }}
+[{{ .Severity }}] {{ .Title }} ({{ .Timestamp.Format "2006-01-02 15:04:05 MST" }})
+{{- if .Description }}
+{{ .Description }}
+{{- end }}
+{{- range .Fields }}
+  {{ .Name }}: {{ .Value }}
+{{- end }}
+{{- if .Footer }}
+{{ .Footer }}
+{{- end }}
+{{ end }}`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap{
+	"colour": func(s Severity) string {
+		return fmt.Sprintf("#%06X", s.Colour())
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<h2>{{ .Subject }}</h2>
+{{ range .Alerts }}
+<div style="border-left: 4px solid {{ colour .Severity }}; padding: 4px 12px; margin-bottom: 16px;">
+<h3 style="margin: 4px 0;">{{ .Title }}</h3>
+<p style="color: #777; margin: 0;">{{ .Severity }} &middot; {{ .Timestamp.Format "2006-01-02 15:04:05 MST" }}</p>
+{{ if .Description }}<p>{{ .Description }}</p>{{ end }}
+{{ if .Fields }}<table>
+{{ range .Fields }}<tr><th align="left">{{ .Name }}</th><td>{{ .Value }}</td></tr>
+{{ end }}</table>{{ end }}
+{{ if .Footer }}<p style="color: #777;">{{ .Footer }}</p>{{ end }}
+</div>
+{{ end }}
+</body>
+</html>
+`))
@@ -0,0 +1,381 @@
+package alerts
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// receivedMail is a message accepted by the SMTP stand-in.
+type receivedMail struct {
+	from string
+	to   []string
+	auth string
+	tls  bool
+	data string
+}
+
+// smtpStandIn is an in-process SMTP server that accepts every message.
+type smtpStandIn struct {
+	ln        net.Listener
+	tlsConfig *tls.Config
+
+	mut      sync.Mutex
+	messages []*receivedMail
+}
+
+func newSMTPStandIn(t *testing.T, tlsConfig *tls.Config) *smtpStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &smtpStandIn{
+		ln:        ln,
+		tlsConfig: tlsConfig,
+	}
+	go s.serve()
+
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) received() []*receivedMail {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.messages
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tc := textproto.NewConn(conn)
+	msg := new(receivedMail)
+	_ = tc.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			lines := []string{"localhost"}
+			if s.tlsConfig != nil && !msg.tls {
+				lines = append(lines, "STARTTLS")
+			}
+			lines = append(lines, "AUTH PLAIN", "8BITMIME")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				_ = tc.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			_ = tc.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tc = textproto.NewConn(conn)
+			msg.tls = true
+		case "AUTH":
+			_, initial, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(initial)
+			msg.auth = string(decoded)
+			_ = tc.PrintfLine("235 Authentication successful")
+		case "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			if i := strings.Index(msg.from, ">"); i >= 0 {
+				msg.from = msg.from[:i]
+			}
+			_ = tc.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = tc.PrintfLine("250 OK")
+		case "DATA":
+			_ = tc.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+
+			s.mut.Lock()
+			s.messages = append(s.messages, msg)
+			s.mut.Unlock()
+
+			msg = &receivedMail{tls: msg.tls}
+			_ = tc.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tc.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tc.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// parsedMail is a received message split into its headers and plain text and HTML parts.
+type parsedMail struct {
+	header mail.Header
+	text   string
+	html   string
+}
+
+func parseMail(t *testing.T, data string) *parsedMail {
+	m, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parsed := &parsedMail{header: m.Header}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+
+		body := new(strings.Builder)
+		_, err = bufio.NewReader(part).WriteTo(body)
+		require.NoError(t, err)
+
+		switch {
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain"):
+			parsed.text = body.String()
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/html"):
+			parsed.html = body.String()
+		}
+	}
+
+	return parsed
+}
+
+type EmailSuite struct {
+	suite.Suite
+
+	standIn  *smtpStandIn
+	notifier *emailNotifier
+
+	// digest is the redis list of the alerts waiting for the digest.
+	digest [][]byte
+}
+
+func TestEmailSuite(t *testing.T) {
+	suite.Run(t, new(EmailSuite))
+}
+
+func (s *EmailSuite) SetupTest() {
+	s.digest = nil
+	pool := goredis.NewMockPool(s.T())
+	s.Require().NoError(goredis.NewPool(
+		goredis.WithInitializedPool(pool),
+		goredis.WithAddress("localhost:6379"),
+		goredis.WithNetwork("tcp"),
+	))
+
+	key := digestKeyPrefix + "email"
+	pool.On("DoCtx", mock.Anything, "RPUSH", key, mock.Anything).Maybe().
+		Return(func(_ context.Context, _ string, args ...any) (any, error) {
+			s.digest = append(s.digest, args[1].([]byte))
+			return int64(len(s.digest)), nil
+		})
+	pool.On("DoCtx", mock.Anything, "LRANGE", key, 0, -1).Maybe().
+		Return(func(context.Context, string, ...any) (any, error) {
+			reply := make([]any, len(s.digest))
+			for i, raw := range s.digest {
+				reply[i] = raw
+			}
+			return reply, nil
+		})
+	pool.On("DoCtx", mock.Anything, "LTRIM", key, mock.Anything, -1).Maybe().
+		Return(func(_ context.Context, _ string, args ...any) (any, error) {
+			s.digest = s.digest[args[1].(int):]
+			return "OK", nil
+		})
+
+	// Borrow the self-signed certificate of an httptest server, which is valid for 127.0.0.1.
+	certSrv := httptest.NewUnstartedServer(nil)
+	certSrv.StartTLS()
+	s.T().Cleanup(certSrv.Close)
+
+	s.standIn = newSMTPStandIn(s.T(), &tls.Config{
+		Certificates: certSrv.TLS.Certificates,
+		MinVersion:   tls.VersionTLS12,
+	})
+	s.T().Cleanup(func() { _ = s.standIn.ln.Close() })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certSrv.Certificate())
+
+	s.notifier = NewEmailNotifier("email", &EmailConfig{
+		Host:            "127.0.0.1",
+		Port:            s.standIn.port(),
+		Username:        "watcher",
+		Password:        "s3cret",
+		From:            "watcher@example.com",
+		To:              []string{"admin@example.com", "ops@example.com"},
+		RequireStartTLS: true,
+		DigestInterval:  DigestHourly,
+	}).(*emailNotifier)
+	s.notifier.tlsConfig.RootCAs = roots
+	s.notifier.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+}
+
+func (s *EmailSuite) TestCriticalSentImmediately() {
+	alert := NewAlert(SeverityCritical, "Game stopped running", "Session `Factory` is no longer running").
+		WithField("Players", "0/4", true)
+
+	s.Require().NoError(s.notifier.Notify(context.Background(), alert))
+
+	received := s.standIn.received()
+	s.Require().Len(received, 1)
+	s.True(received[0].tls)
+	s.Equal("\x00watcher\x00s3cret", received[0].auth)
+	s.Equal("watcher@example.com", received[0].from)
+	s.Equal([]string{"admin@example.com", "ops@example.com"}, received[0].to)
+
+	m := parseMail(s.T(), received[0].data)
+	s.Equal("[CRITICAL] Game stopped running", m.header.Get("Subject"))
+	s.Equal("admin@example.com, ops@example.com", m.header.Get("To"))
+	s.Contains(m.text, "[critical] Game stopped running")
+	s.Contains(m.text, "Players: 0/4")
+	s.Contains(m.html, "<h3 style=\"margin: 4px 0;\">Game stopped running</h3>")
+	s.Contains(m.html, "<td>0/4</td>")
+}
+
+func (s *EmailSuite) TestLowerSeverityBatchedIntoDigest() {
+	s.Require().NoError(s.notifier.Notify(context.Background(), NewAlert(SeverityInfo, "Game paused", "")))
+	s.Require().NoError(s.notifier.Notify(context.Background(), NewAlert(SeverityWarning, "Data is stale", "<no data>")))
+	s.Empty(s.standIn.received())
+	s.Len(s.digest, 2, "the alerts wait for the digest in redis, so a restart doesn't lose them")
+
+	s.Require().NoError(s.notifier.Flush(context.Background()))
+
+	received := s.standIn.received()
+	s.Require().Len(received, 1)
+
+	m := parseMail(s.T(), received[0].data)
+	s.Equal("Satisfactory digest: 2 alerts", m.header.Get("Subject"))
+	s.Contains(m.text, "[info] Game paused")
+	s.Contains(m.text, "[warning] Data is stale")
+	s.Contains(m.text, "<no data>")
+	s.Contains(m.html, "&lt;no data&gt;")
+
+	// Nothing is pending, so nothing else is sent.
+	s.Empty(s.digest)
+	s.Require().NoError(s.notifier.Flush(context.Background()))
+	s.Len(s.standIn.received(), 1)
+}
+
+func (s *EmailSuite) TestDigestKeptWhenDeliveryFails() {
+	s.Require().NoError(s.notifier.Notify(context.Background(), NewAlert(SeverityInfo, "Game paused", "")))
+
+	port := s.notifier.cfg.Port
+	s.notifier.cfg.Port = 1
+	s.Require().Error(s.notifier.Flush(context.Background()))
+	s.Len(s.digest, 1)
+
+	s.notifier.cfg.Port = port
+	s.Require().NoError(s.notifier.Flush(context.Background()))
+	s.Len(s.standIn.received(), 1)
+}
+
+func (s *EmailSuite) TestRequireStartTLS() {
+	plain := newSMTPStandIn(s.T(), nil)
+	defer plain.ln.Close()
+
+	s.notifier.cfg.Port = plain.port()
+	err := s.notifier.Notify(context.Background(), NewAlert(SeverityCritical, "Game stopped running", ""))
+	s.Require().ErrorIs(err, ErrStartTLSUnsupported)
+	s.Empty(plain.received())
+}
+
+func (s *EmailSuite) TestSilentServerConnectionClosed() {
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	defer silent.Close()
+
+	// The server never greets the client, so the client can't be created.
+	closed := make(chan struct{})
+	go func() {
+		conn, err := silent.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	s.notifier.cfg.Port = silent.Addr().(*net.TCPAddr).Port
+	s.Require().Error(s.notifier.Notify(ctx, NewAlert(SeverityCritical, "Game stopped running", "")))
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		s.Fail("the connection was not closed")
+	}
+}
+
+func TestNewEmailNotifier_FromConfig(t *testing.T) {
+	n, err := newEmailNotifier(&NotifierConfig{
+		Name: "email",
+		Type: NotifierTypeEmail,
+		SMTP: &SMTPConfig{
+			Host:           "smtp.example.com",
+			Username:       "watcher",
+			PasswordSecret: "smtp_password",
+			From:           "watcher@example.com",
+			To:             []string{"admin@example.com"},
+			Digest:         "hourly",
+		},
+	}, func(key string) (string, bool) {
+		return "s3cret", key == "smtp_password"
+	})
+	require.NoError(t, err)
+
+	cfg := n.(*emailNotifier).cfg
+	require.Equal(t, 587, cfg.Port)
+	require.Equal(t, "s3cret", cfg.Password)
+	require.Equal(t, DigestHourly, cfg.DigestInterval)
+}
@@ -0,0 +1,111 @@
+package alerts
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	uhttp "github.com/Jacobbrewer1/satisfactory/pkg/utils/http"
+)
+
+// maxHistoryLimit is the most alerts returned by a single history request.
+const maxHistoryLimit = 500
+
+// HistoryHandler returns a handler that lists the sent alerts, newest first. The alerts are filtered by the "from" and
+// "to" RFC 3339 times, or the "since" duration, and by the "category". The number returned is set by "limit".
+func HistoryHandler(history HistoryStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := historyFilter(r, time.Now())
+		if err != nil {
+			uhttp.SendErrorMessageWithStatus(w, http.StatusBadRequest, "Invalid history filter", err)
+			return
+		}
+
+		records, err := history.Query(r.Context(), filter)
+		if err != nil {
+			uhttp.SendErrorMessage(w, "Error getting alert history", err)
+			return
+		}
+
+		if err := uhttp.Encode(w, http.StatusOK, records); err != nil {
+			uhttp.SendErrorMessage(w, "Error encoding alert history", err)
+		}
+	})
+}
+
+// historyFilter builds the history filter from the query parameters of the request.
+func historyFilter(r *http.Request, now time.Time) (*HistoryFilter, error) {
+	q := r.URL.Query()
+	filter := new(HistoryFilter)
+
+	if got := q.Get("since"); got != "" {
+		since, err := time.ParseDuration(got)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+		filter.From = now.Add(-since)
+	}
+
+	if got := q.Get("from"); got != "" {
+		from, err := time.Parse(time.RFC3339, got)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+		filter.From = from
+	}
+
+	if got := q.Get("to"); got != "" {
+		to, err := time.Parse(time.RFC3339, got)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to: %w", err)
+		}
+		filter.To = to
+	}
+
+	if got := q.Get("category"); got != "" {
+		if !slices.Contains(Categories, Category(got)) {
+			return nil, fmt.Errorf("unknown category: %s", got)
+		}
+		filter.Category = Category(got)
+	}
+
+	if got := q.Get("limit"); got != "" {
+		limit, err := strconv.Atoi(got)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit: %s", got)
+		}
+		filter.Limit = min(limit, maxHistoryLimit)
+	}
+
+	return filter, nil
+}
+
+// AlertmanagerHandler returns a handler that receives Prometheus Alertmanager webhooks and sends each alert group
+// through the notifier.
+func AlertmanagerHandler(notifier Notifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := new(AlertmanagerPayload)
+		if err := uhttp.DecodeJSONBody(r, payload); err != nil {
+			uhttp.SendErrorMessageWithStatus(w, http.StatusBadRequest, "Invalid alertmanager payload", err)
+			return
+		}
+
+		if err := payload.Validate(); err != nil {
+			uhttp.SendErrorMessageWithStatus(w, http.StatusBadRequest, "Invalid alertmanager payload", err)
+			return
+		}
+
+		// A failure is returned to Alertmanager, which sends the group again.
+		if err := notifier.Notify(r.Context(), payload.Alert()); err != nil {
+			slog.Error("Error sending alertmanager alert", slog.String("group", payload.GroupKey), slog.String(logging.KeyError, err.Error()))
+			uhttp.SendErrorMessage(w, "Error sending alert", err)
+			return
+		}
+
+		uhttp.SendMessage(w, "Alert received")
+	})
+}
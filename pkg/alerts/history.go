@@ -0,0 +1,309 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// historyKey is the redis sorted set of the IDs of the sent alerts, scored by the unix milliseconds of the alert.
+	historyKey = "alerts:history"
+
+	// historyRecordKeyPrefix prefixes the redis hash of a sent alert. The hash holds the alert and the delivery status
+	// of each of its destinations.
+	historyRecordKeyPrefix = "alerts:history:"
+
+	// historyAlertField is the field of the history hash holding the alert.
+	historyAlertField = "alert"
+
+	// historyDeliveryFieldPrefix prefixes the fields of the history hash holding the delivery status of a destination.
+	historyDeliveryFieldPrefix = "delivery:"
+
+	// DefaultHistoryRetention is how long alerts are kept in the history by default.
+	DefaultHistoryRetention = 30 * 24 * time.Hour
+
+	// defaultHistoryLimit is the number of alerts returned by a history query without a limit.
+	defaultHistoryLimit = 50
+)
+
+// DeliveryStatus is the status of the delivery of an alert to a destination.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is used for deliveries that have not been attempted yet.
+	DeliveryPending DeliveryStatus = "pending"
+
+	// DeliveryDelivered is used for deliveries that were sent.
+	DeliveryDelivered DeliveryStatus = "delivered"
+
+	// DeliveryRetrying is used for deliveries that failed and are waiting to be attempted again.
+	DeliveryRetrying DeliveryStatus = "retrying"
+
+	// DeliveryFailed is used for deliveries that were given up on.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// DeliveryRecord is the delivery status of an alert to a single destination.
+type DeliveryRecord struct {
+	// Status is the status of the delivery.
+	Status DeliveryStatus `json:"status"`
+
+	// Attempts is the number of failed attempts.
+	Attempts int `json:"attempts,omitempty"`
+
+	// LastError is the error of the last failed attempt.
+	LastError string `json:"last_error,omitempty"`
+
+	// UpdatedAt is when the status last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HistoryRecord is a sent alert with the delivery status of each of its destinations.
+type HistoryRecord struct {
+	// Alert is the alert as it was sent, before it was rendered for each destination.
+	Alert *Alert `json:"alert"`
+
+	// Deliveries are the delivery statuses, keyed by the name of the destination.
+	Deliveries map[string]*DeliveryRecord `json:"deliveries"`
+}
+
+// HistoryFilter selects the alerts returned from the history.
+type HistoryFilter struct {
+	// From is the earliest time of the returned alerts. The zero time does not limit the earliest time.
+	From time.Time
+
+	// To is the latest time of the returned alerts. The zero time does not limit the latest time.
+	To time.Time
+
+	// Category limits the returned alerts to the category. An empty category returns alerts of every category.
+	Category Category
+
+	// Limit is the maximum number of alerts returned, newest first.
+	Limit int
+}
+
+// HistoryStore keeps every sent alert, with its delivery status, for the retention period.
+type HistoryStore interface {
+	// Record stores a newly sent alert with a pending delivery to each of the destinations.
+	Record(ctx context.Context, alert *Alert, destinations []string) error
+
+	// UpdateDelivery stores the delivery status of the alert to the destination. It does nothing if the alert is no
+	// longer in the history.
+	UpdateDelivery(ctx context.Context, alertID, destination string, record *DeliveryRecord) error
+
+	// Query returns the alerts matching the filter, newest first.
+	Query(ctx context.Context, filter *HistoryFilter) ([]*HistoryRecord, error)
+}
+
+type historyStore struct {
+	retention time.Duration
+}
+
+// NewHistoryStore creates a new HistoryStore backed by redis that keeps alerts for the retention period.
+func NewHistoryStore(retention time.Duration) HistoryStore {
+	if retention <= 0 {
+		retention = DefaultHistoryRetention
+	}
+
+	return &historyStore{
+		retention: retention,
+	}
+}
+
+func (h *historyStore) Record(ctx context.Context, alert *Alert, destinations []string) error {
+	rawAlert, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshal alert: %w", err)
+	}
+
+	key := historyRecordKeyPrefix + alert.ID
+	args := redisgo.Args{}.Add(key, historyAlertField, rawAlert)
+	for _, dest := range destinations {
+		rawDelivery, err := json.Marshal(&DeliveryRecord{
+			Status:    DeliveryPending,
+			UpdatedAt: alert.Timestamp,
+		})
+		if err != nil {
+			return fmt.Errorf("marshal delivery record: %w", err)
+		}
+		args = args.Add(historyDeliveryFieldPrefix+dest, rawDelivery)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "HSET", args...); err != nil {
+		return fmt.Errorf("store alert history: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "PEXPIRE", key, h.retention.Milliseconds()); err != nil {
+		return fmt.Errorf("set alert history expiry: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "ZADD", historyKey, alert.Timestamp.UnixMilli(), alert.ID); err != nil {
+		return fmt.Errorf("index alert history: %w", err)
+	}
+
+	// The records expire by themselves, only the index has to be pruned.
+	cutoff := strconv.FormatInt(time.Now().Add(-h.retention).UnixMilli(), 10)
+	if _, err := goredis.DoCtx(ctx, "ZREMRANGEBYSCORE", historyKey, "-inf", "("+cutoff); err != nil {
+		return fmt.Errorf("prune alert history: %w", err)
+	}
+
+	return nil
+}
+
+func (h *historyStore) UpdateDelivery(ctx context.Context, alertID, destination string, record *DeliveryRecord) error {
+	key := historyRecordKeyPrefix + alertID
+
+	// Don't recreate an expired record without an expiry.
+	exists, err := redisgo.Bool(goredis.DoCtx(ctx, "EXISTS", key))
+	if err != nil {
+		return fmt.Errorf("check alert history: %w", err)
+	} else if !exists {
+		return nil
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal delivery record: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "HSET", key, historyDeliveryFieldPrefix+destination, raw); err != nil {
+		return fmt.Errorf("store delivery record: %w", err)
+	}
+
+	return nil
+}
+
+func (h *historyStore) Query(ctx context.Context, filter *HistoryFilter) ([]*HistoryRecord, error) {
+	if filter == nil {
+		filter = new(HistoryFilter)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	from, to := "-inf", "+inf"
+	if !filter.From.IsZero() {
+		from = strconv.FormatInt(filter.From.UnixMilli(), 10)
+	}
+	if !filter.To.IsZero() {
+		to = strconv.FormatInt(filter.To.UnixMilli(), 10)
+	}
+
+	// Alerts of other categories are skipped, so keep reading pages of the index until there are enough.
+	records := make([]*HistoryRecord, 0, limit)
+	for offset := 0; len(records) < limit; offset += limit {
+		ids, err := redisgo.Strings(goredis.DoCtx(ctx, "ZREVRANGEBYSCORE", historyKey, to, from, "LIMIT", offset, limit))
+		if err != nil {
+			return nil, fmt.Errorf("get alert history: %w", err)
+		}
+
+		page, err := h.getAll(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range page {
+			if record == nil {
+				// The record expired before the index was pruned.
+				continue
+			} else if filter.Category != "" && record.Alert.Category != filter.Category {
+				continue
+			} else if len(records) >= limit {
+				break
+			}
+
+			records = append(records, record)
+		}
+
+		if len(ids) < limit {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// getAll returns the history records of the alerts, pipelining the reads. The record of an alert that is no longer in
+// the history is nil.
+func (h *historyStore) getAll(ctx context.Context, alertIDs []string) ([]*HistoryRecord, error) {
+	if len(alertIDs) == 0 {
+		return nil, nil
+	}
+
+	conn := goredis.Conn()
+	if conn == nil {
+		return nil, goredis.ErrRedisNotInitialised
+	}
+	defer func(conn redisgo.Conn) {
+		if err := conn.Close(); err != nil {
+			slog.Error("Error closing redis connection", slog.String(logging.KeyError, err.Error()))
+		}
+	}(conn)
+
+	for _, id := range alertIDs {
+		if err := conn.Send("HGETALL", historyRecordKeyPrefix+id); err != nil {
+			return nil, fmt.Errorf("queue alert history record: %w", err)
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, fmt.Errorf("send alert history reads: %w", err)
+	}
+
+	records := make([]*HistoryRecord, len(alertIDs))
+	for i := range alertIDs {
+		fields, err := redisgo.StringMap(redisgo.ReceiveContext(conn, ctx))
+		if err != nil {
+			return nil, fmt.Errorf("get alert history record: %w", err)
+		}
+
+		records[i], err = parseHistoryRecord(fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// parseHistoryRecord returns the history record held in the fields of its hash. It returns nil if the alert is no
+// longer in the history.
+func parseHistoryRecord(fields map[string]string) (*HistoryRecord, error) {
+	rawAlert, ok := fields[historyAlertField]
+	if !ok {
+		return nil, nil
+	}
+
+	record := &HistoryRecord{
+		Alert:      new(Alert),
+		Deliveries: make(map[string]*DeliveryRecord),
+	}
+	if err := json.Unmarshal([]byte(rawAlert), record.Alert); err != nil {
+		return nil, fmt.Errorf("unmarshal alert: %w", err)
+	}
+
+	for field, raw := range fields {
+		dest, ok := strings.CutPrefix(field, historyDeliveryFieldPrefix)
+		if !ok {
+			continue
+		}
+
+		delivery := new(DeliveryRecord)
+		if err := json.Unmarshal([]byte(raw), delivery); err != nil {
+			return nil, fmt.Errorf("unmarshal delivery record: %w", err)
+		}
+		record.Deliveries[dest] = delivery
+	}
+
+	return record, nil
+}
@@ -0,0 +1,210 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HistorySuite struct {
+	suite.Suite
+
+	pool    *goredis.MockPool
+	history HistoryStore
+}
+
+func TestHistorySuite(t *testing.T) {
+	suite.Run(t, new(HistorySuite))
+}
+
+func (s *HistorySuite) SetupTest() {
+	s.pool = goredis.NewMockPool(s.T())
+	s.Require().NoError(goredis.NewPool(
+		goredis.WithInitializedPool(s.pool),
+		goredis.WithAddress("localhost:6379"),
+		goredis.WithNetwork("tcp"),
+	))
+
+	s.history = NewHistoryStore(time.Hour)
+}
+
+// hash returns the reply of HGETALL for the history record of the alert.
+func (s *HistorySuite) hash(alert *Alert, deliveries map[string]*DeliveryRecord) []any {
+	rawAlert, err := json.Marshal(alert)
+	s.Require().NoError(err)
+
+	reply := []any{[]byte(historyAlertField), rawAlert}
+	for dest, d := range deliveries {
+		raw, err := json.Marshal(d)
+		s.Require().NoError(err)
+		reply = append(reply, []byte(historyDeliveryFieldPrefix+dest), raw)
+	}
+
+	return reply
+}
+
+func (s *HistorySuite) TestRecord() {
+	alert := NewAlert(SeverityCritical, "Server down", "").WithCategory(CategoryState)
+	key := historyRecordKeyPrefix + alert.ID
+
+	var stored *Alert
+	s.pool.On("DoCtx", mock.Anything, "HSET", key, historyAlertField, mock.Anything, historyDeliveryFieldPrefix+"discord", mock.Anything).
+		Run(func(args mock.Arguments) {
+			stored = new(Alert)
+			s.Require().NoError(json.Unmarshal(args.Get(4).([]byte), stored))
+		}).
+		Return(int64(2), nil)
+	s.pool.On("DoCtx", mock.Anything, "PEXPIRE", key, time.Hour.Milliseconds()).Return(int64(1), nil)
+	s.pool.On("DoCtx", mock.Anything, "ZADD", historyKey, alert.Timestamp.UnixMilli(), alert.ID).Return(int64(1), nil)
+	s.pool.On("DoCtx", mock.Anything, "ZREMRANGEBYSCORE", historyKey, "-inf", mock.Anything).Return(int64(0), nil)
+
+	s.Require().NoError(s.history.Record(context.Background(), alert, []string{"discord"}))
+	s.Require().NotNil(stored)
+	s.Equal(alert.ID, stored.ID)
+	s.Equal(CategoryState, stored.Category)
+}
+
+func (s *HistorySuite) TestUpdateDeliveryExpired() {
+	s.pool.On("DoCtx", mock.Anything, "EXISTS", historyRecordKeyPrefix+"1").Return(int64(0), nil)
+
+	err := s.history.UpdateDelivery(context.Background(), "1", "discord", &DeliveryRecord{Status: DeliveryDelivered})
+	s.Require().NoError(err)
+	s.pool.AssertNotCalled(s.T(), "DoCtx", mock.Anything, "HSET", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// pipelineConn is a redis connection answering the HGETALLs pipelined on it from the hashes, by key.
+type pipelineConn struct {
+	hashes  map[string][]any
+	pending []string
+	reads   int
+}
+
+func (c *pipelineConn) Close() error { return nil }
+
+func (c *pipelineConn) Err() error { return nil }
+
+func (c *pipelineConn) Do(cmd string, _ ...any) (any, error) {
+	return nil, fmt.Errorf("unexpected command %s", cmd)
+}
+
+func (c *pipelineConn) DoContext(_ context.Context, cmd string, args ...any) (any, error) {
+	return c.Do(cmd, args...)
+}
+
+func (c *pipelineConn) Send(cmd string, args ...any) error {
+	if cmd != "HGETALL" {
+		return fmt.Errorf("unexpected command %s", cmd)
+	}
+	c.pending = append(c.pending, args[0].(string))
+	return nil
+}
+
+func (c *pipelineConn) Flush() error { return nil }
+
+func (c *pipelineConn) Receive() (any, error) {
+	if len(c.pending) == 0 {
+		return nil, errors.New("nothing pipelined")
+	}
+	key := c.pending[0]
+	c.pending = c.pending[1:]
+	c.reads++
+
+	hash, ok := c.hashes[key]
+	if !ok {
+		return []any{}, nil
+	}
+	return hash, nil
+}
+
+func (c *pipelineConn) ReceiveContext(context.Context) (any, error) {
+	return c.Receive()
+}
+
+func (s *HistorySuite) TestQuery() {
+	state := NewAlert(SeverityCritical, "Server down", "").WithCategory(CategoryState)
+	state.ID = "1"
+	game := NewAlert(SeverityInfo, "Game paused", "").WithCategory(CategoryGame)
+	game.ID = "2"
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.pool.On("DoCtx", mock.Anything, "ZREVRANGEBYSCORE", historyKey, "+inf", "1704067200000", "LIMIT", 0, defaultHistoryLimit).
+		Return([]any{[]byte("3"), []byte("2"), []byte("1")}, nil)
+
+	// Alert 3 expired before the index was pruned.
+	conn := &pipelineConn{hashes: map[string][]any{
+		historyRecordKeyPrefix + "2": s.hash(game, nil),
+		historyRecordKeyPrefix + "1": s.hash(state, map[string]*DeliveryRecord{
+			"discord": {Status: DeliveryDelivered},
+		}),
+	}}
+	s.pool.On("Conn").Return(conn)
+
+	records, err := s.history.Query(context.Background(), &HistoryFilter{
+		From:     from,
+		Category: CategoryState,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(records, 1)
+	s.Equal("Server down", records[0].Alert.Title)
+	s.Equal(DeliveryDelivered, records[0].Deliveries["discord"].Status)
+	s.Equal(3, conn.reads)
+}
+
+func (s *HistorySuite) TestQueryPages() {
+	hashes := make(map[string][]any)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		alert := NewAlert(SeverityInfo, "Alert "+id, "").WithCategory(CategoryGame)
+		alert.ID = id
+		if id == "3" || id == "4" {
+			alert.Category = CategoryState
+		}
+		hashes[historyRecordKeyPrefix+id] = s.hash(alert, nil)
+	}
+	conn := &pipelineConn{hashes: hashes}
+	s.pool.On("Conn").Return(conn)
+
+	// The first page has only one alert of the category, so the next page is read to fill the limit.
+	s.pool.On("DoCtx", mock.Anything, "ZREVRANGEBYSCORE", historyKey, "+inf", "-inf", "LIMIT", 0, 2).
+		Return([]any{[]byte("4"), []byte("2")}, nil)
+	s.pool.On("DoCtx", mock.Anything, "ZREVRANGEBYSCORE", historyKey, "+inf", "-inf", "LIMIT", 2, 2).
+		Return([]any{[]byte("1"), []byte("3")}, nil)
+
+	records, err := s.history.Query(context.Background(), &HistoryFilter{Category: CategoryState, Limit: 2})
+	s.Require().NoError(err)
+	s.Require().Len(records, 2)
+	s.Equal("4", records[0].Alert.ID)
+	s.Equal("3", records[1].Alert.ID)
+	s.Equal(4, conn.reads)
+}
+
+func TestHistoryFilter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	r := httptest.NewRequest("GET", "/alerts/history?since=24h&category=state&limit=1000", nil)
+	filter, err := historyFilter(r, now)
+	require.NoError(t, err)
+	require.Equal(t, &HistoryFilter{
+		From:     now.Add(-24 * time.Hour),
+		Category: CategoryState,
+		Limit:    maxHistoryLimit,
+	}, filter)
+
+	r = httptest.NewRequest("GET", "/alerts/history?from=2024-01-01T00:00:00Z&to=2024-01-01T12:00:00Z", nil)
+	filter, err = historyFilter(r, now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), filter.From)
+	require.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), filter.To)
+
+	r = httptest.NewRequest("GET", "/alerts/history?category=weather", nil)
+	_, err = historyFilter(r, now)
+	require.EqualError(t, err, "unknown category: weather")
+}
@@ -0,0 +1,96 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+// incidentsKeyPrefix prefixes the redis hash of the open incidents of a destination, keyed by the incident ID.
+const incidentsKeyPrefix = "alerts:incidents:"
+
+// IncidentStatus is the stage of the incident an alert reports.
+type IncidentStatus string
+
+const (
+	// IncidentOpen is used for alerts that open or update an incident.
+	IncidentOpen IncidentStatus = "open"
+
+	// IncidentResolved is used for the alert that resolves an incident.
+	IncidentResolved IncidentStatus = "resolved"
+)
+
+// Incident is an ongoing problem shown as a single message that is edited as the problem progresses.
+type Incident struct {
+	// ID is the ID of the incident.
+	ID string `json:"id"`
+
+	// MessageID is the ID of the message showing the incident.
+	MessageID string `json:"message_id"`
+
+	// OpenedAt is when the incident was opened.
+	OpenedAt time.Time `json:"opened_at"`
+
+	// UpdatedAt is when the incident was last updated.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IncidentStore maps the open incidents of each destination to the messages showing them.
+type IncidentStore interface {
+	// Get returns the open incident of the destination. It returns nil if the incident is not open.
+	Get(ctx context.Context, destination, incidentID string) (*Incident, error)
+
+	// Put stores the open incident of the destination.
+	Put(ctx context.Context, destination string, incident *Incident) error
+
+	// Close removes the incident of the destination.
+	Close(ctx context.Context, destination, incidentID string) error
+}
+
+type incidentStore struct{}
+
+// NewIncidentStore creates a new IncidentStore backed by redis.
+func NewIncidentStore() IncidentStore {
+	return new(incidentStore)
+}
+
+func (s *incidentStore) Get(ctx context.Context, destination, incidentID string) (*Incident, error) {
+	raw, err := redisgo.Bytes(goredis.DoCtx(ctx, "HGET", incidentsKeyPrefix+destination, incidentID))
+	if errors.Is(err, redisgo.ErrNil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get incident: %w", err)
+	}
+
+	incident := new(Incident)
+	if err := json.Unmarshal(raw, incident); err != nil {
+		return nil, fmt.Errorf("unmarshal incident: %w", err)
+	}
+
+	return incident, nil
+}
+
+func (s *incidentStore) Put(ctx context.Context, destination string, incident *Incident) error {
+	raw, err := json.Marshal(incident)
+	if err != nil {
+		return fmt.Errorf("marshal incident: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "HSET", incidentsKeyPrefix+destination, incident.ID, raw); err != nil {
+		return fmt.Errorf("store incident: %w", err)
+	}
+
+	return nil
+}
+
+func (s *incidentStore) Close(ctx context.Context, destination, incidentID string) error {
+	if _, err := goredis.DoCtx(ctx, "HDEL", incidentsKeyPrefix+destination, incidentID); err != nil {
+		return fmt.Errorf("remove incident: %w", err)
+	}
+	return nil
+}
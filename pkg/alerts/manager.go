@@ -1,56 +1,240 @@
 package alerts
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
 )
 
-type DiscordManager interface {
-	SendDiscordAlert(message string) error
+// Manager fans alerts out to every configured destination.
+type Manager interface {
+	Notifier
+
+	// Notifiers returns the destinations of the manager.
+	Notifiers() []Notifier
+
+	// Destinations returns the destinations the alert is sent to after routing, quiet hours and mutes are applied.
+	Destinations(ctx context.Context, alert *Alert) []Notifier
+
+	// Route returns the destinations the alert is sent to now, like Destinations, and the destinations it is held back
+	// from by quiet hours with when their quiet hours end.
+	Route(ctx context.Context, alert *Alert) (destinations []Notifier, deferred map[string]time.Time)
+
+	// Render returns the alert rendered in the locale of the destination.
+	Render(alert *Alert, destination string) *Alert
+
+	// Run runs the background work of the destinations until the context is done, and returns once it has finished.
+	Run(ctx context.Context)
+}
+
+// runner is implemented by destinations that do work in the background, such as sending digests.
+type runner interface {
+	Run(ctx context.Context)
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption func(m *manager)
+
+// WithRoutes sends each alert to the destinations of the routes that match it, rather than to every destination.
+func WithRoutes(routes ...*Route) ManagerOption {
+	return func(m *manager) {
+		m.routes = append(m.routes, routes...)
+	}
+}
+
+// WithQuietHours only sends critical alerts to the destination during the quiet hours. The other alerts are deferred
+// until the quiet hours end when they are sent through an Outbox.
+func WithQuietHours(destination string, quietHours *QuietHours) ManagerOption {
+	return func(m *manager) {
+		m.quietHours[destination] = quietHours
+	}
+}
+
+// WithMuteStore stops the alerts matched by the mutes in the store being sent.
+func WithMuteStore(store MuteStore) ManagerOption {
+	return func(m *manager) {
+		m.mutes = store
+	}
+}
+
+// WithTemplates renders templated alerts from the templates rather than only the built-in ones.
+func WithTemplates(templates *Templates) ManagerOption {
+	return func(m *manager) {
+		m.templates = templates
+	}
+}
+
+// WithLocale renders the alerts sent to the destination in the locale.
+func WithLocale(destination, locale string) ManagerOption {
+	return func(m *manager) {
+		m.locales[destination] = locale
+	}
+}
+
+type manager struct {
+	notifiers  []Notifier
+	routes     []*Route
+	quietHours map[string]*QuietHours
+	mutes      MuteStore
+	templates  *Templates
+	locales    map[string]string
+	now        func() time.Time
+}
+
+// NewManager creates a new Manager that delivers to the notifiers.
+func NewManager(notifiers []Notifier, opts ...ManagerOption) Manager {
+	m := &manager{
+		notifiers:  notifiers,
+		quietHours: make(map[string]*QuietHours),
+		templates:  NewTemplates(),
+		locales:    make(map[string]string),
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *manager) Name() string {
+	return "manager"
 }
 
-type discordPayload struct {
-	Content string `json:"content"`
+func (m *manager) Notifiers() []Notifier {
+	return m.notifiers
 }
 
-type discordManager struct {
-	webhookURL string
+func (m *manager) Destinations(ctx context.Context, alert *Alert) []Notifier {
+	destinations, _ := m.Route(ctx, alert)
+	return destinations
 }
 
-func NewDiscordManager(webhookURL string) DiscordManager {
-	return &discordManager{
-		webhookURL: webhookURL,
+func (m *manager) Route(ctx context.Context, alert *Alert) ([]Notifier, map[string]time.Time) {
+	routed := m.notifiers
+	if len(m.routes) > 0 {
+		names := make([]string, 0)
+		for _, r := range m.routes {
+			if r.Matches(alert) {
+				names = append(names, r.Destinations...)
+			}
+		}
+
+		routed = make([]Notifier, 0, len(m.notifiers))
+		for _, n := range m.notifiers {
+			if slices.Contains(names, n.Name()) {
+				routed = append(routed, n)
+			}
+		}
+	}
+
+	var mutes []*Mute
+	if m.mutes != nil {
+		var err error
+		mutes, err = m.mutes.Mutes(ctx)
+		if err != nil {
+			// Rather send a muted alert than lose an alert because the mutes could not be read.
+			slog.Error("Error getting alert mutes", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+
+	now := m.now()
+	destinations := make([]Notifier, 0, len(routed))
+	deferred := make(map[string]time.Time)
+	for _, n := range routed {
+		muted := slices.ContainsFunc(mutes, func(mute *Mute) bool {
+			return mute.Matches(alert, n.Name(), now)
+		})
+		if muted {
+			slog.Debug("Alert muted", slog.String("destination", n.Name()))
+			continue
+		}
+
+		if qh, ok := m.quietHours[n.Name()]; ok && alert.Severity != SeverityCritical && qh.Contains(now) {
+			slog.Debug("Alert held back by quiet hours", slog.String("destination", n.Name()))
+			deferred[n.Name()] = qh.EndAfter(now)
+			continue
+		}
+
+		destinations = append(destinations, n)
 	}
+
+	return destinations, deferred
 }
 
-func (d *discordManager) SendDiscordAlert(message string) error {
-	bdy := &discordPayload{
-		Content: message,
+func (m *manager) Render(alert *Alert, destination string) *Alert {
+	locale, ok := m.locales[destination]
+	if !ok {
+		locale = DefaultLocale
 	}
 
-	bdyBytes, err := json.Marshal(bdy)
-	if err != nil {
-		return fmt.Errorf("failed to marshal discord payload: %w", err)
+	rendered, err := m.templates.Render(alert, locale)
+	if err == nil {
+		return rendered
 	}
 
-	req, err := http.NewRequest(http.MethodPost, d.webhookURL, bytes.NewBuffer(bdyBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create discord request: %w", err)
+	slog.Error("Error rendering alert",
+		slog.String("template", alert.Template),
+		slog.String("locale", locale),
+		slog.String(logging.KeyError, err.Error()),
+	)
+
+	// A broken translation should not stop the alert being sent, so fall back to the default locale.
+	if locale != DefaultLocale {
+		if rendered, err := m.templates.Render(alert, DefaultLocale); err == nil {
+			return rendered
+		}
+	}
+
+	fallback := *alert
+	if fallback.Title == "" {
+		fallback.Title = alert.Template
 	}
+	return &fallback
+}
 
-	req.Header.Set("Content-Type", "application/json")
+func (m *manager) Run(ctx context.Context) {
+	wg := new(sync.WaitGroup)
+	for _, n := range m.notifiers {
+		r, ok := n.(runner)
+		if !ok {
+			continue
+		}
 
-	client := new(http.Client)
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send discord request: %w", err)
+		wg.Add(1)
+		go func(r runner) {
+			defer wg.Done()
+			r.Run(ctx)
+		}(r)
 	}
+	wg.Wait()
+}
+
+// Notify delivers the alert to each of its destinations concurrently. A failing destination does not stop delivery to
+// the others, and the errors of all failing destinations are returned together. The manager can't hold alerts, so the
+// destinations in their quiet hours are skipped; use an Outbox to send the alert to them once the quiet hours end.
+func (m *manager) Notify(ctx context.Context, alert *Alert) error {
+	destinations := m.Destinations(ctx, alert)
+	errs := make([]error, len(destinations))
 
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("discord request failed: %s", resp.Status)
+	wg := new(sync.WaitGroup)
+	for i, n := range destinations {
+		wg.Add(1)
+		go func(i int, n Notifier) {
+			defer wg.Done()
+			if err := n.Notify(ctx, m.Render(alert, n.Name())); err != nil {
+				errs[i] = fmt.Errorf("notify %s: %w", n.Name(), err)
+			}
+		}(i, n)
 	}
+	wg.Wait()
 
-	return nil
+	return utils.MultiErrors(errs...)
 }
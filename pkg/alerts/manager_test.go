@@ -0,0 +1,275 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier is a Notifier that records the alerts it receives.
+type recordingNotifier struct {
+	mut    sync.Mutex
+	name   string
+	err    error
+	alerts []*Alert
+}
+
+func (r *recordingNotifier) Name() string {
+	return r.name
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, alert *Alert) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.alerts = append(r.alerts, alert)
+	return r.err
+}
+
+func TestManager_Notify(t *testing.T) {
+	ok := &recordingNotifier{name: "ok"}
+	failing := &recordingNotifier{name: "failing", err: errors.New("boom")}
+
+	alert := NewAlert(SeverityInfo, "title", "description")
+	err := NewManager([]Notifier{ok, failing}).Notify(context.Background(), alert)
+	require.EqualError(t, err, "notify failing: boom")
+
+	require.Equal(t, []*Alert{alert}, ok.alerts)
+	require.Equal(t, []*Alert{alert}, failing.alerts)
+}
+
+func TestFromViper(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(strings.NewReader(`
+alerts:
+  notifiers:
+    - name: discord
+      type: discord
+      url_secret: alerts_url
+    - name: slack
+      type: slack
+      url: https://hooks.slack.com/services/T/B/X
+    - name: automation
+      type: webhook
+      url: https://example.com/hook
+      signing_secret: hook_secret
+      template: '{"text": {{ json .Title }}}'
+      quiet_hours:
+        start: "22:00"
+        end: "07:00"
+        timezone: Europe/Berlin
+  routes:
+    - destinations: [discord, slack]
+    - destinations: [automation]
+      categories: [state]
+      min_severity: warning
+`)))
+
+	secrets := map[string]string{
+		"alerts_url":  "https://discord.com/api/webhooks/1/token",
+		"hook_secret": "s3cret",
+	}
+	lookup := func(key string) (string, bool) {
+		got, ok := secrets[key]
+		return got, ok
+	}
+
+	m, err := FromViper(v, lookup)
+	require.NoError(t, err)
+
+	notifiers := m.Notifiers()
+	require.Len(t, notifiers, 3)
+
+	require.Equal(t, "https://discord.com/api/webhooks/1/token", notifiers[0].(*discordManager).webhookURL)
+	require.Equal(t, "https://hooks.slack.com/services/T/B/X", notifiers[1].(*slackNotifier).webhookURL)
+	require.Equal(t, []byte("s3cret"), notifiers[2].(*webhookNotifier).secret)
+
+	require.Equal(t, []*Route{
+		{Destinations: []string{"discord", "slack"}, Categories: []Category{}},
+		{Destinations: []string{"automation"}, Categories: []Category{CategoryState}, MinSeverity: SeverityWarning},
+	}, m.(*manager).routes)
+	require.Contains(t, m.(*manager).quietHours, "automation")
+
+	names, err := DestinationNames(v)
+	require.NoError(t, err)
+	require.Equal(t, []string{"discord", "slack", "automation"}, names)
+
+	names, err = DestinationNames(viper.New())
+	require.NoError(t, err)
+	require.Equal(t, []string{"discord"}, names, "the discord webhook is the destination when none are configured")
+}
+
+func TestFromViper_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:    "no notifiers",
+			config:  `alerts: {}`,
+			wantErr: "no notifiers configured",
+		},
+		{
+			name: "unknown type",
+			config: `
+alerts:
+  notifiers:
+    - name: pager
+      type: pager
+      url: https://example.com`,
+			wantErr: "create notifier pager: unknown notifier type: pager",
+		},
+		{
+			name: "missing secret",
+			config: `
+alerts:
+  notifiers:
+    - name: discord
+      type: discord
+      url_secret: missing`,
+			wantErr: "create notifier discord: secret not found: missing",
+		},
+		{
+			name: "duplicate name",
+			config: `
+alerts:
+  notifiers:
+    - name: discord
+      type: discord
+      url: https://example.com
+    - name: discord
+      type: slack
+      url: https://example.com`,
+			wantErr: "duplicate notifier name: discord",
+		},
+		{
+			name: "unknown route destination",
+			config: `
+alerts:
+  notifiers:
+    - name: discord
+      type: discord
+      url: https://example.com
+  routes:
+    - destinations: [pager]`,
+			wantErr: "route 0: unknown destination: pager",
+		},
+		{
+			name: "unknown route severity",
+			config: `
+alerts:
+  notifiers:
+    - name: discord
+      type: discord
+      url: https://example.com
+  routes:
+    - destinations: [discord]
+      min_severity: urgent`,
+			wantErr: "route 0: unknown severity: urgent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.SetConfigType("yaml")
+			require.NoError(t, v.ReadConfig(strings.NewReader(tt.config)))
+
+			_, err := FromViper(v, func(string) (string, bool) { return "", false })
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+// staticMuteStore is a MuteStore holding a fixed set of mutes.
+type staticMuteStore struct {
+	MuteStore
+	mutes []*Mute
+}
+
+func (s *staticMuteStore) Mutes(context.Context) ([]*Mute, error) {
+	return s.mutes, nil
+}
+
+func TestManager_Destinations(t *testing.T) {
+	discord := &recordingNotifier{name: "discord"}
+	email := &recordingNotifier{name: "email"}
+	ops := &recordingNotifier{name: "ops"}
+
+	quiet, err := NewQuietHours("22:00", "07:00", "UTC")
+	require.NoError(t, err)
+
+	m := NewManager(
+		[]Notifier{discord, email, ops},
+		WithRoutes(
+			&Route{Destinations: []string{"discord"}},
+			&Route{Destinations: []string{"email"}, MinSeverity: SeverityWarning},
+			&Route{Destinations: []string{"ops"}, Categories: []Category{CategoryState, CategoryVersion}},
+		),
+		WithQuietHours("discord", quiet),
+		WithMuteStore(&staticMuteStore{mutes: []*Mute{
+			{Category: CategoryVersion, Until: time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)},
+		}}),
+	).(*manager)
+	m.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	names := func(alert *Alert) []string {
+		got := make([]string, 0)
+		for _, n := range m.Destinations(context.Background(), alert) {
+			got = append(got, n.Name())
+		}
+		return got
+	}
+
+	require.Equal(t, []string{"discord"}, names(NewAlert(SeverityInfo, "Game paused", "").WithCategory(CategoryGame)))
+	require.Equal(t, []string{"discord", "email", "ops"}, names(NewAlert(SeverityCritical, "Server down", "").WithCategory(CategoryState)))
+	require.Empty(t, names(NewAlert(SeverityInfo, "Server updated", "").WithCategory(CategoryVersion)))
+
+	// During the quiet hours only critical alerts reach discord, the others are deferred until the quiet hours end.
+	m.now = func() time.Time { return time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC) }
+	require.Empty(t, names(NewAlert(SeverityInfo, "Game paused", "").WithCategory(CategoryGame)))
+	require.Equal(t, []string{"discord", "email", "ops"}, names(NewAlert(SeverityCritical, "Server down", "").WithCategory(CategoryState)))
+
+	_, deferred := m.Route(context.Background(), NewAlert(SeverityInfo, "Game paused", "").WithCategory(CategoryGame))
+	require.Equal(t, map[string]time.Time{"discord": time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC)}, deferred)
+	_, deferred = m.Route(context.Background(), NewAlert(SeverityCritical, "Server down", "").WithCategory(CategoryState))
+	require.Empty(t, deferred)
+
+	// The mute has expired, so the update reaches its destinations again.
+	require.Equal(t, []string{"ops"}, names(NewAlert(SeverityInfo, "Server updated", "").WithCategory(CategoryVersion)))
+}
+
+func TestQuietHours_Contains(t *testing.T) {
+	overnight, err := NewQuietHours("22:00", "07:00", "Europe/Berlin")
+	require.NoError(t, err)
+
+	require.True(t, overnight.Contains(time.Date(2024, 1, 1, 21, 30, 0, 0, time.UTC)))
+	require.True(t, overnight.Contains(time.Date(2024, 1, 1, 5, 59, 0, 0, time.UTC)))
+	require.False(t, overnight.Contains(time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)))
+	require.False(t, overnight.Contains(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)))
+
+	daytime, err := NewQuietHours("09:00", "17:00", "UTC")
+	require.NoError(t, err)
+
+	require.True(t, daytime.Contains(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+	require.False(t, daytime.Contains(time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC)))
+}
+
+func TestQuietHours_EndAfter(t *testing.T) {
+	overnight, err := NewQuietHours("22:00", "07:00", "Europe/Berlin")
+	require.NoError(t, err)
+
+	berlin := overnight.Location
+	require.Equal(t, time.Date(2024, 1, 2, 7, 0, 0, 0, berlin), overnight.EndAfter(time.Date(2024, 1, 1, 22, 30, 0, 0, berlin)))
+	require.Equal(t, time.Date(2024, 1, 2, 7, 0, 0, 0, berlin), overnight.EndAfter(time.Date(2024, 1, 2, 5, 0, 0, 0, berlin)))
+
+	// The quiet hours end at the same local time after a change to summer time.
+	require.Equal(t, time.Date(2024, 3, 31, 7, 0, 0, 0, berlin), overnight.EndAfter(time.Date(2024, 3, 30, 23, 0, 0, 0, berlin)))
+}
@@ -0,0 +1,135 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+// mutesKey is the redis hash of the active mutes, keyed by the mute key.
+const mutesKey = "alerts:mutes"
+
+// Mute stops the alerts it matches being sent until it expires.
+type Mute struct {
+	// Category is the category of the muted alerts. Every category is muted when it is empty.
+	Category Category `json:"category,omitempty"`
+
+	// Destination is the name of the muted notifier. Every destination is muted when it is empty.
+	Destination string `json:"destination,omitempty"`
+
+	// Until is when the mute expires.
+	Until time.Time `json:"until"`
+
+	// CreatedBy is who created the mute.
+	CreatedBy string `json:"created_by,omitempty"`
+}
+
+// Key returns the key that identifies what the mute matches, such as "category:state".
+func (m *Mute) Key() string {
+	parts := make([]string, 0, 2)
+	if m.Category != "" {
+		parts = append(parts, "category:"+string(m.Category))
+	}
+	if m.Destination != "" {
+		parts = append(parts, "destination:"+m.Destination)
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Matches returns true if the mute matches the alert sent to the destination at the time.
+func (m *Mute) Matches(alert *Alert, destination string, at time.Time) bool {
+	switch {
+	case !at.Before(m.Until):
+		return false
+	case m.Category != "" && m.Category != alert.Category:
+		return false
+	case m.Destination != "" && m.Destination != destination:
+		return false
+	default:
+		return true
+	}
+}
+
+// MuteStore stores the active mutes.
+type MuteStore interface {
+	// Mute stores the mute, replacing any mute with the same key.
+	Mute(ctx context.Context, mute *Mute) error
+
+	// Unmute removes the mute with the key. It returns false if there was no such mute.
+	Unmute(ctx context.Context, key string) (bool, error)
+
+	// Mutes returns the mutes that have not expired.
+	Mutes(ctx context.Context) ([]*Mute, error)
+}
+
+type muteStore struct{}
+
+// NewMuteStore creates a new MuteStore backed by redis.
+func NewMuteStore() MuteStore {
+	return new(muteStore)
+}
+
+func (s *muteStore) Mute(ctx context.Context, mute *Mute) error {
+	raw, err := json.Marshal(mute)
+	if err != nil {
+		return fmt.Errorf("marshal mute: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "HSET", mutesKey, mute.Key(), raw); err != nil {
+		return fmt.Errorf("store mute: %w", err)
+	}
+
+	return nil
+}
+
+func (s *muteStore) Unmute(ctx context.Context, key string) (bool, error) {
+	removed, err := redisgo.Int(goredis.DoCtx(ctx, "HDEL", mutesKey, key))
+	if err != nil {
+		return false, fmt.Errorf("remove mute: %w", err)
+	}
+	return removed > 0, nil
+}
+
+func (s *muteStore) Mutes(ctx context.Context) ([]*Mute, error) {
+	raw, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", mutesKey))
+	if errors.Is(err, redisgo.ErrNil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get mutes: %w", err)
+	}
+
+	now := time.Now()
+	mutes := make([]*Mute, 0, len(raw))
+	for key, r := range raw {
+		m := new(Mute)
+		if err := json.Unmarshal([]byte(r), m); err != nil {
+			return nil, fmt.Errorf("unmarshal mute: %w", err)
+		}
+
+		// Expired mutes are removed lazily when they are next read.
+		if !now.Before(m.Until) {
+			if _, err := goredis.DoCtx(ctx, "HDEL", mutesKey, key); err != nil {
+				return nil, fmt.Errorf("remove expired mute: %w", err)
+			}
+			continue
+		}
+
+		mutes = append(mutes, m)
+	}
+
+	slices.SortFunc(mutes, func(a, b *Mute) int {
+		return a.Until.Compare(b.Until)
+	})
+
+	return mutes, nil
+}
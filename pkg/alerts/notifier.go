@@ -0,0 +1,38 @@
+package alerts
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+)
+
+const (
+	// defaultHTTPTimeout is the timeout of the requests made by the notifiers.
+	defaultHTTPTimeout = 10 * time.Second
+)
+
+// Notifier delivers alerts to a single destination.
+type Notifier interface {
+	// Name returns the name of the destination.
+	Name() string
+
+	// Notify delivers the alert to the destination.
+	Notify(ctx context.Context, alert *Alert) error
+}
+
+// newHTTPClient returns the HTTP client used by the notifiers.
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: defaultHTTPTimeout,
+	}
+}
+
+// closeBody closes the body of the response.
+func closeBody(resp *http.Response) {
+	if err := resp.Body.Close(); err != nil {
+		slog.Error("Error closing response body", slog.String(logging.KeyError, err.Error()))
+	}
+}
@@ -0,0 +1,503 @@
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// outboxKey is the redis list of deliveries waiting to be sent.
+	outboxKey = "alerts:outbox"
+
+	// outboxProcessingKey is the redis list of deliveries being sent. Deliveries left here by a crash are moved back
+	// to the outbox when the worker starts.
+	outboxProcessingKey = "alerts:outbox:processing"
+
+	// outboxRetryKey is the redis sorted set of deliveries waiting to be retried, scored by the unix milliseconds of
+	// the next attempt.
+	outboxRetryKey = "alerts:outbox:retry"
+
+	// deadLetterKey is the redis list of deliveries that could not be sent, newest first.
+	deadLetterKey = "alerts:dead_letter"
+
+	// maxDeadLetters is the number of dead letters that are kept.
+	maxDeadLetters = 1000
+
+	// maxDeliveryAttempts is the number of times a delivery is attempted before it is dead lettered.
+	maxDeliveryAttempts = 8
+
+	// maxDeliveryAge is how long a delivery is retried for before it is dead lettered, so a destination that stays
+	// rate limited can't hold deliveries forever.
+	maxDeliveryAge = time.Hour
+
+	// baseRetryDelay is the delay before the first retry. The delay doubles with every attempt.
+	baseRetryDelay = 2 * time.Second
+
+	// maxRetryDelay is the longest delay between two attempts.
+	maxRetryDelay = 5 * time.Minute
+
+	// outboxPollTimeout is how long the worker blocks waiting for a delivery, so it notices due retries and shutdown.
+	outboxPollTimeout = 1
+
+	// promoteRetriesScript moves the retries due by ARGV[1] from the retry set back to the outbox in one step, so a
+	// retry is never lost between the two or moved twice by two workers. It returns the number of retries moved.
+	promoteRetriesScript = `local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+for _, raw in ipairs(due) do
+	redis.call("ZREM", KEYS[1], raw)
+	redis.call("LPUSH", KEYS[2], raw)
+end
+return #due`
+)
+
+// Delivery is an alert waiting to be sent to a single destination.
+type Delivery struct {
+	// ID is the unique ID of the delivery.
+	ID string `json:"id"`
+
+	// Destination is the name of the notifier the alert is sent to.
+	Destination string `json:"destination"`
+
+	// Alert is the alert to send.
+	Alert *Alert `json:"alert"`
+
+	// Attempts is the number of failed attempts.
+	Attempts int `json:"attempts"`
+
+	// LastError is the error of the last failed attempt.
+	LastError string `json:"last_error,omitempty"`
+
+	// EnqueuedAt is when the delivery was enqueued.
+	EnqueuedAt time.Time `json:"enqueued_at"`
+
+	// DeferredUntil is when the quiet hours the delivery was held back by end. It is zero if it wasn't held back.
+	DeferredUntil time.Time `json:"deferred_until,omitempty"`
+}
+
+// expired returns true if the delivery has been retried for longer than maxDeliveryAge since it could first be sent.
+func (d *Delivery) expired() bool {
+	since := d.EnqueuedAt
+	if d.DeferredUntil.After(since) {
+		since = d.DeferredUntil
+	}
+	return !since.IsZero() && time.Since(since) >= maxDeliveryAge
+}
+
+// Outbox is a Manager that enqueues alerts in redis and delivers them in the background, so sending an alert never
+// waits for a destination.
+type Outbox interface {
+	Manager
+
+	// DeadLetters returns up to limit of the most recent deliveries that could not be sent.
+	DeadLetters(ctx context.Context, limit int) ([]*Delivery, error)
+}
+
+// OutboxOption is a function that configures an Outbox.
+type OutboxOption func(o *outbox)
+
+// WithHistory records every alert, and the status of its deliveries, in the history.
+func WithHistory(history HistoryStore) OutboxOption {
+	return func(o *outbox) {
+		o.history = history
+	}
+}
+
+// WithSource sets the source of the alerts that don't name one.
+func WithSource(source string) OutboxOption {
+	return func(o *outbox) {
+		o.source = source
+	}
+}
+
+type outbox struct {
+	manager   Manager
+	notifiers map[string]Notifier
+
+	// history records the sent alerts. It is nil if the alerts are not recorded.
+	history HistoryStore
+
+	// source is the source of the alerts that don't name one.
+	source string
+
+	// blockedUntil is when each rate limited destination can be sent to again.
+	blockedUntil map[string]time.Time
+
+	// mut guards blockedUntil.
+	mut sync.Mutex
+}
+
+// NewOutbox creates a new Outbox that delivers to the destinations of the manager.
+func NewOutbox(manager Manager, opts ...OutboxOption) Outbox {
+	notifiers := make(map[string]Notifier, len(manager.Notifiers()))
+	for _, n := range manager.Notifiers() {
+		notifiers[n.Name()] = n
+	}
+
+	o := &outbox{
+		manager:      manager,
+		notifiers:    notifiers,
+		blockedUntil: make(map[string]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+func (o *outbox) Name() string {
+	return "outbox"
+}
+
+func (o *outbox) Notifiers() []Notifier {
+	return o.manager.Notifiers()
+}
+
+func (o *outbox) Destinations(ctx context.Context, alert *Alert) []Notifier {
+	return o.manager.Destinations(ctx, alert)
+}
+
+func (o *outbox) Route(ctx context.Context, alert *Alert) ([]Notifier, map[string]time.Time) {
+	return o.manager.Route(ctx, alert)
+}
+
+func (o *outbox) Render(alert *Alert, destination string) *Alert {
+	return o.manager.Render(alert, destination)
+}
+
+// Notify enqueues a delivery of the alert for each of its destinations, rendered in the locale of the destination.
+// The deliveries to the destinations in their quiet hours are scheduled for when the quiet hours end.
+func (o *outbox) Notify(ctx context.Context, alert *Alert) error {
+	if alert.Source == "" {
+		alert.Source = o.source
+	}
+
+	destinations, deferred := o.manager.Route(ctx, alert)
+
+	if o.history != nil {
+		names := make([]string, 0, len(destinations)+len(deferred))
+		for _, n := range destinations {
+			names = append(names, n.Name())
+		}
+		for name := range deferred {
+			names = append(names, name)
+		}
+
+		// The history is for looking back, so failing to record the alert must not stop it being sent.
+		if err := o.history.Record(ctx, alert, names); err != nil {
+			slog.Error("Error recording alert history", slog.String("alert", alert.ID), slog.String(logging.KeyError, err.Error()))
+		}
+	}
+
+	if err := o.deferAll(ctx, alert, deferred); err != nil {
+		return err
+	}
+
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	args := redisgo.Args{}.Add(outboxKey)
+	for _, n := range destinations {
+		raw, err := json.Marshal(&Delivery{
+			ID:          newID(),
+			Destination: n.Name(),
+			Alert:       o.manager.Render(alert, n.Name()),
+			EnqueuedAt:  time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("marshal delivery: %w", err)
+		}
+		args = args.Add(raw)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "LPUSH", args...); err != nil {
+		return fmt.Errorf("enqueue deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// deferAll schedules a delivery of the alert to each of the destinations for when their quiet hours end.
+func (o *outbox) deferAll(ctx context.Context, alert *Alert, deferred map[string]time.Time) error {
+	for name, until := range deferred {
+		raw, err := json.Marshal(&Delivery{
+			ID:            newID(),
+			Destination:   name,
+			Alert:         o.manager.Render(alert, name),
+			EnqueuedAt:    time.Now().UTC(),
+			DeferredUntil: until.UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("marshal delivery: %w", err)
+		}
+
+		if _, err := goredis.DoCtx(ctx, "ZADD", outboxRetryKey, until.UnixMilli(), raw); err != nil {
+			return fmt.Errorf("defer delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Run delivers the enqueued alerts until the context is done. It returns once the destinations have finished their
+// background work, such as sending the final email digest.
+func (o *outbox) Run(ctx context.Context) {
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.manager.Run(ctx)
+	}()
+	defer wg.Wait()
+
+	if err := o.recover(ctx); err != nil {
+		slog.Error("Error recovering in-flight deliveries", slog.String(logging.KeyError, err.Error()))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Debug("Context done")
+			return
+		default:
+		}
+
+		if err := o.promoteRetries(ctx); err != nil {
+			slog.Error("Error promoting due retries", slog.String(logging.KeyError, err.Error()))
+		}
+
+		raw, err := redisgo.Bytes(goredis.DoCtx(ctx, "BRPOPLPUSH", outboxKey, outboxProcessingKey, outboxPollTimeout))
+		if errors.Is(err, redisgo.ErrNil) {
+			continue
+		} else if err != nil {
+			slog.Error("Error getting delivery from outbox", slog.String(logging.KeyError, err.Error()))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		o.process(ctx, raw)
+
+		if _, err := goredis.DoCtx(ctx, "LREM", outboxProcessingKey, 1, raw); err != nil {
+			slog.Error("Error removing processed delivery", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+func (o *outbox) DeadLetters(ctx context.Context, limit int) ([]*Delivery, error) {
+	raw, err := redisgo.ByteSlices(goredis.DoCtx(ctx, "LRANGE", deadLetterKey, 0, limit-1))
+	if err != nil {
+		return nil, fmt.Errorf("get dead letters: %w", err)
+	}
+
+	deliveries := make([]*Delivery, 0, len(raw))
+	for _, r := range raw {
+		d := new(Delivery)
+		if err := json.Unmarshal(r, d); err != nil {
+			return nil, fmt.Errorf("unmarshal dead letter: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// process attempts a single delivery, scheduling a retry or dead lettering it if it fails.
+func (o *outbox) process(ctx context.Context, raw []byte) {
+	d := new(Delivery)
+	if err := json.Unmarshal(raw, d); err != nil {
+		slog.Error("Error unmarshalling delivery", slog.String(logging.KeyError, err.Error()))
+		o.deadLetter(ctx, raw)
+		return
+	}
+
+	n, ok := o.notifiers[d.Destination]
+	if !ok {
+		slog.Error("Unknown alert destination", slog.String("destination", d.Destination))
+		d.LastError = "unknown destination"
+		o.deadLetterDelivery(ctx, d)
+		o.recordDelivery(ctx, d, DeliveryFailed)
+		return
+	}
+
+	// Don't send to a destination that is known to be rate limited, wait until the limit resets.
+	if wait := o.blockedFor(d.Destination); wait > 0 {
+		if d.expired() {
+			d.LastError = "rate limited"
+			o.giveUp(ctx, d)
+			return
+		}
+		o.retry(ctx, d, wait)
+		return
+	}
+
+	err := n.Notify(ctx, d.Alert)
+	if err == nil {
+		slog.Debug("Alert delivered", slog.String("destination", d.Destination), slog.String("id", d.ID))
+		o.recordDelivery(ctx, d, DeliveryDelivered)
+		return
+	}
+
+	rateLimitErr := new(RateLimitError)
+	if errors.As(err, &rateLimitErr) {
+		slog.Warn("Alert destination rate limited",
+			slog.String("destination", d.Destination),
+			slog.Duration("retry_after", rateLimitErr.RetryAfter),
+		)
+		o.block(d.Destination, rateLimitErr.RetryAfter)
+
+		d.Attempts++
+		d.LastError = err.Error()
+		if d.Attempts >= maxDeliveryAttempts || d.expired() {
+			o.giveUp(ctx, d)
+			return
+		}
+
+		o.retry(ctx, d, rateLimitErr.RetryAfter)
+		o.recordDelivery(ctx, d, DeliveryRetrying)
+		return
+	}
+
+	d.Attempts++
+	d.LastError = err.Error()
+	if d.Attempts >= maxDeliveryAttempts || d.expired() {
+		o.giveUp(ctx, d)
+		return
+	}
+
+	delay := retryDelay(d.Attempts)
+	slog.Warn("Alert delivery failed, retrying",
+		slog.String("destination", d.Destination),
+		slog.Int("attempts", d.Attempts),
+		slog.Duration("delay", delay),
+		slog.String(logging.KeyError, err.Error()),
+	)
+	o.retry(ctx, d, delay)
+	o.recordDelivery(ctx, d, DeliveryRetrying)
+}
+
+// giveUp dead letters the delivery after it failed for too long.
+func (o *outbox) giveUp(ctx context.Context, d *Delivery) {
+	slog.Error("Alert delivery failed, giving up",
+		slog.String("destination", d.Destination),
+		slog.Int("attempts", d.Attempts),
+		slog.Duration("age", time.Since(d.EnqueuedAt)),
+		slog.String(logging.KeyError, d.LastError),
+	)
+	o.deadLetterDelivery(ctx, d)
+	o.recordDelivery(ctx, d, DeliveryFailed)
+}
+
+// recordDelivery records the status of the delivery in the history.
+func (o *outbox) recordDelivery(ctx context.Context, d *Delivery, status DeliveryStatus) {
+	if o.history == nil || d.Alert == nil {
+		return
+	}
+
+	err := o.history.UpdateDelivery(ctx, d.Alert.ID, d.Destination, &DeliveryRecord{
+		Status:    status,
+		Attempts:  d.Attempts,
+		LastError: d.LastError,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		slog.Error("Error recording alert delivery", slog.String("alert", d.Alert.ID), slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// retry schedules the delivery to be attempted again after the delay.
+func (o *outbox) retry(ctx context.Context, d *Delivery, delay time.Duration) {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		slog.Error("Error marshalling delivery", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	at := time.Now().Add(delay).UnixMilli()
+	if _, err := goredis.DoCtx(ctx, "ZADD", outboxRetryKey, at, raw); err != nil {
+		slog.Error("Error scheduling delivery retry", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// promoteRetries moves the retries that are due back to the outbox.
+func (o *outbox) promoteRetries(ctx context.Context) error {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if _, err := goredis.DoCtx(ctx, "EVAL", promoteRetriesScript, 2, outboxRetryKey, outboxKey, now); err != nil {
+		return fmt.Errorf("promote due retries: %w", err)
+	}
+	return nil
+}
+
+// recover moves the deliveries that were being sent when the worker stopped back to the outbox.
+func (o *outbox) recover(ctx context.Context) error {
+	for {
+		_, err := redisgo.Bytes(goredis.DoCtx(ctx, "RPOPLPUSH", outboxProcessingKey, outboxKey))
+		if errors.Is(err, redisgo.ErrNil) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (o *outbox) deadLetterDelivery(ctx context.Context, d *Delivery) {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		slog.Error("Error marshalling delivery", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+	o.deadLetter(ctx, raw)
+}
+
+func (o *outbox) deadLetter(ctx context.Context, raw []byte) {
+	if _, err := goredis.DoCtx(ctx, "LPUSH", deadLetterKey, raw); err != nil {
+		slog.Error("Error storing dead letter", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	if _, err := goredis.DoCtx(ctx, "LTRIM", deadLetterKey, 0, maxDeadLetters-1); err != nil {
+		slog.Error("Error trimming dead letters", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+func (o *outbox) block(destination string, d time.Duration) {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+	o.blockedUntil[destination] = time.Now().Add(d)
+}
+
+func (o *outbox) blockedFor(destination string) time.Duration {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+	return time.Until(o.blockedUntil[destination])
+}
+
+// retryDelay returns the delay before the next attempt after the given number of failed attempts. The delay doubles
+// with every attempt up to the maximum, with up to a fifth added as jitter.
+func retryDelay(attempts int) time.Duration {
+	delay := maxRetryDelay
+	if attempts < 20 {
+		delay = min(baseRetryDelay<<(attempts-1), maxRetryDelay)
+	}
+	return delay + mathrand.N(delay/5+1)
+}
+
+// newID returns a new random ID.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,251 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type OutboxSuite struct {
+	suite.Suite
+
+	pool     *goredis.MockPool
+	notifier *recordingNotifier
+	outbox   *outbox
+}
+
+func TestOutboxSuite(t *testing.T) {
+	suite.Run(t, new(OutboxSuite))
+}
+
+func (s *OutboxSuite) SetupTest() {
+	s.pool = goredis.NewMockPool(s.T())
+	s.Require().NoError(goredis.NewPool(
+		goredis.WithInitializedPool(s.pool),
+		goredis.WithAddress("localhost:6379"),
+		goredis.WithNetwork("tcp"),
+	))
+
+	s.notifier = &recordingNotifier{name: "discord"}
+	s.outbox = NewOutbox(NewManager([]Notifier{s.notifier})).(*outbox)
+}
+
+// delivery returns the delivery in the argument at the index of the command.
+func (s *OutboxSuite) delivery(args mock.Arguments, index int) *Delivery {
+	d := new(Delivery)
+	s.Require().NoError(json.Unmarshal(args.Get(index).([]byte), d))
+	return d
+}
+
+func (s *OutboxSuite) rawDelivery(d *Delivery) []byte {
+	raw, err := json.Marshal(d)
+	s.Require().NoError(err)
+	return raw
+}
+
+func (s *OutboxSuite) TestNotifyEnqueues() {
+	var enqueued *Delivery
+	s.pool.On("DoCtx", mock.Anything, "LPUSH", outboxKey, mock.Anything).
+		Run(func(args mock.Arguments) { enqueued = s.delivery(args, 3) }).
+		Return(int64(1), nil)
+
+	s.Require().NoError(s.outbox.Notify(context.Background(), NewAlert(SeverityInfo, "Game paused", "")))
+
+	s.Require().NotNil(enqueued)
+	s.NotEmpty(enqueued.ID)
+	s.Equal("discord", enqueued.Destination)
+	s.Equal("Game paused", enqueued.Alert.Title)
+	s.Zero(enqueued.Attempts)
+
+	// Nothing is delivered until the worker picks up the delivery.
+	s.Empty(s.notifier.alerts)
+}
+
+func (s *OutboxSuite) TestNotifyDefersQuietHours() {
+	quiet, err := NewQuietHours("22:00", "07:00", "UTC")
+	s.Require().NoError(err)
+	m := NewManager([]Notifier{s.notifier}, WithQuietHours("discord", quiet)).(*manager)
+	m.now = func() time.Time { return time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC) }
+	s.outbox = NewOutbox(m).(*outbox)
+
+	var (
+		at       int64
+		deferred *Delivery
+	)
+	s.pool.On("DoCtx", mock.Anything, "ZADD", outboxRetryKey, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			at = args.Get(3).(int64)
+			deferred = s.delivery(args, 4)
+		}).
+		Return(int64(1), nil)
+
+	s.Require().NoError(s.outbox.Notify(context.Background(), NewAlert(SeverityInfo, "Game paused", "")))
+
+	// The delivery is scheduled for the end of the quiet hours rather than dropped.
+	s.Require().NotNil(deferred)
+	s.Equal("discord", deferred.Destination)
+	s.Equal("Game paused", deferred.Alert.Title)
+	s.Equal(time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC), deferred.DeferredUntil)
+	s.Equal(deferred.DeferredUntil.UnixMilli(), at)
+	s.WithinDuration(time.Now(), deferred.EnqueuedAt, time.Minute, "the delivery was enqueued when the alert was sent")
+	s.Empty(s.notifier.alerts)
+}
+
+func (s *OutboxSuite) TestProcessDelivered() {
+	raw := s.rawDelivery(&Delivery{ID: "1", Destination: "discord", Alert: NewAlert(SeverityInfo, "Game paused", "")})
+
+	s.outbox.process(context.Background(), raw)
+
+	s.Require().Len(s.notifier.alerts, 1)
+	s.Equal("Game paused", s.notifier.alerts[0].Title)
+}
+
+func (s *OutboxSuite) TestProcessFailureRetried() {
+	s.notifier.err = errors.New("boom")
+
+	var retried *Delivery
+	s.pool.On("DoCtx", mock.Anything, "ZADD", outboxRetryKey, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { retried = s.delivery(args, 4) }).
+		Return(int64(1), nil)
+
+	raw := s.rawDelivery(&Delivery{ID: "1", Destination: "discord", Alert: NewAlert(SeverityInfo, "Game paused", "")})
+	s.outbox.process(context.Background(), raw)
+
+	s.Require().NotNil(retried)
+	s.Equal(1, retried.Attempts)
+	s.Equal("boom", retried.LastError)
+}
+
+func (s *OutboxSuite) TestProcessRateLimitedBlocksDestination() {
+	s.notifier.err = &RateLimitError{RetryAfter: time.Minute}
+
+	var retried []*Delivery
+	s.pool.On("DoCtx", mock.Anything, "ZADD", outboxRetryKey, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { retried = append(retried, s.delivery(args, 4)) }).
+		Return(int64(1), nil)
+
+	for _, id := range []string{"1", "2"} {
+		raw := s.rawDelivery(&Delivery{ID: id, Destination: "discord", Alert: NewAlert(SeverityInfo, "Game paused", "")})
+		s.outbox.process(context.Background(), raw)
+	}
+
+	// Being rate limited counts as a failed attempt, but the second delivery is held back without being sent.
+	s.Require().Len(retried, 2)
+	s.Equal(1, retried[0].Attempts)
+	s.Zero(retried[1].Attempts)
+	s.Len(s.notifier.alerts, 1)
+}
+
+func (s *OutboxSuite) TestProcessRateLimitedDeadLettered() {
+	s.notifier.err = &RateLimitError{RetryAfter: time.Minute}
+
+	var dead []*Delivery
+	s.pool.On("DoCtx", mock.Anything, "LPUSH", deadLetterKey, mock.Anything).
+		Run(func(args mock.Arguments) { dead = append(dead, s.delivery(args, 3)) }).
+		Return(int64(1), nil)
+	s.pool.On("DoCtx", mock.Anything, "LTRIM", deadLetterKey, 0, maxDeadLetters-1).Return("OK", nil)
+
+	// The first delivery runs out of attempts, and the second has been held back for too long.
+	s.outbox.process(context.Background(), s.rawDelivery(&Delivery{
+		ID:          "1",
+		Destination: "discord",
+		Alert:       NewAlert(SeverityInfo, "Game paused", ""),
+		Attempts:    maxDeliveryAttempts - 1,
+		EnqueuedAt:  time.Now(),
+	}))
+	s.outbox.process(context.Background(), s.rawDelivery(&Delivery{
+		ID:          "2",
+		Destination: "discord",
+		Alert:       NewAlert(SeverityInfo, "Game paused", ""),
+		EnqueuedAt:  time.Now().Add(-maxDeliveryAge),
+	}))
+
+	s.Require().Len(dead, 2)
+	s.Equal(maxDeliveryAttempts, dead[0].Attempts)
+	s.Equal("rate limited, retry after 1m0s", dead[0].LastError)
+	s.Equal("2", dead[1].ID)
+	s.Equal("rate limited", dead[1].LastError)
+	s.Len(s.notifier.alerts, 1)
+}
+
+func (s *OutboxSuite) TestProcessDeadLettered() {
+	s.notifier.err = errors.New("boom")
+
+	var dead *Delivery
+	s.pool.On("DoCtx", mock.Anything, "LPUSH", deadLetterKey, mock.Anything).
+		Run(func(args mock.Arguments) { dead = s.delivery(args, 3) }).
+		Return(int64(1), nil)
+	s.pool.On("DoCtx", mock.Anything, "LTRIM", deadLetterKey, 0, maxDeadLetters-1).Return("OK", nil)
+
+	raw := s.rawDelivery(&Delivery{
+		ID:          "1",
+		Destination: "discord",
+		Alert:       NewAlert(SeverityInfo, "Game paused", ""),
+		Attempts:    maxDeliveryAttempts - 1,
+	})
+	s.outbox.process(context.Background(), raw)
+
+	s.Require().NotNil(dead)
+	s.Equal(maxDeliveryAttempts, dead.Attempts)
+	s.Equal("boom", dead.LastError)
+}
+
+func (s *OutboxSuite) TestPromoteRetries() {
+	// The due retries are moved by one script, so a crash can't remove a retry without queueing it.
+	s.pool.On("DoCtx", mock.Anything, "EVAL", promoteRetriesScript, 2, outboxRetryKey, outboxKey, mock.Anything).
+		Return(int64(2), nil).Once()
+
+	s.Require().NoError(s.outbox.promoteRetries(context.Background()))
+}
+
+func TestDelivery_Expired(t *testing.T) {
+	require.False(t, (&Delivery{}).expired(), "a delivery without an enqueue time never expires")
+	require.False(t, (&Delivery{EnqueuedAt: time.Now().Add(-time.Minute)}).expired())
+	require.True(t, (&Delivery{EnqueuedAt: time.Now().Add(-maxDeliveryAge)}).expired())
+
+	// A deferred delivery is retried for as long as any other once its quiet hours end.
+	require.False(t, (&Delivery{
+		EnqueuedAt:    time.Now().Add(-8 * time.Hour),
+		DeferredUntil: time.Now().Add(-time.Minute),
+	}).expired())
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		min      time.Duration
+	}{
+		{attempts: 1, min: 2 * time.Second},
+		{attempts: 2, min: 4 * time.Second},
+		{attempts: 5, min: 32 * time.Second},
+		{attempts: 10, min: maxRetryDelay},
+		{attempts: 100, min: maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		got := retryDelay(tt.attempts)
+		require.GreaterOrEqual(t, got, tt.min)
+		require.LessOrEqual(t, got, tt.min+tt.min/5)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Reset-After", "1.5")
+	header.Set("Retry-After", "30")
+	require.Equal(t, 1500*time.Millisecond, retryAfter(&http.Response{Header: header}))
+
+	header = http.Header{}
+	header.Set("Retry-After", "30")
+	require.Equal(t, 30*time.Second, retryAfter(&http.Response{Header: header}))
+
+	require.Equal(t, defaultRetryAfter, retryAfter(&http.Response{Header: http.Header{}}))
+}
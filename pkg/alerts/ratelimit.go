@@ -0,0 +1,53 @@
+package alerts
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultRetryAfter is how long to wait when a rate limited response does not say how long to wait.
+	defaultRetryAfter = time.Second
+)
+
+// RateLimitError is returned when a destination is rate limited.
+type RateLimitError struct {
+	// RetryAfter is how long to wait before trying again.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+}
+
+// retryAfter returns how long the response says to wait before sending another request.
+func retryAfter(resp *http.Response) time.Duration {
+	for _, h := range []string{"X-RateLimit-Reset-After", "Retry-After"} {
+		v := resp.Header.Get(h)
+		if v == "" {
+			continue
+		}
+
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(secs * float64(time.Second))
+		}
+
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+
+	return defaultRetryAfter
+}
+
+// rateLimited returns a RateLimitError if the response is a rate limited response.
+func rateLimited(resp *http.Response) error {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	return &RateLimitError{
+		RetryAfter: retryAfter(resp),
+	}
+}
@@ -0,0 +1,100 @@
+package alerts
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Route sends the alerts it matches to its destinations.
+type Route struct {
+	// Destinations are the names of the notifiers the matched alerts are sent to.
+	Destinations []string
+
+	// Categories are the categories of the alerts that are matched. Every category is matched when it is empty.
+	Categories []Category
+
+	// MinSeverity is the least important severity that is matched. Every severity is matched when it is empty.
+	MinSeverity Severity
+}
+
+// Matches returns true if the route matches the alert.
+func (r *Route) Matches(alert *Alert) bool {
+	if len(r.Categories) > 0 && !slices.Contains(r.Categories, alert.Category) {
+		return false
+	}
+	return r.MinSeverity == "" || alert.Severity.AtLeast(r.MinSeverity)
+}
+
+// QuietHours is a daily period in which a destination only receives critical alerts.
+type QuietHours struct {
+	// Start is the time of day the quiet hours start, as an offset from midnight.
+	Start time.Duration
+
+	// End is the time of day the quiet hours end, as an offset from midnight. The quiet hours span midnight when it
+	// is before the start.
+	End time.Duration
+
+	// Location is the time zone of the start and end.
+	Location *time.Location
+}
+
+// NewQuietHours creates new QuietHours from the "15:04" formatted start and end times in the named time zone. The
+// local time zone is used when the zone is empty.
+func NewQuietHours(start, end, zone string) (*QuietHours, error) {
+	startOffset, err := parseTimeOfDay(start)
+	if err != nil {
+		return nil, fmt.Errorf("parse start: %w", err)
+	}
+
+	endOffset, err := parseTimeOfDay(end)
+	if err != nil {
+		return nil, fmt.Errorf("parse end: %w", err)
+	}
+
+	loc := time.Local
+	if zone != "" {
+		loc, err = time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("load time zone: %w", err)
+		}
+	}
+
+	return &QuietHours{
+		Start:    startOffset,
+		End:      endOffset,
+		Location: loc,
+	}, nil
+}
+
+// Contains returns true if the time is within the quiet hours.
+func (q *QuietHours) Contains(t time.Time) bool {
+	t = t.In(q.Location)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	if q.Start <= q.End {
+		return offset >= q.Start && offset < q.End
+	}
+	return offset >= q.Start || offset < q.End
+}
+
+// EndAfter returns the first end of the quiet hours after the time.
+func (q *QuietHours) EndAfter(t time.Time) time.Time {
+	t = t.In(q.Location)
+	hour, minute := int(q.End/time.Hour), int(q.End%time.Hour/time.Minute)
+
+	end := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, q.Location)
+	if !end.After(t) {
+		end = time.Date(t.Year(), t.Month(), t.Day()+1, hour, minute, 0, 0, q.Location)
+	}
+	return end
+}
+
+// parseTimeOfDay returns the "15:04" formatted time of day as an offset from midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
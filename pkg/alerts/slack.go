@@ -0,0 +1,159 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// maxSlackFields is the number of fields slack allows in a section block.
+	maxSlackFields = 10
+)
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type      string            `json:"type"`
+	Text      *slackText        `json:"text,omitempty"`
+	Fields    []*slackText      `json:"fields,omitempty"`
+	Elements  []*slackText      `json:"elements,omitempty"`
+	Accessory *slackImageAccess `json:"accessory,omitempty"`
+}
+
+type slackImageAccess struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+type slackAttachment struct {
+	Color  string        `json:"color"`
+	Blocks []*slackBlock `json:"blocks"`
+}
+
+type slackPayload struct {
+	Text        string             `json:"text"`
+	Attachments []*slackAttachment `json:"attachments"`
+}
+
+type slackNotifier struct {
+	name       string
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier creates a new notifier that posts to a slack incoming webhook.
+func NewSlackNotifier(name, webhookURL string) Notifier {
+	return &slackNotifier{
+		name:       name,
+		webhookURL: webhookURL,
+		client:     newHTTPClient(),
+	}
+}
+
+func (s *slackNotifier) Name() string {
+	return s.name
+}
+
+func (s *slackNotifier) Notify(ctx context.Context, alert *Alert) error {
+	bdyBytes, err := json.Marshal(slackMessage(alert))
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewBuffer(bdyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create slack request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send slack request: %w", err)
+	}
+	defer closeBody(resp)
+
+	if err := rateLimited(resp); err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack request failed: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// slackMessage renders the alert as a slack message using Block Kit.
+func slackMessage(alert *Alert) *slackPayload {
+	blocks := []*slackBlock{
+		{
+			Type: "header",
+			Text: &slackText{Type: "plain_text", Text: alert.Title},
+		},
+	}
+
+	if alert.Description != "" {
+		section := &slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: alert.Description},
+		}
+		if alert.ThumbnailURL != "" {
+			section.Accessory = &slackImageAccess{
+				Type:     "image",
+				ImageURL: alert.ThumbnailURL,
+				AltText:  alert.Title,
+			}
+		}
+		blocks = append(blocks, section)
+	}
+
+	for i := 0; i < len(alert.Fields); i += maxSlackFields {
+		end := min(i+maxSlackFields, len(alert.Fields))
+
+		fields := make([]*slackText, 0, end-i)
+		for _, f := range alert.Fields[i:end] {
+			fields = append(fields, &slackText{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("*%s*\n%s", f.Name, f.Value),
+			})
+		}
+
+		blocks = append(blocks, &slackBlock{
+			Type:   "section",
+			Fields: fields,
+		})
+	}
+
+	elements := make([]*slackText, 0, 2)
+	if alert.Footer != "" {
+		elements = append(elements, &slackText{Type: "mrkdwn", Text: alert.Footer})
+	}
+	if !alert.Timestamp.IsZero() {
+		elements = append(elements, &slackText{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", alert.Timestamp.Unix(), alert.Timestamp.Format("2006-01-02 15:04 MST")),
+		})
+	}
+	if len(elements) > 0 {
+		blocks = append(blocks, &slackBlock{
+			Type:     "context",
+			Elements: elements,
+		})
+	}
+
+	return &slackPayload{
+		Text: alert.Title,
+		Attachments: []*slackAttachment{
+			{
+				Color:  fmt.Sprintf("#%06X", alert.Severity.Colour()),
+				Blocks: blocks,
+			},
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackNotifier_Notify(t *testing.T) {
+	var got slackPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	alert := NewAlert(SeverityWarning, "Data is stale", "No server details received for 10m0s").
+		WithField("Source", "server details", true).
+		WithFooter("Check vector")
+	alert.Timestamp = time.Unix(1704110400, 0).UTC()
+
+	err := NewSlackNotifier("slack", srv.URL).Notify(context.Background(), alert)
+	require.NoError(t, err)
+
+	require.Equal(t, "Data is stale", got.Text)
+	require.Len(t, got.Attachments, 1)
+	require.Equal(t, "#F1C40F", got.Attachments[0].Color)
+
+	blocks := got.Attachments[0].Blocks
+	require.Len(t, blocks, 4)
+	require.Equal(t, "header", blocks[0].Type)
+	require.Equal(t, &slackText{Type: "plain_text", Text: "Data is stale"}, blocks[0].Text)
+	require.Equal(t, &slackText{Type: "mrkdwn", Text: "No server details received for 10m0s"}, blocks[1].Text)
+	require.Equal(t, []*slackText{{Type: "mrkdwn", Text: "*Source*\nserver details"}}, blocks[2].Fields)
+	require.Equal(t, "context", blocks[3].Type)
+	require.Len(t, blocks[3].Elements, 2)
+	require.Contains(t, blocks[3].Elements[1].Text, "<!date^1704110400^")
+}
+
+func TestSlackMessage_SplitsFields(t *testing.T) {
+	alert := NewAlert(SeverityInfo, "title", "")
+	for i := 0; i < 12; i++ {
+		alert.WithField("name", "value", true)
+	}
+
+	blocks := slackMessage(alert).Attachments[0].Blocks
+	require.Len(t, blocks, 4)
+	require.Len(t, blocks[1].Fields, 10)
+	require.Len(t, blocks[2].Fields, 2)
+}
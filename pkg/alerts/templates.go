@@ -0,0 +1,340 @@
+package alerts
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// DefaultLocale is the locale alerts are rendered in when a destination has no locale or the template is not
+// translated to its locale.
+const DefaultLocale = "en"
+
+const (
+	// TemplateServerStateChanged is sent when the state of the server container changes.
+	TemplateServerStateChanged = "server_state_changed"
+
+	// TemplateSessionChanged is sent when the active game session changes.
+	TemplateSessionChanged = "session_changed"
+
+	// TemplateGameRunning is sent when the game starts running.
+	TemplateGameRunning = "game_running"
+
+	// TemplateGameStopped is sent when the game stops running.
+	TemplateGameStopped = "game_stopped"
+
+	// TemplateGamePaused is sent when the game is paused.
+	TemplateGamePaused = "game_paused"
+
+	// TemplateGameResumed is sent when the game is resumed.
+	TemplateGameResumed = "game_resumed"
+
+	// TemplateDataStale is sent when a data source misses its deadline.
+	TemplateDataStale = "data_stale"
+
+	// TemplateDataReceived is sent when data is received from a stale source again.
+	TemplateDataReceived = "data_received"
+
+	// TemplateServerUpdated is sent when the server build changes.
+	TemplateServerUpdated = "server_updated"
+
+	// TemplateTickRateDegraded is sent when the average tick rate drops below the threshold, and as it changes while
+	// it stays below.
+	TemplateTickRateDegraded = "tick_rate_degraded"
+
+	// TemplateTickRateRecovered is sent when the average tick rate is back above the threshold.
+	TemplateTickRateRecovered = "tick_rate_recovered"
+)
+
+// MessageTemplate is the text/template source of each part of an alert. The templates are executed with the data of
+// the alert. The parts a translation leaves empty are taken from the template of the default locale.
+type MessageTemplate struct {
+	// Title is the template of the title.
+	Title string `mapstructure:"title"`
+
+	// Description is the template of the description.
+	Description string `mapstructure:"description"`
+
+	// Footer is the template of the footer. The footer of the alert is kept when it is empty.
+	Footer string `mapstructure:"footer"`
+
+	// Fields are the templates of the fields. The fields of the alert are kept when it is empty.
+	Fields []*FieldTemplate `mapstructure:"fields"`
+}
+
+// FieldTemplate is the text/template source of a field.
+type FieldTemplate struct {
+	// Name is the template of the field name.
+	Name string `mapstructure:"name"`
+
+	// Value is the template of the field value.
+	Value string `mapstructure:"value"`
+
+	// Inline is true if the field can be shown next to other inline fields.
+	Inline bool `mapstructure:"inline"`
+}
+
+// defaultTemplates are the built-in templates of the default locale.
+var defaultTemplates = map[string]*MessageTemplate{
+	TemplateServerStateChanged: {
+		Title:       "Server state changed",
+		Description: "`{{ .Old }}` → `{{ .New }}`",
+		Footer:      "{{ .Names }}",
+		Fields: []*FieldTemplate{
+			{Name: "Status", Value: "{{ .Status }}", Inline: true},
+			{Name: "Running For", Value: "{{ .RunningFor }}", Inline: true},
+		},
+	},
+	TemplateSessionChanged: {
+		Title:       "Active session changed",
+		Description: "`{{ .Old }}` → `{{ .New }}`",
+		Fields: []*FieldTemplate{
+			{Name: "Tech Tier", Value: "{{ .TechTier }}", Inline: true},
+			{Name: "Players", Value: "{{ .Players }}/{{ .PlayerLimit }}", Inline: true},
+		},
+	},
+	TemplateGameRunning: {
+		Title:       "Game is running",
+		Description: "Session `{{ .Session }}` is running",
+	},
+	TemplateGameStopped: {
+		Title:       "Game stopped running",
+		Description: "Session `{{ .Session }}` is no longer running",
+	},
+	TemplateGamePaused: {
+		Title:       "Game paused",
+		Description: "Session `{{ .Session }}` is paused",
+	},
+	TemplateGameResumed: {
+		Title:       "Game resumed",
+		Description: "Session `{{ .Session }}` is no longer paused",
+	},
+	TemplateDataStale: {
+		Title:       "Data is stale",
+		Description: "No {{ .SourceName }} received for {{ duration .Deadline }}",
+		Footer:      "Check that vector and the scraper are running",
+	},
+	TemplateDataReceived: {
+		Title:       "Data received again",
+		Description: "Receiving {{ .SourceName }} again after {{ duration .Downtime }} without data",
+	},
+	TemplateServerUpdated: {
+		Title:       "Server updated",
+		Description: "Server updated from build `{{ .From }}` to `{{ .To }}`",
+		Footer:      "Update your game client before joining",
+		Fields: []*FieldTemplate{
+			{Name: "Previous Build", Value: "{{ .From }}", Inline: true},
+			{Name: "New Build", Value: "{{ .To }}", Inline: true},
+		},
+	},
+	TemplateTickRateDegraded: {
+		Title:       "Tick rate degraded",
+		Description: "The average tick rate is {{ printf \"%.1f\" .Current }}, below {{ printf \"%.1f\" .Threshold }}",
+		Footer:      "Large factories and many players slow the server down",
+		Fields: []*FieldTemplate{
+			{Name: "Previous", Value: "{{ printf \"%.1f\" .Previous }}", Inline: true},
+			{Name: "Current", Value: "{{ printf \"%.1f\" .Current }}", Inline: true},
+		},
+	},
+	TemplateTickRateRecovered: {
+		Title:       "Tick rate recovered",
+		Description: "The average tick rate is back up to {{ printf \"%.1f\" .Current }}",
+	},
+}
+
+// templateFuncs are the helper functions available to every template.
+var templateFuncs = template.FuncMap{
+	// duration formats the duration rounded to the second.
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Second).String()
+	},
+
+	// since returns the time elapsed since the time.
+	"since": func(t time.Time) time.Duration {
+		return time.Since(t)
+	},
+
+	// until returns the time remaining until the time.
+	"until": func(t time.Time) time.Duration {
+		return time.Until(t)
+	},
+
+	// plural returns the singular form if n is one and the plural form otherwise.
+	"plural": plural,
+}
+
+// Templates are the alert templates of each locale.
+type Templates struct {
+	// locales maps each locale to its templates by name.
+	locales map[string]map[string]*compiledTemplate
+}
+
+type compiledTemplate struct {
+	title       *template.Template
+	description *template.Template
+	footer      *template.Template
+	fields      []*compiledField
+}
+
+type compiledField struct {
+	name   *template.Template
+	value  *template.Template
+	inline bool
+}
+
+// NewTemplates creates new Templates holding the built-in templates of the default locale.
+func NewTemplates() *Templates {
+	t := &Templates{
+		locales: make(map[string]map[string]*compiledTemplate),
+	}
+
+	for name, mt := range defaultTemplates {
+		if err := t.Add(DefaultLocale, name, mt); err != nil {
+			panic(fmt.Sprintf("invalid default template %s: %s", name, err))
+		}
+	}
+
+	return t
+}
+
+// Add parses the template and adds it under the name for the locale, replacing any template with the same name.
+func (t *Templates) Add(locale, name string, mt *MessageTemplate) error {
+	parse := func(part, src string) (*template.Template, error) {
+		if src == "" {
+			return nil, nil
+		}
+
+		tmpl, err := template.New(name + "." + part).Funcs(templateFuncs).Option("missingkey=error").Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", part, err)
+		}
+		return tmpl, nil
+	}
+
+	ct := new(compiledTemplate)
+
+	var err error
+	if ct.title, err = parse("title", mt.Title); err != nil {
+		return err
+	}
+	if ct.description, err = parse("description", mt.Description); err != nil {
+		return err
+	}
+	if ct.footer, err = parse("footer", mt.Footer); err != nil {
+		return err
+	}
+
+	for i, f := range mt.Fields {
+		cf := &compiledField{inline: f.Inline}
+		if cf.name, err = parse(fmt.Sprintf("fields[%d].name", i), f.Name); err != nil {
+			return err
+		}
+		if cf.value, err = parse(fmt.Sprintf("fields[%d].value", i), f.Value); err != nil {
+			return err
+		}
+		ct.fields = append(ct.fields, cf)
+	}
+
+	if _, ok := t.locales[locale]; !ok {
+		t.locales[locale] = make(map[string]*compiledTemplate)
+	}
+	t.locales[locale][name] = ct
+
+	return nil
+}
+
+// Render returns a copy of the alert with the parts rendered from its template in the locale. Each part the locale
+// doesn't translate, or the whole template when the locale doesn't translate it, is rendered from the default locale.
+// The alert is returned as it is when it has no template.
+func (t *Templates) Render(alert *Alert, locale string) (*Alert, error) {
+	if alert.Template == "" {
+		return alert, nil
+	}
+
+	ct, ok := t.locales[locale][alert.Template]
+	def, hasDefault := t.locales[DefaultLocale][alert.Template]
+	switch {
+	case !ok && !hasDefault:
+		return nil, fmt.Errorf("unknown template: %s", alert.Template)
+	case !ok:
+		ct = def
+	case hasDefault:
+		ct = ct.withDefaults(def)
+	}
+
+	exec := func(tmpl *template.Template, fallback string) (string, error) {
+		if tmpl == nil {
+			return fallback, nil
+		}
+
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, alert.Data); err != nil {
+			return "", fmt.Errorf("execute template: %w", err)
+		}
+		return buf.String(), nil
+	}
+
+	rendered := *alert
+
+	var err error
+	if rendered.Title, err = exec(ct.title, alert.Title); err != nil {
+		return nil, err
+	}
+	if rendered.Description, err = exec(ct.description, alert.Description); err != nil {
+		return nil, err
+	}
+	if rendered.Footer, err = exec(ct.footer, alert.Footer); err != nil {
+		return nil, err
+	}
+
+	if len(ct.fields) > 0 {
+		rendered.Fields = make([]*Field, 0, len(ct.fields))
+		for _, cf := range ct.fields {
+			name, err := exec(cf.name, "")
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := exec(cf.value, "")
+			if err != nil {
+				return nil, err
+			}
+
+			rendered.Fields = append(rendered.Fields, &Field{
+				Name:   name,
+				Value:  value,
+				Inline: cf.inline,
+			})
+		}
+	}
+
+	return &rendered, nil
+}
+
+// withDefaults returns a copy of the template with the parts it leaves empty taken from the default template. The
+// fields are taken from the default template when there are none, and a field without a name or value takes it from
+// the default field in the same position.
+func (ct *compiledTemplate) withDefaults(def *compiledTemplate) *compiledTemplate {
+	merged := &compiledTemplate{
+		title:       cmp.Or(ct.title, def.title),
+		description: cmp.Or(ct.description, def.description),
+		footer:      cmp.Or(ct.footer, def.footer),
+		fields:      def.fields,
+	}
+
+	if len(ct.fields) > 0 {
+		merged.fields = make([]*compiledField, len(ct.fields))
+		for i, cf := range ct.fields {
+			field := *cf
+			if i < len(def.fields) {
+				field.name = cmp.Or(field.name, def.fields[i].name)
+				field.value = cmp.Or(field.value, def.fields[i].value)
+			}
+			merged.fields[i] = &field
+		}
+	}
+
+	return merged
+}
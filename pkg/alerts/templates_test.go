@@ -0,0 +1,147 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_RenderDefault(t *testing.T) {
+	alert := NewTemplatedAlert(SeverityCritical, TemplateServerStateChanged, map[string]any{
+		"Old":        "running",
+		"New":        "exited",
+		"Status":     "Exited (1)",
+		"RunningFor": "2 hours",
+		"Names":      "satisfactory",
+	})
+
+	got, err := NewTemplates().Render(alert, DefaultLocale)
+	require.NoError(t, err)
+
+	require.Equal(t, "Server state changed", got.Title)
+	require.Equal(t, "`running` → `exited`", got.Description)
+	require.Equal(t, "satisfactory", got.Footer)
+	require.Equal(t, []*Field{
+		{Name: "Status", Value: "Exited (1)", Inline: true},
+		{Name: "Running For", Value: "2 hours", Inline: true},
+	}, got.Fields)
+
+	// The alert itself is left untouched, so it can be rendered for other destinations.
+	require.Empty(t, alert.Title)
+}
+
+func TestTemplates_RenderLocale(t *testing.T) {
+	templates := NewTemplates()
+	require.NoError(t, templates.Add("de", TemplateDataStale, &MessageTemplate{
+		Title:       "Daten veraltet",
+		Description: "Seit {{ duration .Deadline }} keine {{ .SourceName }} empfangen",
+	}))
+
+	alert := NewTemplatedAlert(SeverityWarning, TemplateDataStale, map[string]any{
+		"SourceName": "Serverdetails",
+		"Deadline":   10*time.Minute + 300*time.Millisecond,
+	})
+
+	got, err := templates.Render(alert, "de")
+	require.NoError(t, err)
+	require.Equal(t, "Daten veraltet", got.Title)
+	require.Equal(t, "Seit 10m0s keine Serverdetails empfangen", got.Description)
+
+	// Parts missing from the translation, and untranslated templates, use the default locale.
+	require.Equal(t, "Check that vector and the scraper are running", got.Footer)
+
+	got, err = templates.Render(NewTemplatedAlert(SeverityInfo, TemplateGamePaused, map[string]any{"Session": "Factory"}), "de")
+	require.NoError(t, err)
+	require.Equal(t, "Game paused", got.Title)
+}
+
+func TestTemplates_RenderPartialTranslation(t *testing.T) {
+	templates := NewTemplates()
+	require.NoError(t, templates.Add("de", TemplateServerUpdated, &MessageTemplate{
+		Title: "Server aktualisiert",
+		Fields: []*FieldTemplate{
+			{Name: "Vorheriger Build", Inline: true},
+		},
+	}))
+
+	got, err := templates.Render(NewTemplatedAlert(SeverityInfo, TemplateServerUpdated, map[string]any{"From": "1", "To": "2"}), "de")
+	require.NoError(t, err)
+	require.Equal(t, "Server aktualisiert", got.Title)
+	require.Equal(t, "Server updated from build `1` to `2`", got.Description)
+	require.Equal(t, "Update your game client before joining", got.Footer)
+	require.Equal(t, []*Field{{Name: "Vorheriger Build", Value: "1", Inline: true}}, got.Fields)
+
+	// The default template is left as it is.
+	got, err = templates.Render(NewTemplatedAlert(SeverityInfo, TemplateServerUpdated, map[string]any{"From": "1", "To": "2"}), DefaultLocale)
+	require.NoError(t, err)
+	require.Equal(t, "Server updated", got.Title)
+	require.Len(t, got.Fields, 2)
+}
+
+func TestTemplates_Funcs(t *testing.T) {
+	templates := NewTemplates()
+	require.NoError(t, templates.Add(DefaultLocale, "players", &MessageTemplate{
+		Title: "{{ .Players }} {{ plural .Players \"player\" \"players\" }} online",
+	}))
+
+	got, err := templates.Render(NewTemplatedAlert(SeverityInfo, "players", map[string]any{"Players": 1}), DefaultLocale)
+	require.NoError(t, err)
+	require.Equal(t, "1 player online", got.Title)
+
+	got, err = templates.Render(NewTemplatedAlert(SeverityInfo, "players", map[string]any{"Players": 3}), DefaultLocale)
+	require.NoError(t, err)
+	require.Equal(t, "3 players online", got.Title)
+}
+
+func TestTemplates_Errors(t *testing.T) {
+	templates := NewTemplates()
+	require.EqualError(t, templates.Add("de", "broken", &MessageTemplate{Title: "{{ .Title"}),
+		"parse title: template: broken.title:1: unclosed action")
+
+	_, err := templates.Render(NewTemplatedAlert(SeverityInfo, "missing", nil), DefaultLocale)
+	require.EqualError(t, err, "unknown template: missing")
+
+	_, err = templates.Render(NewTemplatedAlert(SeverityInfo, TemplateGamePaused, map[string]any{}), DefaultLocale)
+	require.ErrorContains(t, err, `map has no entry for key "Session"`)
+}
+
+func TestManager_RenderFallsBackToDefaultLocale(t *testing.T) {
+	templates := NewTemplates()
+	require.NoError(t, templates.Add("de", TemplateGamePaused, &MessageTemplate{
+		Title: "Spiel {{ .Sitzung }} pausiert",
+	}))
+
+	m := NewManager(nil, WithTemplates(templates), WithLocale("discord", "de"))
+
+	got := m.Render(NewTemplatedAlert(SeverityInfo, TemplateGamePaused, map[string]any{"Session": "Factory"}), "discord")
+	require.Equal(t, "Game paused", got.Title)
+	require.Equal(t, "Session `Factory` is paused", got.Description)
+}
+
+func TestFromViper_Templates(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(strings.NewReader(`
+alerts:
+  notifiers:
+    - name: discord
+      type: discord
+      url: https://example.com
+      locale: DE
+  templates:
+    de:
+      game_paused:
+        title: Spiel pausiert
+        description: Die Sitzung {{ .Session }} ist pausiert
+`)))
+
+	m, err := FromViper(v, func(string) (string, bool) { return "", false })
+	require.NoError(t, err)
+
+	got := m.Render(NewTemplatedAlert(SeverityInfo, TemplateGamePaused, map[string]any{"Session": "Factory"}), "discord")
+	require.Equal(t, "Spiel pausiert", got.Title)
+	require.Equal(t, "Die Sitzung Factory ist pausiert", got.Description)
+}
@@ -0,0 +1,133 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+const (
+	// SignatureHeader is the header holding the HMAC signature of a generic webhook request.
+	SignatureHeader = "X-Satisfactory-Signature"
+
+	// TimestampHeader is the header holding the unix time a generic webhook request was signed at.
+	TimestampHeader = "X-Satisfactory-Timestamp"
+)
+
+// webhookFuncs are the functions available to the body templates of a generic webhook.
+var webhookFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+}
+
+type webhookNotifier struct {
+	name    string
+	url     string
+	secret  []byte
+	headers map[string]string
+	body    *template.Template
+	client  *http.Client
+	now     func() time.Time
+}
+
+// NewWebhookNotifier creates a new notifier that posts JSON to a generic webhook.
+//
+// The body is the JSON encoded alert unless a body template is given, in which case the template is executed with the
+// alert. The "json" template function encodes a value as JSON. When a secret is given, every request is signed with
+// HMAC-SHA256 over the timestamp header, a dot and the body, and the hex signature is sent as "sha256=<signature>".
+func NewWebhookNotifier(name, url, bodyTemplate, secret string, headers map[string]string) (Notifier, error) {
+	w := &webhookNotifier{
+		name:    name,
+		url:     url,
+		secret:  []byte(secret),
+		headers: headers,
+		client:  newHTTPClient(),
+		now:     time.Now,
+	}
+
+	if bodyTemplate != "" {
+		tmpl, err := template.New(name).Funcs(webhookFuncs).Parse(bodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("parse body template: %w", err)
+		}
+		w.body = tmpl
+	}
+
+	return w, nil
+}
+
+func (w *webhookNotifier) Name() string {
+	return w.name
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, alert *Alert) error {
+	bdyBytes, err := w.render(alert)
+	if err != nil {
+		return fmt.Errorf("failed to render webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewBuffer(bdyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	if len(w.secret) > 0 {
+		timestamp := strconv.FormatInt(w.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, timestamp, bdyBytes))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer closeBody(resp)
+
+	if err := rateLimited(resp); err != nil {
+		return err
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook request failed: %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (w *webhookNotifier) render(alert *Alert) ([]byte, error) {
+	if w.body == nil {
+		return json.Marshal(alert)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := w.body.Execute(buf, alert); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of a generic webhook request.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,71 @@
+package alerts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// webhookRequest is a request received by a test webhook receiver.
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, got *webhookRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		got.header = r.Header.Clone()
+		got.body = body
+		w.WriteHeader(http.StatusAccepted)
+	}))
+}
+
+func TestWebhookNotifier_DefaultBody(t *testing.T) {
+	got := new(webhookRequest)
+	srv := newWebhookReceiver(t, got)
+	defer srv.Close()
+
+	n, err := NewWebhookNotifier("hook", srv.URL, "", "", map[string]string{"x-api-key": "key"})
+	require.NoError(t, err)
+
+	alert := NewAlert(SeverityInfo, "Server updated", "build 1 to 2")
+	alert.ID = "1"
+	alert.Timestamp = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, n.Notify(context.Background(), alert))
+	require.JSONEq(t, `{"id":"1","title":"Server updated","description":"build 1 to 2","severity":"info","timestamp":"2024-01-01T00:00:00Z"}`, string(got.body))
+	require.Equal(t, "key", got.header.Get("X-Api-Key"))
+	require.Empty(t, got.header.Get(SignatureHeader))
+}
+
+func TestWebhookNotifier_TemplatedSignedBody(t *testing.T) {
+	got := new(webhookRequest)
+	srv := newWebhookReceiver(t, got)
+	defer srv.Close()
+
+	n, err := NewWebhookNotifier("hook", srv.URL, `{"text": {{ json .Title }}, "level": "{{ .Severity }}"}`, "s3cret", nil)
+	require.NoError(t, err)
+	n.(*webhookNotifier).now = func() time.Time { return time.Unix(1704067200, 0) }
+
+	require.NoError(t, n.Notify(context.Background(), NewAlert(SeverityCritical, `Game "stopped"`, "")))
+	require.JSONEq(t, `{"text": "Game \"stopped\"", "level": "critical"}`, string(got.body))
+	require.Equal(t, "1704067200", got.header.Get(TimestampHeader))
+	require.Equal(t, "sha256="+Sign([]byte("s3cret"), "1704067200", got.body), got.header.Get(SignatureHeader))
+}
+
+func TestNewWebhookNotifier_InvalidTemplate(t *testing.T) {
+	_, err := NewWebhookNotifier("hook", "http://localhost", "{{ .Title", "", nil)
+	require.Error(t, err)
+}
+
+func TestSign(t *testing.T) {
+	// Generated with: printf '1704067200.{}' | openssl dgst -sha256 -hmac secret
+	require.Equal(t, "b577d0c0bd151aca91908eac4e4a1e43a00cb9368ced75540aed0a743fbb5aae", Sign([]byte("secret"), "1704067200", []byte("{}")))
+}
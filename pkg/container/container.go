@@ -0,0 +1,27 @@
+package container
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrContainerNotFound is returned when the container does not exist.
+	ErrContainerNotFound = errors.New("container not found")
+)
+
+// Controller starts and stops the container the dedicated server runs in.
+type Controller interface {
+	// Start starts the container. It does nothing if the container is already running.
+	Start(ctx context.Context) error
+
+	// Stop stops the container, killing it if it has not stopped within the stop timeout. It does nothing if the
+	// container is already stopped.
+	Stop(ctx context.Context) error
+
+	// Restart stops the container and starts it again.
+	Restart(ctx context.Context) error
+
+	// State returns the state of the container, such as "running" or "exited".
+	State(ctx context.Context) (string, error)
+}
@@ -0,0 +1,193 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+)
+
+const (
+	// DefaultDockerSocket is the unix socket the Docker Engine API listens on.
+	DefaultDockerSocket = "/var/run/docker.sock"
+
+	// dockerHost is the host of the requests to the Docker Engine API. The socket is dialled whatever the host is.
+	dockerHost = "http://docker"
+
+	// defaultStopTimeout is how long the server has to shut down before it is killed. Saving a large world can take a
+	// while.
+	defaultStopTimeout = time.Minute
+
+	// maxDockerErrorSize is the largest error response that is read.
+	maxDockerErrorSize = 64 * 1024
+)
+
+type dockerController struct {
+	// name is the name or ID of the container.
+	name string
+
+	// stopTimeout is how long the container has to stop before it is killed.
+	stopTimeout time.Duration
+
+	// client sends the requests to the Docker Engine API.
+	client *http.Client
+}
+
+// DockerOption configures the Docker Controller.
+type DockerOption func(c *dockerController)
+
+// WithSocket talks to the Docker Engine API on the unix socket instead of the default one.
+func WithSocket(path string) DockerOption {
+	return func(c *dockerController) {
+		c.client.Transport = socketTransport(path)
+	}
+}
+
+// WithStopTimeout sets how long the container has to stop before it is killed.
+func WithStopTimeout(timeout time.Duration) DockerOption {
+	return func(c *dockerController) {
+		if timeout > 0 {
+			c.stopTimeout = timeout
+		}
+	}
+}
+
+// NewDockerController creates a new Controller for the container with the name, using the Docker Engine API.
+func NewDockerController(name string, opts ...DockerOption) Controller {
+	c := &dockerController{
+		name:        name,
+		stopTimeout: defaultStopTimeout,
+		client: &http.Client{
+			Transport: socketTransport(DefaultDockerSocket),
+		},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// socketTransport returns a transport that sends every request over the unix socket.
+func socketTransport(path string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, "unix", path)
+		},
+	}
+}
+
+func (c *dockerController) Start(ctx context.Context) error {
+	return c.post(ctx, "start", nil)
+}
+
+func (c *dockerController) Stop(ctx context.Context) error {
+	return c.post(ctx, "stop", c.timeoutQuery())
+}
+
+func (c *dockerController) Restart(ctx context.Context) error {
+	return c.post(ctx, "restart", c.timeoutQuery())
+}
+
+func (c *dockerController) State(ctx context.Context) (string, error) {
+	res, err := c.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(c.name)+"/json")
+	if err != nil {
+		return "", err
+	}
+	defer closeBody(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return "", c.responseError(res)
+	}
+
+	info := new(struct {
+		State struct {
+			Status string `json:"Status"`
+		} `json:"State"`
+	})
+	if err := json.NewDecoder(res.Body).Decode(info); err != nil {
+		return "", fmt.Errorf("decode container: %w", err)
+	}
+
+	return info.State.Status, nil
+}
+
+// timeoutQuery returns the query setting how long the container has to stop, in seconds.
+func (c *dockerController) timeoutQuery() url.Values {
+	return url.Values{
+		"t": []string{strconv.Itoa(int(c.stopTimeout.Seconds()))},
+	}
+}
+
+// post calls the container action. A container already in the state the action leads to is not an error.
+func (c *dockerController) post(ctx context.Context, action string, query url.Values) error {
+	path := "/containers/" + url.PathEscape(c.name) + "/" + action
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	// Stopping waits for the container, so give the request the stop timeout on top of the deadline of the caller.
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.stopTimeout+30*time.Second)
+		defer cancel()
+	}
+
+	res, err := c.do(ctx, http.MethodPost, path)
+	if err != nil {
+		return err
+	}
+	defer closeBody(res.Body)
+
+	switch res.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return nil
+	default:
+		return c.responseError(res)
+	}
+}
+
+func (c *dockerController) do(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, dockerHost+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call docker: %w", err)
+	}
+
+	return res, nil
+}
+
+// responseError returns the error in the response of the Docker Engine API.
+func (c *dockerController) responseError(res *http.Response) error {
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrContainerNotFound, c.name)
+	}
+
+	body := new(struct {
+		Message string `json:"message"`
+	})
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxDockerErrorSize)).Decode(body); err != nil || body.Message == "" {
+		return fmt.Errorf("docker responded with status %d", res.StatusCode)
+	}
+
+	return fmt.Errorf("docker responded with status %d: %s", res.StatusCode, body.Message)
+}
+
+func closeBody(body io.ReadCloser) {
+	if err := body.Close(); err != nil {
+		slog.Error("Error closing docker response body", slog.String(logging.KeyError, err.Error()))
+	}
+}
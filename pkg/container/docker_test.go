@@ -0,0 +1,82 @@
+package container
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dockerSocket serves the handler on a unix socket, returning the path of the socket.
+func dockerSocket(t *testing.T, handler http.HandlerFunc) string {
+	path := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	return path
+}
+
+func TestDockerController_Stop(t *testing.T) {
+	var got *http.Request
+	socket := dockerSocket(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	c := NewDockerController("satisfactory", WithSocket(socket), WithStopTimeout(90*time.Second))
+	require.NoError(t, c.Stop(context.Background()))
+
+	require.Equal(t, http.MethodPost, got.Method)
+	require.Equal(t, "/containers/satisfactory/stop", got.URL.Path)
+	require.Equal(t, "90", got.URL.Query().Get("t"))
+}
+
+func TestDockerController_StartAlreadyRunning(t *testing.T) {
+	socket := dockerSocket(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/containers/satisfactory/start", r.URL.Path)
+		w.WriteHeader(http.StatusNotModified)
+	})
+
+	require.NoError(t, NewDockerController("satisfactory", WithSocket(socket)).Start(context.Background()))
+}
+
+func TestDockerController_Errors(t *testing.T) {
+	socket := dockerSocket(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/containers/missing/restart" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message":"cannot restart container"}`))
+	})
+
+	err := NewDockerController("missing", WithSocket(socket)).Restart(context.Background())
+	require.ErrorIs(t, err, ErrContainerNotFound)
+
+	err = NewDockerController("satisfactory", WithSocket(socket)).Restart(context.Background())
+	require.EqualError(t, err, "docker responded with status 500: cannot restart container")
+}
+
+func TestDockerController_State(t *testing.T) {
+	socket := dockerSocket(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, "/containers/satisfactory/json", r.URL.Path)
+		_, _ = w.Write([]byte(`{"Id":"abc","State":{"Status":"running","Running":true}}`))
+	})
+
+	state, err := NewDockerController("satisfactory", WithSocket(socket)).State(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "running", state)
+}
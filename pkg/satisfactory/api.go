@@ -0,0 +1,411 @@
+package satisfactory
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+)
+
+const (
+	// apiPath is the path of the HTTPS API on the dedicated server.
+	apiPath = "/api/v1"
+
+	// defaultAPITimeout is how long to wait for a response when the context has no deadline.
+	defaultAPITimeout = 30 * time.Second
+
+	// maxAPIErrorSize is the largest error response that is read.
+	maxAPIErrorSize = 64 * 1024
+
+	// saveDateTimeLayout is the layout of SaveHeader.SaveDateTime.
+	saveDateTimeLayout = "2006.01.02-15.04.05"
+)
+
+// APIError is an error returned by the HTTPS API.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int `json:"-"`
+
+	// Code identifies the error, such as "insufficient_scope".
+	Code string `json:"errorCode"`
+
+	// Message describes the error.
+	Message string `json:"errorMessage"`
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api error %s (status %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("api error %s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// APIClient calls the HTTPS API of a dedicated server.
+type APIClient interface {
+	// SetClientPassword sets the password players need to join the server. An empty password removes it.
+	SetClientPassword(ctx context.Context, password string) error
+
+	// SaveGame saves the game under the name. It returns once the save is written.
+	SaveGame(ctx context.Context, name string) error
+
+	// GetServerOptions returns the server options in effect, and the changes waiting for the session to restart.
+	GetServerOptions(ctx context.Context) (*ServerOptions, error)
+
+	// ApplyServerOptions changes the server options, keyed by the name of the option.
+	ApplyServerOptions(ctx context.Context, options map[string]string) error
+
+	// RenameServer changes the name of the server.
+	RenameServer(ctx context.Context, name string) error
+
+	// SetAutoLoadSessionName sets the session loaded when the server starts.
+	SetAutoLoadSessionName(ctx context.Context, session string) error
+
+	// EnumerateSessions returns the sessions saved on the server, with their saves.
+	EnumerateSessions(ctx context.Context) ([]*Session, error)
+
+	// RunCommand runs the console command on the server, returning its output.
+	RunCommand(ctx context.Context, command string) (string, error)
+
+	// DownloadSaveGame returns the contents of the save. The caller must close it.
+	DownloadSaveGame(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// UploadSaveGame stores the save under the name, loading it straight away when load is true.
+	UploadSaveGame(ctx context.Context, name string, save io.Reader, load bool) error
+
+	// LoadGame loads the save. Everyone playing is disconnected while it loads.
+	LoadGame(ctx context.Context, name string) error
+
+	// DeleteSaveFile deletes the save.
+	DeleteSaveFile(ctx context.Context, name string) error
+}
+
+// The names of the server options.
+const (
+	OptionAutoPause             = "FG.DSAutoPause"
+	OptionAutoSaveOnDisconnect  = "FG.DSAutoSaveOnDisconnect"
+	OptionAutosaveInterval      = "FG.AutosaveInterval"
+	OptionServerRestartTimeSlot = "FG.ServerRestartTimeSlot"
+	OptionSendGameplayData      = "FG.SendGameplayData"
+	OptionNetworkQuality        = "FG.NetworkQuality"
+)
+
+// ServerOptions are the options of the server, keyed by name. The values are strings whatever their type, booleans
+// are "True" or "False".
+type ServerOptions struct {
+	// Current are the options in effect.
+	Current map[string]string `json:"serverOptions"`
+
+	// Pending are the options that take effect when the session restarts.
+	Pending map[string]string `json:"pendingServerOptions"`
+}
+
+// SaveHeader describes a save.
+type SaveHeader struct {
+	// Name is the name of the save.
+	Name string `json:"saveName"`
+
+	// SessionName is the name of the session the save belongs to.
+	SessionName string `json:"sessionName"`
+
+	// PlayDurationSeconds is how long the session had been played for when it was saved.
+	PlayDurationSeconds int64 `json:"playDurationSeconds"`
+
+	// SaveDateTime is when the game was saved, in the format of the server, such as 2024.09.10-13.52.24.
+	SaveDateTime string `json:"saveDateTime"`
+}
+
+// SavedAt returns when the game was saved. The server writes the time in UTC.
+func (h *SaveHeader) SavedAt() (time.Time, error) {
+	t, err := time.Parse(saveDateTimeLayout, h.SaveDateTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse save date: %w", err)
+	}
+	return t, nil
+}
+
+// PlayDuration returns how long the session had been played for when it was saved.
+func (h *SaveHeader) PlayDuration() time.Duration {
+	return time.Duration(h.PlayDurationSeconds) * time.Second
+}
+
+// Session is a session saved on the server.
+type Session struct {
+	// Name is the name of the session.
+	Name string `json:"sessionName"`
+
+	// Saves are the saves of the session.
+	Saves []*SaveHeader `json:"saveHeaders"`
+}
+
+type apiClient struct {
+	// address is the base URL of the server, such as https://localhost:7777.
+	address string
+
+	// token is the API token sent as the bearer token of every request.
+	token string
+
+	// client sends the requests.
+	client *http.Client
+}
+
+// APIOption configures an APIClient.
+type APIOption func(c *apiClient)
+
+// WithInsecureSkipVerify skips verifying the certificate of the server. Dedicated servers use a self-signed
+// certificate unless one is configured.
+func WithInsecureSkipVerify() APIOption {
+	return func(c *apiClient) {
+		c.client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true, // Only when asked for, as servers use a self-signed certificate.
+			},
+		}
+	}
+}
+
+// WithHTTPClient sends the requests with the client.
+func WithHTTPClient(client *http.Client) APIOption {
+	return func(c *apiClient) {
+		c.client = client
+	}
+}
+
+// NewAPIClient creates a new APIClient for the server at the base URL, authenticating with the API token.
+func NewAPIClient(address, token string, opts ...APIOption) APIClient {
+	c := &apiClient{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		client:  new(http.Client),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *apiClient) SetClientPassword(ctx context.Context, password string) error {
+	return c.call(ctx, "SetClientPassword", map[string]string{"Password": password}, nil)
+}
+
+func (c *apiClient) SaveGame(ctx context.Context, name string) error {
+	return c.call(ctx, "SaveGame", map[string]string{"SaveName": name}, nil)
+}
+
+func (c *apiClient) LoadGame(ctx context.Context, name string) error {
+	return c.call(ctx, "LoadGame", map[string]string{"SaveName": name}, nil)
+}
+
+func (c *apiClient) DeleteSaveFile(ctx context.Context, name string) error {
+	return c.call(ctx, "DeleteSaveFile", map[string]string{"SaveName": name}, nil)
+}
+
+func (c *apiClient) GetServerOptions(ctx context.Context) (*ServerOptions, error) {
+	options := new(ServerOptions)
+	if err := c.call(ctx, "GetServerOptions", nil, options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+func (c *apiClient) ApplyServerOptions(ctx context.Context, options map[string]string) error {
+	return c.call(ctx, "ApplyServerOptions", map[string]any{"UpdatedServerOptions": options}, nil)
+}
+
+func (c *apiClient) RenameServer(ctx context.Context, name string) error {
+	return c.call(ctx, "RenameServer", map[string]string{"ServerName": name}, nil)
+}
+
+func (c *apiClient) SetAutoLoadSessionName(ctx context.Context, session string) error {
+	return c.call(ctx, "SetAutoLoadSessionName", map[string]string{"SessionName": session}, nil)
+}
+
+func (c *apiClient) EnumerateSessions(ctx context.Context) ([]*Session, error) {
+	resp := new(struct {
+		Sessions []*Session `json:"sessions"`
+	})
+	if err := c.call(ctx, "EnumerateSessions", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+func (c *apiClient) RunCommand(ctx context.Context, command string) (string, error) {
+	resp := new(struct {
+		Result string `json:"commandResult"`
+	})
+	if err := c.call(ctx, "RunCommand", map[string]string{"Command": command}, resp); err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+// apiRequest is the body of a call to the API.
+type apiRequest struct {
+	Function string `json:"function"`
+	Data     any    `json:"data,omitempty"`
+}
+
+func (c *apiClient) DownloadSaveGame(ctx context.Context, name string) (io.ReadCloser, error) {
+	body, err := json.Marshal(&apiRequest{
+		Function: "DownloadSaveGame",
+		Data:     map[string]string{"SaveName": name},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	res, err := c.do(ctx, "DownloadSaveGame", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Body, nil
+}
+
+func (c *apiClient) UploadSaveGame(ctx context.Context, name string, save io.Reader, load bool) error {
+	data, err := json.Marshal(&apiRequest{
+		Function: "UploadSaveGame",
+		Data: map[string]any{
+			"SaveName":     name,
+			"LoadSaveGame": load,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	// The save is streamed into the request so it is never held in memory twice.
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeSaveForm(form, data, name, save))
+	}()
+
+	res, err := c.do(ctx, "UploadSaveGame", form.FormDataContentType(), pr)
+	if err != nil {
+		// Stop the writer if the request failed before reading all of the form.
+		_ = pr.CloseWithError(err)
+		return err
+	}
+	closeBody(res.Body)
+
+	return nil
+}
+
+// writeSaveForm writes the multipart form of an upload, with the request as the data field and the save as the file.
+func writeSaveForm(form *multipart.Writer, data []byte, name string, save io.Reader) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="data"`)
+	header.Set("Content-Type", "application/json")
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("create data part: %w", err)
+	} else if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("write data part: %w", err)
+	}
+
+	file, err := form.CreateFormFile("saveGameFile", name+".sav")
+	if err != nil {
+		return fmt.Errorf("create file part: %w", err)
+	} else if _, err := io.Copy(file, save); err != nil {
+		return fmt.Errorf("write file part: %w", err)
+	}
+
+	return form.Close()
+}
+
+// call calls the API function with the data, decoding the data of the response into resp when it is not nil.
+func (c *apiClient) call(ctx context.Context, function string, data, resp any) error {
+	body, err := json.Marshal(&apiRequest{
+		Function: function,
+		Data:     data,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultAPITimeout)
+		defer cancel()
+	}
+
+	res, err := c.do(ctx, function, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer closeBody(res.Body)
+
+	if resp == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	wrapper := struct {
+		Data any `json:"data"`
+	}{
+		Data: resp,
+	}
+	if err := json.NewDecoder(res.Body).Decode(&wrapper); err != nil {
+		return fmt.Errorf("decode %s response: %w", function, err)
+	}
+
+	return nil
+}
+
+// do sends the body to the API, returning the response if the call succeeded. The caller must close the body of the
+// response.
+func (c *apiClient) do(ctx context.Context, function, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.address+apiPath, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", function, err)
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		defer closeBody(res.Body)
+		return nil, decodeAPIError(res)
+	}
+
+	return res, nil
+}
+
+func closeBody(body io.ReadCloser) {
+	if err := body.Close(); err != nil {
+		slog.Error("Error closing api response body", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// decodeAPIError returns the error in the response. The status is used when the body isn't an API error.
+func decodeAPIError(res *http.Response) error {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(res.Body, maxAPIErrorSize))
+	if err != nil || json.Unmarshal(raw, apiErr) != nil || apiErr.Code == "" {
+		apiErr.Code = strings.ToLower(strings.ReplaceAll(http.StatusText(res.StatusCode), " ", "_"))
+	}
+
+	return apiErr
+}
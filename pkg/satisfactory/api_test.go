@@ -0,0 +1,213 @@
+package satisfactory
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// receivedRequest is a request received by the fake API.
+type receivedRequest struct {
+	Function string          `json:"function"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func TestAPIClient_SetClientPassword(t *testing.T) {
+	var got receivedRequest
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, apiPath, r.URL.Path)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewAPIClient(srv.URL+"/", "token", WithInsecureSkipVerify())
+	require.NoError(t, client.SetClientPassword(context.Background(), "hunter2"))
+
+	require.Equal(t, "SetClientPassword", got.Function)
+	require.JSONEq(t, `{"Password":"hunter2"}`, string(got.Data))
+}
+
+func TestAPIClient_Error(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errorCode":"insufficient_scope","errorMessage":"The token can't do that"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	err := NewAPIClient(srv.URL, "token", WithHTTPClient(srv.Client())).SetClientPassword(context.Background(), "hunter2")
+
+	apiErr := new(APIError)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	require.Equal(t, "insufficient_scope", apiErr.Code)
+	require.Equal(t, "The token can't do that", apiErr.Message)
+}
+
+func TestAPIClient_ErrorWithoutBody(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := NewAPIClient(srv.URL, "", WithHTTPClient(srv.Client())).SetClientPassword(context.Background(), "")
+
+	apiErr := new(APIError)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, "unauthorized", apiErr.Code)
+}
+
+func TestAPIClient_SaveGame(t *testing.T) {
+	var got receivedRequest
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	require.NoError(t, NewAPIClient(srv.URL, "token", WithHTTPClient(srv.Client())).SaveGame(context.Background(), "before_stop"))
+
+	require.Equal(t, "SaveGame", got.Function)
+	require.JSONEq(t, `{"SaveName":"before_stop"}`, string(got.Data))
+}
+
+func TestAPIClient_LoadAndDeleteSave(t *testing.T) {
+	got := make([]receivedRequest, 0)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := receivedRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		got = append(got, req)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewAPIClient(srv.URL, "token", WithHTTPClient(srv.Client()))
+	require.NoError(t, client.LoadGame(context.Background(), "Factory_autosave_0"))
+	require.NoError(t, client.DeleteSaveFile(context.Background(), "Factory_autosave_1"))
+
+	require.Len(t, got, 2)
+	require.Equal(t, "LoadGame", got[0].Function)
+	require.JSONEq(t, `{"SaveName":"Factory_autosave_0"}`, string(got[0].Data))
+	require.Equal(t, "DeleteSaveFile", got[1].Function)
+	require.JSONEq(t, `{"SaveName":"Factory_autosave_1"}`, string(got[1].Data))
+}
+
+func TestAPIClient_GetServerOptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"serverOptions":{"FG.AutosaveInterval":"300","FG.NetworkQuality":"3"},"pendingServerOptions":{"FG.AutosaveInterval":"600"}}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	options, err := NewAPIClient(srv.URL, "token", WithHTTPClient(srv.Client())).GetServerOptions(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "300", options.Current[OptionAutosaveInterval])
+	require.Equal(t, "3", options.Current[OptionNetworkQuality])
+	require.Equal(t, map[string]string{OptionAutosaveInterval: "600"}, options.Pending)
+}
+
+func TestAPIClient_EnumerateSessions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got receivedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		require.Equal(t, "EnumerateSessions", got.Function)
+		require.Empty(t, got.Data)
+
+		_, _ = w.Write([]byte(`{"data":{"currentSessionIndex":0,"sessions":[{"sessionName":"Factory","saveHeaders":[{"saveName":"Factory_autosave_0","sessionName":"Factory","playDurationSeconds":3600,"saveDateTime":"2024.09.10-13.52.24"}]}]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	sessions, err := NewAPIClient(srv.URL, "token", WithHTTPClient(srv.Client())).EnumerateSessions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, "Factory", sessions[0].Name)
+	require.Equal(t, &SaveHeader{
+		Name:                "Factory_autosave_0",
+		SessionName:         "Factory",
+		PlayDurationSeconds: 3600,
+		SaveDateTime:        "2024.09.10-13.52.24",
+	}, sessions[0].Saves[0])
+
+	savedAt, err := sessions[0].Saves[0].SavedAt()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.September, 10, 13, 52, 24, 0, time.UTC), savedAt)
+	require.Equal(t, time.Hour, sessions[0].Saves[0].PlayDuration())
+}
+
+func TestAPIClient_RunCommand(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got receivedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		require.Equal(t, "RunCommand", got.Function)
+		require.JSONEq(t, `{"Command":"FG.AutosaveInterval"}`, string(got.Data))
+
+		_, _ = w.Write([]byte(`{"data":{"commandResult":"FG.AutosaveInterval = \"300\"","returnValue":true}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	out, err := NewAPIClient(srv.URL, "token", WithHTTPClient(srv.Client())).RunCommand(context.Background(), "FG.AutosaveInterval")
+	require.NoError(t, err)
+	require.Equal(t, `FG.AutosaveInterval = "300"`, out)
+}
+
+func TestAPIClient_DownloadSaveGame(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got receivedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		require.Equal(t, "DownloadSaveGame", got.Function)
+
+		if string(got.Data) != `{"SaveName":"Factory_autosave_0"}` {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errorCode":"file_not_found","errorMessage":"Save not found"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte("save contents"))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewAPIClient(srv.URL, "token", WithHTTPClient(srv.Client()))
+
+	save, err := client.DownloadSaveGame(context.Background(), "Factory_autosave_0")
+	require.NoError(t, err)
+	contents, err := io.ReadAll(save)
+	require.NoError(t, err)
+	require.NoError(t, save.Close())
+	require.Equal(t, "save contents", string(contents))
+
+	_, err = client.DownloadSaveGame(context.Background(), "Missing")
+	apiErr := new(APIError)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, "file_not_found", apiErr.Code)
+}
+
+func TestAPIClient_UploadSaveGame(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		require.JSONEq(t, `{"function":"UploadSaveGame","data":{"SaveName":"Imported","LoadSaveGame":true}}`, r.FormValue("data"))
+
+		file, header, err := r.FormFile("saveGameFile")
+		require.NoError(t, err)
+		require.Equal(t, "Imported.sav", header.Filename)
+
+		contents, err := io.ReadAll(file)
+		require.NoError(t, err)
+		require.Equal(t, "save contents", string(contents))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewAPIClient(srv.URL, "token", WithHTTPClient(srv.Client()))
+	require.NoError(t, client.UploadSaveGame(context.Background(), "Imported", strings.NewReader("save contents"), true))
+}
@@ -0,0 +1,227 @@
+package satisfactory
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+)
+
+const (
+	// queryProtocolMagic identifies messages of the lightweight query API.
+	queryProtocolMagic uint16 = 0xF6D5
+
+	// queryProtocolVersion is the version of the lightweight query API that is supported.
+	queryProtocolVersion uint8 = 1
+
+	// queryMessageTerminator is the byte every message of the lightweight query API ends with.
+	queryMessageTerminator uint8 = 0x1
+
+	// queryMessagePollServerState is the message type of a server state request.
+	queryMessagePollServerState uint8 = 0
+
+	// queryMessageServerStateResponse is the message type of a server state response.
+	queryMessageServerStateResponse uint8 = 1
+
+	// defaultQueryTimeout is how long to wait for a response when the context has no deadline.
+	defaultQueryTimeout = 5 * time.Second
+
+	// maxQueryResponseSize is the largest response that is read.
+	maxQueryResponseSize = 4096
+)
+
+var (
+	// ErrUnexpectedResponse is returned when the server responds with a message that is not understood.
+	ErrUnexpectedResponse = errors.New("unexpected response from server")
+)
+
+// ServerStatus is the state of the server as reported by the lightweight query API.
+type ServerStatus uint8
+
+const (
+	ServerStatusOffline ServerStatus = iota
+	ServerStatusIdle
+	ServerStatusLoading
+	ServerStatusPlaying
+)
+
+// String returns the string representation of the ServerStatus.
+func (s ServerStatus) String() string {
+	switch s {
+	case ServerStatusOffline:
+		return "offline"
+	case ServerStatusIdle:
+		return "idle"
+	case ServerStatusLoading:
+		return "loading"
+	case ServerStatusPlaying:
+		return "playing"
+	default:
+		return fmt.Sprintf("unknown (%d)", uint8(s))
+	}
+}
+
+// SubState is the version of a piece of server state. The version changes whenever the state changes.
+type SubState struct {
+	ID      uint8
+	Version uint16
+}
+
+// ServerState is the response to a server state poll.
+type ServerState struct {
+	// Status is the current state of the server.
+	Status ServerStatus
+
+	// NetCL is the changelist of the server build.
+	NetCL uint32
+
+	// Flags are the server flags.
+	Flags uint64
+
+	// SubStates are the versions of the server sub states.
+	SubStates []SubState
+
+	// Name is the name of the server.
+	Name string
+}
+
+// QueryClient polls the lightweight query API of a dedicated server over UDP.
+type QueryClient interface {
+	// PollServerState returns the current state of the server.
+	PollServerState(ctx context.Context) (*ServerState, error)
+}
+
+type queryClient struct {
+	// address is the host:port of the query API.
+	address string
+}
+
+// NewQueryClient creates a new QueryClient for the given host:port.
+func NewQueryClient(address string) QueryClient {
+	return &queryClient{
+		address: address,
+	}
+}
+
+func (c *queryClient) PollServerState(ctx context.Context) (*ServerState, error) {
+	dialer := new(net.Dialer)
+	conn, err := dialer.DialContext(ctx, "udp", c.address)
+	if err != nil {
+		return nil, fmt.Errorf("dial query api: %w", err)
+	}
+	defer func(conn net.Conn) {
+		if err := conn.Close(); err != nil {
+			slog.Error("Error closing query connection", slog.String(logging.KeyError, err.Error()))
+		}
+	}(conn)
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultQueryTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("set deadline: %w", err)
+	}
+
+	var cookie uint64
+	if err := binary.Read(rand.Reader, binary.LittleEndian, &cookie); err != nil {
+		return nil, fmt.Errorf("generate cookie: %w", err)
+	}
+
+	if _, err := conn.Write(encodePollServerState(cookie)); err != nil {
+		return nil, fmt.Errorf("send poll request: %w", err)
+	}
+
+	buf := make([]byte, maxQueryResponseSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("read poll response: %w", err)
+	}
+
+	return decodeServerStateResponse(buf[:n], cookie)
+}
+
+// encodePollServerState returns the bytes of a server state request.
+func encodePollServerState(cookie uint64) []byte {
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.LittleEndian, queryProtocolMagic)
+	buf.WriteByte(queryMessagePollServerState)
+	buf.WriteByte(queryProtocolVersion)
+	_ = binary.Write(buf, binary.LittleEndian, cookie)
+	buf.WriteByte(queryMessageTerminator)
+	return buf.Bytes()
+}
+
+// decodeServerStateResponse parses a server state response, checking that it answers the request with the cookie.
+func decodeServerStateResponse(data []byte, cookie uint64) (*ServerState, error) {
+	r := bytes.NewReader(data)
+
+	var header struct {
+		Magic    uint16
+		Type     uint8
+		Version  uint8
+		Cookie   uint64
+		Status   uint8
+		NetCL    uint32
+		Flags    uint64
+		NumState uint8
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	switch {
+	case header.Magic != queryProtocolMagic:
+		return nil, fmt.Errorf("%w: invalid magic %#x", ErrUnexpectedResponse, header.Magic)
+	case header.Type != queryMessageServerStateResponse:
+		return nil, fmt.Errorf("%w: message type %d", ErrUnexpectedResponse, header.Type)
+	case header.Version != queryProtocolVersion:
+		return nil, fmt.Errorf("%w: protocol version %d", ErrUnexpectedResponse, header.Version)
+	case header.Cookie != cookie:
+		return nil, fmt.Errorf("%w: cookie mismatch", ErrUnexpectedResponse)
+	}
+
+	state := &ServerState{
+		Status:    ServerStatus(header.Status),
+		NetCL:     header.NetCL,
+		Flags:     header.Flags,
+		SubStates: make([]SubState, header.NumState),
+	}
+
+	for i := range state.SubStates {
+		if err := binary.Read(r, binary.LittleEndian, &state.SubStates[i].ID); err != nil {
+			return nil, fmt.Errorf("read sub state id: %w", err)
+		}
+		if err := binary.Read(r, binary.LittleEndian, &state.SubStates[i].Version); err != nil {
+			return nil, fmt.Errorf("read sub state version: %w", err)
+		}
+	}
+
+	var nameLen uint16
+	if err := binary.Read(r, binary.LittleEndian, &nameLen); err != nil {
+		return nil, fmt.Errorf("read server name length: %w", err)
+	}
+
+	name := make([]byte, nameLen)
+	if _, err := io.ReadFull(r, name); err != nil {
+		return nil, fmt.Errorf("read server name: %w", err)
+	}
+	state.Name = string(name)
+
+	terminator, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("read terminator: %w", err)
+	} else if terminator != queryMessageTerminator {
+		return nil, fmt.Errorf("%w: invalid terminator %#x", ErrUnexpectedResponse, terminator)
+	}
+
+	return state, nil
+}
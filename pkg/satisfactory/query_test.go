@@ -0,0 +1,88 @@
+package satisfactory
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// encodeServerStateResponse returns the bytes of a server state response for the given cookie.
+func encodeServerStateResponse(cookie uint64, state *ServerState) []byte {
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.LittleEndian, queryProtocolMagic)
+	buf.WriteByte(queryMessageServerStateResponse)
+	buf.WriteByte(queryProtocolVersion)
+	_ = binary.Write(buf, binary.LittleEndian, cookie)
+	buf.WriteByte(byte(state.Status))
+	_ = binary.Write(buf, binary.LittleEndian, state.NetCL)
+	_ = binary.Write(buf, binary.LittleEndian, state.Flags)
+	buf.WriteByte(byte(len(state.SubStates)))
+	for _, sub := range state.SubStates {
+		buf.WriteByte(sub.ID)
+		_ = binary.Write(buf, binary.LittleEndian, sub.Version)
+	}
+	_ = binary.Write(buf, binary.LittleEndian, uint16(len(state.Name)))
+	buf.WriteString(state.Name)
+	buf.WriteByte(queryMessageTerminator)
+	return buf.Bytes()
+}
+
+func TestDecodeServerStateResponse(t *testing.T) {
+	want := &ServerState{
+		Status:    ServerStatusPlaying,
+		NetCL:     365306,
+		Flags:     1,
+		SubStates: []SubState{{ID: 0, Version: 4}, {ID: 1, Version: 2}},
+		Name:      "Factory",
+	}
+
+	got, err := decodeServerStateResponse(encodeServerStateResponse(42, want), 42)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestDecodeServerStateResponse_CookieMismatch(t *testing.T) {
+	_, err := decodeServerStateResponse(encodeServerStateResponse(42, &ServerState{SubStates: []SubState{}}), 43)
+	require.ErrorIs(t, err, ErrUnexpectedResponse)
+}
+
+func TestDecodeServerStateResponse_Truncated(t *testing.T) {
+	data := encodeServerStateResponse(42, &ServerState{Name: "Factory", SubStates: []SubState{}})
+	_, err := decodeServerStateResponse(data[:len(data)-4], 42)
+	require.Error(t, err)
+}
+
+func TestQueryClient_PollServerState(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	want := &ServerState{
+		Status:    ServerStatusIdle,
+		NetCL:     365306,
+		SubStates: []SubState{},
+		Name:      "Factory",
+	}
+
+	go func() {
+		buf := make([]byte, 64)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil || n != 13 {
+			return
+		}
+		cookie := binary.LittleEndian.Uint64(buf[4:12])
+		_, _ = conn.WriteTo(encodeServerStateResponse(cookie, want), addr)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	got, err := NewQueryClient(conn.LocalAddr().String()).PollServerState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
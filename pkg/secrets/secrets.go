@@ -0,0 +1,37 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSecretNotFound is returned when there is no secret at the path.
+	ErrSecretNotFound = errors.New("secret not found")
+)
+
+// Store reads and writes secrets. Each secret is a set of named values stored at a path.
+type Store interface {
+	// Get returns the values of the secret at the path. It returns ErrSecretNotFound if there is no secret.
+	Get(ctx context.Context, path string) (map[string]string, error)
+
+	// Put replaces the values of the secret at the path.
+	Put(ctx context.Context, path string, values map[string]string) error
+}
+
+// Value returns the named value of the secret at the path. It returns an error naming the path and key if the
+// value is missing or empty.
+func Value(ctx context.Context, store Store, path, key string) (string, error) {
+	values, err := store.Get(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := values[key]
+	if !ok || value == "" {
+		return "", fmt.Errorf("secret %s has no value for key %q", path, key)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,42 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mapStore map[string]map[string]string
+
+func (m mapStore) Get(_ context.Context, path string) (map[string]string, error) {
+	values, ok := m[path]
+	if !ok {
+		return nil, ErrSecretNotFound
+	}
+	return values, nil
+}
+
+func (m mapStore) Put(_ context.Context, path string, values map[string]string) error {
+	m[path] = values
+	return nil
+}
+
+func TestValue(t *testing.T) {
+	store := mapStore{
+		"satisfactory/api": {"token": "abc123", "empty": ""},
+	}
+
+	value, err := Value(context.Background(), store, "satisfactory/api", "token")
+	require.NoError(t, err)
+	require.Equal(t, "abc123", value)
+
+	_, err = Value(context.Background(), store, "satisfactory/api", "missing")
+	require.EqualError(t, err, `secret satisfactory/api has no value for key "missing"`)
+
+	_, err = Value(context.Background(), store, "satisfactory/api", "empty")
+	require.EqualError(t, err, `secret satisfactory/api has no value for key "empty"`)
+
+	_, err = Value(context.Background(), store, "satisfactory/other", "token")
+	require.ErrorIs(t, err, ErrSecretNotFound)
+}
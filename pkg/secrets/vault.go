@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jacobbrewer1/vaulty"
+)
+
+type vaultStore struct {
+	client vaulty.ClientHandler
+	mount  string
+}
+
+// NewVaultStore creates a new Store backed by the KV version 2 secrets engine at the mount.
+func NewVaultStore(client vaulty.ClientHandler, mount string) Store {
+	return &vaultStore{
+		client: client,
+		mount:  mount,
+	}
+}
+
+func (v *vaultStore) Get(ctx context.Context, path string) (map[string]string, error) {
+	secret, err := v.client.Client().KVv2(v.mount).Get(ctx, path)
+	if errors.Is(err, vaulty.ErrSecretNotFound) {
+		return nil, ErrSecretNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("get secret from vault: %w", err)
+	}
+
+	values := make(map[string]string, len(secret.Data))
+	for k, val := range secret.Data {
+		str, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("secret value %s is a %T, not a string", k, val)
+		}
+		values[k] = str
+	}
+
+	return values, nil
+}
+
+func (v *vaultStore) Put(ctx context.Context, path string, values map[string]string) error {
+	data := make(map[string]any, len(values))
+	for k, val := range values {
+		data[k] = val
+	}
+
+	if _, err := v.client.Client().KVv2(v.mount).Put(ctx, path, data); err != nil {
+		return fmt.Errorf("put secret in vault: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// accessDeniedResponse is shown to the user when a policy doesn't let them use a command.
+const accessDeniedResponse = "You don't have access to this command"
+
+// AccessPolicy limits who can use a command. A user must be in one of the guilds, when any are set, and either be one
+// of the users or have one of the roles, when any are set.
+type AccessPolicy struct {
+	// Roles are the IDs of the roles allowed to use the command.
+	Roles []string `mapstructure:"roles"`
+
+	// Users are the IDs of the users allowed to use the command.
+	Users []string `mapstructure:"users"`
+
+	// Guilds are the IDs of the guilds the command can be used in.
+	Guilds []string `mapstructure:"guilds"`
+
+	// DefaultMemberPermissions replaces the permissions a member needs to see the command, registered with discord.
+	// The declared permissions of the command are kept when it is nil.
+	DefaultMemberPermissions *int64 `mapstructure:"default_member_permissions"`
+}
+
+// Allows returns true if the policy lets the user that created the interaction use the command.
+func (p *AccessPolicy) Allows(i *discordgo.InteractionCreate) bool {
+	if p == nil {
+		return true
+	}
+
+	if len(p.Guilds) > 0 && !slices.Contains(p.Guilds, i.GuildID) {
+		return false
+	}
+
+	if len(p.Users) == 0 && len(p.Roles) == 0 {
+		return true
+	}
+
+	if slices.Contains(p.Users, interactionUserID(i)) {
+		return true
+	}
+
+	// Roles only exist in guilds, so a direct message is only allowed by the user ID.
+	if i.Member == nil {
+		return false
+	}
+
+	for _, role := range i.Member.Roles {
+		if slices.Contains(p.Roles, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// interactionUserID returns the ID of the user that created the interaction.
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	switch {
+	case i.Member != nil && i.Member.User != nil:
+		return i.Member.User.ID
+	case i.User != nil:
+		return i.User.ID
+	default:
+		return ""
+	}
+}
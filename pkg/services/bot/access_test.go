@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+// memberInteraction returns an interaction created by the member of the guild with the roles.
+func memberInteraction(guildID, userID string, roles ...string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			GuildID: guildID,
+			Member: &discordgo.Member{
+				User:  &discordgo.User{ID: userID, Username: "user-" + userID},
+				Roles: roles,
+			},
+		},
+	}
+}
+
+func TestAccessPolicy_Allows(t *testing.T) {
+	dm := &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			User: &discordgo.User{ID: "1"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		policy *AccessPolicy
+		i      *discordgo.InteractionCreate
+		want   bool
+	}{
+		{name: "no policy", policy: nil, i: memberInteraction("g1", "1"), want: true},
+		{name: "guild allowed", policy: &AccessPolicy{Guilds: []string{"g1"}}, i: memberInteraction("g1", "1"), want: true},
+		{name: "other guild", policy: &AccessPolicy{Guilds: []string{"g1"}}, i: memberInteraction("g2", "1"), want: false},
+		{name: "role", policy: &AccessPolicy{Roles: []string{"admin"}}, i: memberInteraction("g1", "1", "player", "admin"), want: true},
+		{name: "missing role", policy: &AccessPolicy{Roles: []string{"admin"}}, i: memberInteraction("g1", "1", "player"), want: false},
+		{name: "user", policy: &AccessPolicy{Roles: []string{"admin"}, Users: []string{"1"}}, i: memberInteraction("g1", "1"), want: true},
+		{name: "role in other guild", policy: &AccessPolicy{Roles: []string{"admin"}, Guilds: []string{"g1"}}, i: memberInteraction("g2", "1", "admin"), want: false},
+		{name: "direct message user", policy: &AccessPolicy{Users: []string{"1"}}, i: dm, want: true},
+		{name: "direct message role", policy: &AccessPolicy{Roles: []string{"admin"}}, i: dm, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.policy.Allows(tt.i))
+		})
+	}
+}
+
+// memoryAuditLog is an auditLog kept in memory.
+type memoryAuditLog struct {
+	entries []*auditEntry
+}
+
+func (m *memoryAuditLog) Record(_ context.Context, entry *auditEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryAuditLog) Entries(context.Context, *auditFilter) ([]*auditEntry, error) {
+	return m.entries, nil
+}
+
+func TestRegistry_HandleAccessPolicy(t *testing.T) {
+	var called string
+	r := testRegistry(&called)
+	r.byName["alerts"].sensitive = true
+	r.policies["alerts"] = &AccessPolicy{Roles: []string{"admin"}}
+
+	audit := new(memoryAuditLog)
+	r.audit = audit
+
+	var responded string
+	r.respondError = func(_ *discordgo.Session, _ *discordgo.InteractionCreate, msg string) {
+		responded = msg
+	}
+
+	mute := &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "mute",
+		Type: discordgo.ApplicationCommandOptionSubCommand,
+	}
+
+	denied := memberInteraction("g1", "1", "player")
+	denied.Type = discordgo.InteractionApplicationCommand
+	denied.Data = discordgo.ApplicationCommandInteractionData{Name: "alerts", Options: []*discordgo.ApplicationCommandInteractionDataOption{mute}}
+
+	r.handle(nil, denied)
+	require.Empty(t, called)
+	require.Equal(t, accessDeniedResponse, responded)
+
+	allowed := memberInteraction("g1", "2", "admin")
+	allowed.Type = discordgo.InteractionApplicationCommand
+	allowed.Data = denied.Data
+
+	r.handle(nil, allowed)
+	require.Equal(t, "alerts mute", called)
+
+	require.Len(t, audit.entries, 2)
+	require.Equal(t, "alerts mute", audit.entries[0].Command)
+	require.Equal(t, "1", audit.entries[0].UserID)
+	require.Equal(t, "g1", audit.entries[0].GuildID)
+	require.False(t, audit.entries[0].Allowed)
+	require.True(t, audit.entries[1].Allowed)
+
+	// Commands that aren't sensitive are not audited.
+	r.handle(nil, commandInteraction("ping"))
+	require.Len(t, audit.entries, 2)
+}
+
+func TestRegistry_PolicyPermissions(t *testing.T) {
+	var called string
+	r := testRegistry(&called)
+	r.policies["ping"] = &AccessPolicy{DefaultMemberPermissions: utils.Ptr(int64(discordgo.PermissionManageServer))}
+
+	cmds := r.applicationCommands()
+	require.Equal(t, int64(discordgo.PermissionManageServer), *cmds[0].DefaultMemberPermissions)
+	require.Nil(t, cmds[1].DefaultMemberPermissions)
+}
+
+func TestRegistry_HandleTopLevelPermissions(t *testing.T) {
+	var called string
+	r := testRegistry(&called)
+	r.byName["ping"].permissions = utils.Ptr(int64(discordgo.PermissionManageServer))
+	r.byName["ping"].sensitive = true
+
+	var responded string
+	r.respondError = func(_ *discordgo.Session, _ *discordgo.InteractionCreate, msg string) {
+		responded = msg
+	}
+
+	ping := func(i *discordgo.InteractionCreate) *discordgo.InteractionCreate {
+		i.Type = discordgo.InteractionApplicationCommand
+		i.Data = discordgo.ApplicationCommandInteractionData{Name: "ping"}
+		return i
+	}
+
+	// A guild overriding the permissions in discord doesn't let members without them in.
+	r.handle(nil, ping(memberInteraction("g1", "1")))
+	require.Empty(t, called)
+	require.Equal(t, accessDeniedResponse, responded)
+
+	// Nor can the command be used in a direct message.
+	r.handle(nil, ping(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{User: &discordgo.User{ID: "1"}}}))
+	require.Empty(t, called)
+
+	manager := ping(memberInteraction("g1", "2"))
+	manager.Member.Permissions = discordgo.PermissionManageServer
+	r.handle(nil, manager)
+	require.Equal(t, "ping", called)
+
+	// The permissions of the policy replace the declared ones.
+	called = ""
+	r.policies["ping"] = &AccessPolicy{DefaultMemberPermissions: utils.Ptr(int64(0))}
+	r.handle(nil, ping(memberInteraction("g1", "1")))
+	require.Equal(t, "ping", called)
+
+	cmds := r.applicationCommands()
+	require.False(t, *cmds[0].DMPermission)
+	require.Nil(t, cmds[1].DMPermission)
+}
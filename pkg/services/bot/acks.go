@@ -0,0 +1,305 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/bwmarrin/discordgo"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// alertAckComponentID prefixes the custom ID of the acknowledge button of an alert.
+	alertAckComponentID = "alert-ack"
+
+	// alertResolveComponentID prefixes the custom ID of the resolve button of an alert.
+	alertResolveComponentID = "alert-resolve"
+
+	// defaultAckTimeout is how long an alert can go unacknowledged before it is escalated.
+	defaultAckTimeout = 15 * time.Minute
+
+	// maxEscalations is the number of times an alert is escalated before the bot stops mentioning anyone.
+	maxEscalations = 3
+
+	// escalationCheckInterval is how often the unacknowledged alerts are checked.
+	escalationCheckInterval = 30 * time.Second
+
+	// alertQueueTimeout is how long to block waiting for an alert, in seconds, so shutdown is noticed.
+	alertQueueTimeout = 5
+)
+
+// watchAlerts posts the alerts handed to the bot until the bot stops.
+func (s *service) watchAlerts() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			slog.Debug("Context done")
+			return
+		default:
+		}
+
+		reply, err := redisgo.ByteSlices(goredis.DoCtx(s.ctx, "BLPOP", s.alertQueue, alertQueueTimeout))
+		if errors.Is(err, redisgo.ErrNil) {
+			continue
+		} else if err != nil {
+			slog.Error("Error getting alert from queue", slog.String(logging.KeyError, err.Error()))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		alert := new(alerts.Alert)
+		if err := json.Unmarshal(reply[1], alert); err != nil {
+			slog.Error("Error unmarshalling alert", slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+
+		if err := s.postAlert(alert); err != nil {
+			slog.Error("Error posting alert", slog.String("alert", alert.ID), slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// postAlert posts the alert with buttons to acknowledge and resolve it.
+func (s *service) postAlert(alert *alerts.Alert) error {
+	ack := &alerts.Acknowledgement{
+		Alert:     alert,
+		ChannelID: s.alertChannelID,
+	}
+
+	msg, err := s.s.ChannelMessageSendComplex(s.alertChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{ackEmbed(ack, "")},
+		Components: alertComponents(alert.ID, false),
+	})
+	if err != nil {
+		return fmt.Errorf("send alert message: %w", err)
+	}
+
+	now := time.Now().UTC()
+	ack.MessageID = msg.ID
+	ack.PostedAt = now
+	ack.LastNotifiedAt = now
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	if err := s.acks.Open(ctx, ack); err != nil {
+		return fmt.Errorf("open acknowledgement: %w", err)
+	}
+
+	return nil
+}
+
+func (s *service) onAlertAck(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alertID := componentArg(i)
+	user := interactionUser(i)
+
+	ack, err := s.acks.Acknowledge(ctx, alertID, user, time.Now())
+	if errors.Is(err, alerts.ErrAlertNotFound) {
+		s.respondEphemeral(i, "This alert has already been resolved")
+		return
+	} else if err != nil {
+		slog.Error("Error acknowledging alert", slog.String("alert", alertID), slog.String(logging.KeyError, err.Error()))
+		s.respondEphemeral(i, "Error acknowledging alert")
+		return
+	}
+
+	if ack.AckedBy != user {
+		s.respondEphemeral(i, fmt.Sprintf("%s is already on it", ack.AckedBy))
+		return
+	}
+
+	slog.Info("Alert acknowledged", slog.String("alert", alertID), slog.String("user", user))
+	err = s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{ackEmbed(ack, "")},
+			Components: alertComponents(alertID, true),
+		},
+	})
+	if err != nil {
+		slog.Error("Error updating acknowledged alert", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+func (s *service) onAlertResolve(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alertID := componentArg(i)
+	user := interactionUser(i)
+
+	ack, err := s.acks.Resolve(ctx, alertID)
+	if errors.Is(err, alerts.ErrAlertNotFound) {
+		s.respondEphemeral(i, "This alert has already been resolved")
+		return
+	} else if err != nil {
+		slog.Error("Error resolving alert", slog.String("alert", alertID), slog.String(logging.KeyError, err.Error()))
+		s.respondEphemeral(i, "Error resolving alert")
+		return
+	}
+
+	slog.Info("Alert resolved", slog.String("alert", alertID), slog.String("user", user))
+	err = s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{ackEmbed(ack, user)},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		slog.Error("Error updating resolved alert", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// watchEscalations escalates the alerts that have not been acknowledged in time until the bot stops.
+func (s *service) watchEscalations() {
+	ticker := time.NewTicker(escalationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			slog.Debug("Context done")
+			return
+		case <-ticker.C:
+			s.checkEscalations()
+		}
+	}
+}
+
+func (s *service) checkEscalations() {
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	pending, err := s.acks.Unacknowledged(ctx)
+	if err != nil {
+		slog.Error("Error getting unacknowledged alerts", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	now := time.Now()
+	for _, ack := range pending {
+		if ack.Escalations >= maxEscalations || now.Sub(ack.LastNotifiedAt) < s.ackTimeout {
+			continue
+		}
+
+		if err := s.escalate(ack, now); err != nil {
+			slog.Error("Error escalating alert", slog.String("alert", ack.Alert.ID), slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+
+		if err := s.acks.Escalated(ctx, ack.Alert.ID, now); err != nil {
+			slog.Error("Error recording escalation", slog.String("alert", ack.Alert.ID), slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// escalate replies to the alert mentioning the escalation role, or everyone in the channel when there is no role.
+func (s *service) escalate(ack *alerts.Acknowledgement, now time.Time) error {
+	mention := "@here"
+	allowed := &discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeEveryone},
+	}
+	if s.escalationRoleID != "" {
+		mention = "<@&" + s.escalationRoleID + ">"
+		allowed = &discordgo.MessageAllowedMentions{
+			Roles: []string{s.escalationRoleID},
+		}
+	}
+
+	_, err := s.s.ChannelMessageSendComplex(ack.ChannelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf(
+			"%s **%s** has not been acknowledged for %s",
+			mention,
+			ack.Alert.Title,
+			now.Sub(ack.PostedAt).Round(time.Minute),
+		),
+		AllowedMentions: allowed,
+		Reference: &discordgo.MessageReference{
+			MessageID: ack.MessageID,
+			ChannelID: ack.ChannelID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("send escalation message: %w", err)
+	}
+
+	slog.Info("Alert escalated", slog.String("alert", ack.Alert.ID), slog.Int("escalation", ack.Escalations+1))
+	return nil
+}
+
+// ackEmbed renders the alert with who acknowledged it and, once it is resolved, who resolved it.
+func ackEmbed(ack *alerts.Acknowledgement, resolvedBy string) *discordgo.MessageEmbed {
+	embed := alerts.DiscordEmbed(ack.Alert)
+
+	if ack.AckedBy != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Acknowledged By",
+			Value:  ack.AckedBy,
+			Inline: true,
+		})
+	}
+
+	if resolvedBy != "" {
+		embed.Color = alerts.SeverityOK.Colour()
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Resolved By",
+			Value:  resolvedBy,
+			Inline: true,
+		})
+	}
+
+	return embed
+}
+
+// alertComponents returns the buttons of an open alert.
+func alertComponents(alertID string, acked bool) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Acknowledge",
+					Style:    discordgo.PrimaryButton,
+					Disabled: acked,
+					CustomID: alertAckComponentID + ":" + alertID,
+				},
+				discordgo.Button{
+					Label:    "Resolve",
+					Style:    discordgo.SuccessButton,
+					CustomID: alertResolveComponentID + ":" + alertID,
+				},
+			},
+		},
+	}
+}
+
+// componentArg returns the part of the custom ID of the component after the handler prefix.
+func componentArg(i *discordgo.InteractionCreate) string {
+	_, arg, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	return arg
+}
+
+// respondEphemeral responds to the interaction with a message only the user can see.
+func (s *service) respondEphemeral(i *discordgo.InteractionCreate, msg string) {
+	err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: msg,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to interaction", slog.String(logging.KeyError, err.Error()))
+	}
+}
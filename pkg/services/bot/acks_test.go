@@ -0,0 +1,263 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+// discordRequest is a request the bot made to discord.
+type discordRequest struct {
+	method string
+	path   string
+	body   []byte
+}
+
+// fakeDiscord stands in for the discord API, recording the requests and answering each one with a message, or with
+// the reply set for its method and path.
+type fakeDiscord struct {
+	mut      sync.Mutex
+	requests []*discordRequest
+	replies  map[string]string
+}
+
+// newFakeDiscord returns a session that sends its requests to a fake discord.
+func newFakeDiscord(t *testing.T) (*discordgo.Session, *fakeDiscord) {
+	f := &fakeDiscord{replies: make(map[string]string)}
+	session, err := discordgo.New("Bot token")
+	require.NoError(t, err)
+	session.Client = &http.Client{Transport: f}
+	return session, f
+}
+
+func (f *fakeDiscord) RoundTrip(r *http.Request) (*http.Response, error) {
+	body := make([]byte, 0)
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion)
+
+	f.mut.Lock()
+	f.requests = append(f.requests, &discordRequest{
+		method: r.Method,
+		path:   path,
+		body:   body,
+	})
+	reply, ok := f.replies[r.Method+" "+path]
+	f.mut.Unlock()
+
+	if !ok {
+		reply = `{"id":"m1"}`
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(reply)),
+		Request:    r,
+	}, nil
+}
+
+// sent returns the requests made so far.
+func (f *fakeDiscord) sent() []*discordRequest {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.requests
+}
+
+// interactionResponse is the response the bot gave to an interaction. discordgo can't decode the components of a
+// response, so they are left raw.
+type interactionResponse struct {
+	Type discordgo.InteractionResponseType `json:"type"`
+	Data struct {
+		Content    string                    `json:"content"`
+		Flags      discordgo.MessageFlags    `json:"flags"`
+		Embeds     []*discordgo.MessageEmbed `json:"embeds"`
+		Components []json.RawMessage         `json:"components"`
+	} `json:"data"`
+}
+
+// response returns the response the bot gave to an interaction in the request.
+func (r *discordRequest) response(t *testing.T) *interactionResponse {
+	resp := new(interactionResponse)
+	require.NoError(t, json.Unmarshal(r.body, resp))
+	return resp
+}
+
+// memoryAcks is an AckStore kept in memory.
+type memoryAcks map[string]*alerts.Acknowledgement
+
+func (m memoryAcks) Open(_ context.Context, ack *alerts.Acknowledgement) error {
+	m[ack.Alert.ID] = ack
+	return nil
+}
+
+func (m memoryAcks) Get(_ context.Context, alertID string) (*alerts.Acknowledgement, error) {
+	ack, ok := m[alertID]
+	if !ok {
+		return nil, alerts.ErrAlertNotFound
+	}
+	return ack, nil
+}
+
+func (m memoryAcks) Acknowledge(ctx context.Context, alertID, user string, at time.Time) (*alerts.Acknowledgement, error) {
+	ack, err := m.Get(ctx, alertID)
+	if err != nil {
+		return nil, err
+	} else if ack.AckedBy == "" {
+		ack.AckedBy = user
+		ack.AckedAt = at
+	}
+	return ack, nil
+}
+
+func (m memoryAcks) Escalated(ctx context.Context, alertID string, at time.Time) error {
+	ack, err := m.Get(ctx, alertID)
+	if err != nil {
+		return err
+	}
+	ack.Escalations++
+	ack.LastNotifiedAt = at
+	return nil
+}
+
+func (m memoryAcks) Resolve(ctx context.Context, alertID string) (*alerts.Acknowledgement, error) {
+	ack, err := m.Get(ctx, alertID)
+	if err != nil {
+		return nil, err
+	}
+	delete(m, alertID)
+	return ack, nil
+}
+
+func (m memoryAcks) Unacknowledged(context.Context) ([]*alerts.Acknowledgement, error) {
+	acks := make([]*alerts.Acknowledgement, 0)
+	for _, ack := range m {
+		if ack.AckedBy == "" {
+			acks = append(acks, ack)
+		}
+	}
+	return acks, nil
+}
+
+// ackService returns a service posting alerts to a fake discord, with one open alert.
+func ackService(t *testing.T) (*service, *fakeDiscord, memoryAcks) {
+	session, discord := newFakeDiscord(t)
+	s := NewService("token", WithEscalation("oncall", 15*time.Minute)).(*service)
+	s.ctx = context.Background()
+	s.s = session
+
+	alert := alerts.NewAlert(alerts.SeverityCritical, "Server down", "")
+	alert.ID = "a1"
+
+	acks := memoryAcks{
+		"a1": {Alert: alert, ChannelID: "alerts", MessageID: "m1", PostedAt: time.Now(), LastNotifiedAt: time.Now()},
+	}
+	s.acks = acks
+	return s, discord, acks
+}
+
+// buttonInteraction returns an interaction of the user pressing the button.
+func buttonInteraction(user, customID string) *discordgo.InteractionCreate {
+	i := memberInteraction("g1", user)
+	i.ID = "i-" + user
+	i.Token = "token"
+	i.Type = discordgo.InteractionMessageComponent
+	i.Data = discordgo.MessageComponentInteractionData{CustomID: customID}
+	return i
+}
+
+func TestService_OnAlertAck(t *testing.T) {
+	s, discord, acks := ackService(t)
+
+	s.onAlertAck(nil, buttonInteraction("1", "alert-ack:a1"))
+	require.Equal(t, "user-1", acks["a1"].AckedBy)
+
+	sent := discord.sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, "/interactions/i-1/token/callback", sent[0].path)
+	resp := sent[0].response(t)
+	require.Equal(t, discordgo.InteractionResponseUpdateMessage, resp.Type)
+	fields := resp.Data.Embeds[0].Fields
+	require.Equal(t, "Acknowledged By", fields[len(fields)-1].Name)
+	require.Equal(t, "user-1", fields[len(fields)-1].Value)
+
+	// Only the first user takes the alert, the second is told who has it.
+	s.onAlertAck(nil, buttonInteraction("2", "alert-ack:a1"))
+	require.Equal(t, "user-1", acks["a1"].AckedBy)
+	resp = discord.sent()[1].response(t)
+	require.Equal(t, discordgo.InteractionResponseChannelMessageWithSource, resp.Type)
+	require.Equal(t, "user-1 is already on it", resp.Data.Content)
+	require.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
+}
+
+func TestService_OnAlertResolve(t *testing.T) {
+	s, discord, acks := ackService(t)
+
+	s.onAlertResolve(nil, buttonInteraction("1", "alert-resolve:a1"))
+	require.Empty(t, acks)
+
+	resp := discord.sent()[0].response(t)
+	require.Equal(t, discordgo.InteractionResponseUpdateMessage, resp.Type)
+	require.Empty(t, resp.Data.Components, "a resolved alert has no buttons")
+	fields := resp.Data.Embeds[0].Fields
+	require.Equal(t, "Resolved By", fields[len(fields)-1].Name)
+	require.Equal(t, alerts.SeverityOK.Colour(), resp.Data.Embeds[0].Color)
+
+	// The alert can't be acknowledged or resolved again.
+	s.onAlertAck(nil, buttonInteraction("2", "alert-ack:a1"))
+	s.onAlertResolve(nil, buttonInteraction("2", "alert-resolve:a1"))
+	for _, req := range discord.sent()[1:] {
+		require.Equal(t, "This alert has already been resolved", req.response(t).Data.Content)
+	}
+}
+
+func TestService_CheckEscalations(t *testing.T) {
+	s, discord, acks := ackService(t)
+
+	// The alert was posted within the timeout.
+	s.checkEscalations()
+	require.Empty(t, discord.sent())
+
+	acks["a1"].PostedAt = time.Now().Add(-20 * time.Minute)
+	acks["a1"].LastNotifiedAt = acks["a1"].PostedAt
+	s.checkEscalations()
+
+	sent := discord.sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, "/channels/alerts/messages", sent[0].path)
+	msg := new(discordgo.MessageSend)
+	require.NoError(t, json.Unmarshal(sent[0].body, msg))
+	require.Equal(t, "<@&oncall> **Server down** has not been acknowledged for 20m0s", msg.Content)
+	require.Equal(t, "m1", msg.Reference.MessageID)
+	require.Equal(t, 1, acks["a1"].Escalations)
+
+	// The escalation restarts the timeout.
+	s.checkEscalations()
+	require.Len(t, discord.sent(), 1)
+
+	// Nobody is mentioned again once the alert has been escalated enough times.
+	acks["a1"].Escalations = maxEscalations
+	acks["a1"].LastNotifiedAt = time.Now().Add(-time.Hour)
+	s.checkEscalations()
+	require.Len(t, discord.sent(), 1)
+
+	// Acknowledged alerts are not escalated.
+	acks["a1"].Escalations = 0
+	acks["a1"].AckedBy = "user-1"
+	s.checkEscalations()
+	require.Len(t, discord.sent(), 1)
+}
@@ -0,0 +1,224 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxMuteDuration is the longest an alert can be muted for.
+	maxMuteDuration = 7 * 24 * time.Hour
+
+	// defaultHistoryWindow is how far back the alert history is shown when the user doesn't say.
+	defaultHistoryWindow = 24 * time.Hour
+
+	// historyLimit is the number of alerts shown in the history, so the list fits in a message.
+	historyLimit = 15
+
+	// maxMessageLength is the longest message discord accepts.
+	maxMessageLength = 2000
+)
+
+func (s *service) muteAlerts(ctx context.Context, i *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	d, err := time.ParseDuration(options[durationOption])
+	if err != nil {
+		return "", fmt.Errorf("invalid duration %q, use a duration such as 30m or 2h", options[durationOption])
+	} else if d <= 0 || d > maxMuteDuration {
+		return "", fmt.Errorf("duration must be between 0s and %s", maxMuteDuration)
+	}
+
+	destination := options[destinationOption]
+	if destination != "" && !slices.Contains(s.alertDestinations, destination) {
+		return "", fmt.Errorf("unknown destination %q, use one of: %s", destination, strings.Join(s.alertDestinations, ", "))
+	}
+
+	mute := &alerts.Mute{
+		Category:    alerts.Category(options[categoryOption]),
+		Destination: destination,
+		Until:       time.Now().Add(d).UTC(),
+		CreatedBy:   interactionUser(i),
+	}
+
+	if err := s.mutes.Mute(ctx, mute); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Muted `%s` until <t:%d:f>", mute.Key(), mute.Until.Unix()), nil
+}
+
+func (s *service) unmuteAlerts(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	key := (&alerts.Mute{
+		Category:    alerts.Category(options[categoryOption]),
+		Destination: options[destinationOption],
+	}).Key()
+
+	removed, err := s.mutes.Unmute(ctx, key)
+	if err != nil {
+		return "", err
+	} else if !removed {
+		return fmt.Sprintf("No mute for `%s`", key), nil
+	}
+
+	return fmt.Sprintf("Unmuted `%s`", key), nil
+}
+
+func (s *service) listMutes(ctx context.Context, _ *discordgo.InteractionCreate, _ map[string]string) (string, error) {
+	mutes, err := s.mutes.Mutes(ctx)
+	if err != nil {
+		return "", err
+	} else if len(mutes) == 0 {
+		return "No alerts are muted", nil
+	}
+
+	sb := new(strings.Builder)
+	sb.WriteString("Muted alerts:")
+	for _, m := range mutes {
+		fmt.Fprintf(sb, "\n- `%s` until <t:%d:f>", m.Key(), m.Until.Unix())
+		if m.CreatedBy != "" {
+			fmt.Fprintf(sb, " (by %s)", m.CreatedBy)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func (s *service) alertHistory(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	since := defaultHistoryWindow
+	if got, ok := options[sinceOption]; ok {
+		d, err := time.ParseDuration(got)
+		if err != nil || d <= 0 {
+			return "", fmt.Errorf("invalid since %q, use a duration such as 2h or 48h", got)
+		}
+		since = d
+	}
+
+	var until time.Duration
+	if got, ok := options[untilOption]; ok {
+		d, err := time.ParseDuration(got)
+		if err != nil || d < 0 || d >= since {
+			return "", fmt.Errorf("invalid until %q, use a duration shorter than since", got)
+		}
+		until = d
+	}
+
+	now := time.Now()
+	records, err := s.history.Query(ctx, &alerts.HistoryFilter{
+		From:     now.Add(-since),
+		To:       now.Add(-until),
+		Category: alerts.Category(options[categoryOption]),
+		Limit:    historyLimit,
+	})
+	if err != nil {
+		return "", err
+	} else if len(records) == 0 {
+		return "No alerts were sent", nil
+	}
+
+	sb := new(strings.Builder)
+	sb.WriteString("Sent alerts, newest first:")
+	for _, r := range records {
+		line := fmt.Sprintf("\n- <t:%d:f> **%s** %s", r.Alert.Timestamp.Unix(), historyTitle(r.Alert), r.Alert.Severity)
+		if r.Alert.Category != "" {
+			line += fmt.Sprintf(" `%s`", r.Alert.Category)
+		}
+		if r.Alert.Source != "" {
+			line += " from " + r.Alert.Source
+		}
+		line += " " + deliverySummary(r.Deliveries)
+
+		if sb.Len()+len(line) > maxMessageLength {
+			break
+		}
+		sb.WriteString(line)
+	}
+
+	return sb.String(), nil
+}
+
+// configuredDestinations suggests the configured alert destinations, for muting them.
+func (s *service) configuredDestinations(_ context.Context, _ *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(s.alertDestinations))
+	for _, name := range s.alertDestinations {
+		if !strings.HasPrefix(name, value) {
+			continue
+		}
+
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: name,
+		})
+	}
+
+	return choices, nil
+}
+
+// mutedDestinations suggests the destinations that have a mute, for removing it.
+func (s *service) mutedDestinations(ctx context.Context, _ *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+	mutes, err := s.mutes.Mutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(mutes))
+	seen := make(map[string]bool, len(mutes))
+	for _, m := range mutes {
+		if m.Destination == "" || seen[m.Destination] || !strings.HasPrefix(m.Destination, value) {
+			continue
+		}
+
+		seen[m.Destination] = true
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  m.Destination,
+			Value: m.Destination,
+		})
+	}
+
+	return choices, nil
+}
+
+// historyTitle returns the title of the alert, or the name of its template when it was only rendered for each
+// destination.
+func historyTitle(alert *alerts.Alert) string {
+	if alert.Title != "" {
+		return alert.Title
+	}
+	return alert.Template
+}
+
+// deliverySummary lists the delivery status of each destination, sorted by destination.
+func deliverySummary(deliveries map[string]*alerts.DeliveryRecord) string {
+	if len(deliveries) == 0 {
+		return "(no destinations)"
+	}
+
+	names := make([]string, 0, len(deliveries))
+	for name := range deliveries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %s", name, deliveries[name].Status)
+	}
+
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// interactionUser returns the name of the user that created the interaction.
+func interactionUser(i *discordgo.InteractionCreate) string {
+	switch {
+	case i.Member != nil && i.Member.User != nil:
+		return i.Member.User.Username
+	case i.User != nil:
+		return i.User.Username
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryMutes is a MuteStore kept in memory.
+type memoryMutes map[string]*alerts.Mute
+
+func (m memoryMutes) Mute(_ context.Context, mute *alerts.Mute) error {
+	m[mute.Key()] = mute
+	return nil
+}
+
+func (m memoryMutes) Unmute(_ context.Context, key string) (bool, error) {
+	_, ok := m[key]
+	delete(m, key)
+	return ok, nil
+}
+
+func (m memoryMutes) Mutes(context.Context) ([]*alerts.Mute, error) {
+	mutes := make([]*alerts.Mute, 0, len(m))
+	for _, mute := range m {
+		mutes = append(mutes, mute)
+	}
+	return mutes, nil
+}
+
+func TestService_MuteAlerts(t *testing.T) {
+	mutes := make(memoryMutes)
+	s := NewService("token", WithAlertDestinations([]string{"discord", "email"})).(*service)
+	s.mutes = mutes
+
+	i := memberInteraction("g1", "1")
+
+	_, err := s.muteAlerts(context.Background(), i, map[string]string{durationOption: "1h", destinationOption: "emial"})
+	require.EqualError(t, err, `unknown destination "emial", use one of: discord, email`)
+	require.Empty(t, mutes, "a mute of an unknown destination is not stored")
+
+	_, err = s.muteAlerts(context.Background(), i, map[string]string{durationOption: "1h", destinationOption: "email"})
+	require.NoError(t, err)
+	require.Equal(t, "email", mutes[(&alerts.Mute{Destination: "email"}).Key()].Destination)
+
+	// Muting every destination doesn't name one.
+	_, err = s.muteAlerts(context.Background(), i, map[string]string{durationOption: "1h", categoryOption: "game"})
+	require.NoError(t, err)
+	require.Len(t, mutes, 2)
+}
+
+func TestService_ConfiguredDestinations(t *testing.T) {
+	s := NewService("token", WithAlertDestinations([]string{"discord", "email", "ops"})).(*service)
+
+	choices, err := s.configuredDestinations(context.Background(), nil, "e")
+	require.NoError(t, err)
+	require.Len(t, choices, 1)
+	require.Equal(t, "email", choices[0].Value)
+}
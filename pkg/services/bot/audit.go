@@ -0,0 +1,233 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/bwmarrin/discordgo"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// auditKey is the redis list of the audit entries, newest first.
+	auditKey = "bot:audit"
+
+	// maxAuditEntries is the number of audit entries that are kept.
+	maxAuditEntries = 10000
+
+	// defaultAuditLimit is the number of audit entries shown when the user doesn't say.
+	defaultAuditLimit = 20
+
+	// maxAuditLimit is the most audit entries shown, so the list fits in a message.
+	maxAuditLimit = 50
+
+	// auditPageSize is the number of audit entries read at a time when they are filtered, so a filter matching few
+	// entries doesn't take a read for every page of the limit.
+	auditPageSize = 200
+)
+
+// auditEntry records an invocation of a sensitive command.
+type auditEntry struct {
+	// Command is the command and subcommands that were invoked.
+	Command string `json:"command"`
+
+	// Options are the options given to the command, when the command audits them.
+	Options map[string]string `json:"options,omitempty"`
+
+	// UserID is the ID of the user that invoked the command.
+	UserID string `json:"user_id"`
+
+	// User is the name of the user that invoked the command.
+	User string `json:"user"`
+
+	// GuildID is the ID of the guild the command was invoked in. It is empty for direct messages.
+	GuildID string `json:"guild_id,omitempty"`
+
+	// ChannelID is the ID of the channel the command was invoked in.
+	ChannelID string `json:"channel_id,omitempty"`
+
+	// Allowed is false if an access policy stopped the user using the command.
+	Allowed bool `json:"allowed"`
+
+	// At is when the command was invoked.
+	At time.Time `json:"at"`
+}
+
+// auditFilter selects the audit entries returned.
+type auditFilter struct {
+	// Command limits the entries to the commands starting with it.
+	Command string
+
+	// UserID limits the entries to the user.
+	UserID string
+
+	// Limit is the maximum number of entries returned, newest first.
+	Limit int
+}
+
+// auditLog records who used the sensitive commands of the bot.
+type auditLog interface {
+	// Record stores the entry.
+	Record(ctx context.Context, entry *auditEntry) error
+
+	// Entries returns the entries matching the filter, newest first.
+	Entries(ctx context.Context, filter *auditFilter) ([]*auditEntry, error)
+}
+
+type redisAuditLog struct{}
+
+// newAuditLog creates a new auditLog backed by redis.
+func newAuditLog() auditLog {
+	return new(redisAuditLog)
+}
+
+func (a *redisAuditLog) Record(ctx context.Context, entry *auditEntry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal audit entry: %w", err)
+	}
+
+	conn := goredis.Conn()
+	if conn == nil {
+		return goredis.ErrRedisNotInitialised
+	}
+	defer func(conn redisgo.Conn) {
+		if err := conn.Close(); err != nil {
+			slog.Error("Error closing redis connection", slog.String(logging.KeyError, err.Error()))
+		}
+	}(conn)
+
+	// The entry is stored and the log trimmed together, so the log can't grow past its limit.
+	if err := conn.Send("MULTI"); err != nil {
+		return fmt.Errorf("start transaction: %w", err)
+	}
+	if err := conn.Send("LPUSH", auditKey, raw); err != nil {
+		return fmt.Errorf("queue audit entry: %w", err)
+	}
+	if err := conn.Send("LTRIM", auditKey, 0, maxAuditEntries-1); err != nil {
+		return fmt.Errorf("queue audit log trim: %w", err)
+	}
+	if _, err := redisgo.DoContext(conn, ctx, "EXEC"); err != nil {
+		return fmt.Errorf("store audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func (a *redisAuditLog) Entries(ctx context.Context, filter *auditFilter) ([]*auditEntry, error) {
+	// Without a filter the newest entries are the page, otherwise the log is read until the page is filled.
+	pageSize := filter.Limit
+	if filter.Command != "" || filter.UserID != "" {
+		pageSize = max(pageSize, auditPageSize)
+	}
+
+	entries := make([]*auditEntry, 0, filter.Limit)
+	for start := 0; len(entries) < filter.Limit && start < maxAuditEntries; start += pageSize {
+		raw, err := redisgo.ByteSlices(goredis.DoCtx(ctx, "LRANGE", auditKey, start, start+pageSize-1))
+		if err != nil {
+			return nil, fmt.Errorf("get audit log: %w", err)
+		}
+
+		for _, r := range raw {
+			if len(entries) >= filter.Limit {
+				break
+			}
+
+			e := new(auditEntry)
+			if err := json.Unmarshal(r, e); err != nil {
+				return nil, fmt.Errorf("unmarshal audit entry: %w", err)
+			}
+
+			if filter.Command != "" && !strings.HasPrefix(e.Command, filter.Command) {
+				continue
+			} else if filter.UserID != "" && e.UserID != filter.UserID {
+				continue
+			}
+
+			entries = append(entries, e)
+		}
+
+		if len(raw) < pageSize {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
+// newAuditEntry returns the audit entry of the interaction invoking the command.
+func newAuditEntry(i *discordgo.InteractionCreate, command string, allowed bool) *auditEntry {
+	return &auditEntry{
+		Command:   command,
+		UserID:    interactionUserID(i),
+		User:      interactionUser(i),
+		GuildID:   i.GuildID,
+		ChannelID: i.ChannelID,
+		Allowed:   allowed,
+		At:        time.Now().UTC(),
+	}
+}
+
+func (s *service) auditEntries(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	limit := defaultAuditLimit
+	if got, ok := options[limitOption]; ok {
+		n, err := strconv.Atoi(got)
+		if err != nil || n <= 0 || n > maxAuditLimit {
+			return "", fmt.Errorf("limit must be between 1 and %d", maxAuditLimit)
+		}
+		limit = n
+	}
+
+	entries, err := s.audit.Entries(ctx, &auditFilter{
+		Command: options[commandOption],
+		UserID:  options[userOption],
+		Limit:   limit,
+	})
+	if err != nil {
+		return "", err
+	} else if len(entries) == 0 {
+		return "No commands were audited", nil
+	}
+
+	sb := new(strings.Builder)
+	sb.WriteString("Audited commands, newest first:")
+	for _, e := range entries {
+		line := fmt.Sprintf("\n- <t:%d:f> <@%s> `/%s%s`", e.At.Unix(), e.UserID, e.Command, formatAuditOptions(e.Options))
+		if e.GuildID == "" {
+			line += " in a direct message"
+		}
+		if !e.Allowed {
+			line += " **denied**"
+		}
+
+		if sb.Len()+len(line) > maxMessageLength {
+			break
+		}
+		sb.WriteString(line)
+	}
+
+	return sb.String(), nil
+}
+
+// formatAuditOptions returns the options as they would be typed after the command, sorted by name.
+func formatAuditOptions(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	sb := new(strings.Builder)
+	for _, name := range names {
+		fmt.Fprintf(sb, " %s:%s", name, strings.ReplaceAll(options[name], "`", "'"))
+	}
+	return sb.String()
+}
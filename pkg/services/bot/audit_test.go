@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPool makes the mock the redis pool.
+func mockPool(t *testing.T) *goredis.MockPool {
+	pool := goredis.NewMockPool(t)
+	require.NoError(t, goredis.NewPool(
+		goredis.WithInitializedPool(pool),
+		goredis.WithAddress("localhost:6379"),
+		goredis.WithNetwork("tcp"),
+	))
+	return pool
+}
+
+// txConn is a redis connection recording the commands, with their key, of the transaction run on it.
+type txConn struct {
+	queued []string
+	exec   bool
+}
+
+func (c *txConn) Close() error { return nil }
+
+func (c *txConn) Err() error { return nil }
+
+func (c *txConn) Do(cmd string, _ ...any) (any, error) {
+	if cmd != "EXEC" {
+		return nil, fmt.Errorf("unexpected command %s", cmd)
+	}
+	c.exec = true
+	return []any{int64(1), "OK"}, nil
+}
+
+func (c *txConn) DoContext(_ context.Context, cmd string, args ...any) (any, error) {
+	return c.Do(cmd, args...)
+}
+
+func (c *txConn) Send(cmd string, args ...any) error {
+	if len(args) > 0 {
+		cmd += " " + fmt.Sprint(args[0])
+	}
+	c.queued = append(c.queued, cmd)
+	return nil
+}
+
+func (c *txConn) Flush() error { return nil }
+
+func (c *txConn) Receive() (any, error) { return nil, nil }
+
+func (c *txConn) ReceiveContext(context.Context) (any, error) { return c.Receive() }
+
+// rawAuditEntries returns the entries as they are stored in the audit log.
+func rawAuditEntries(t *testing.T, entries ...*auditEntry) []any {
+	raw := make([]any, len(entries))
+	for i, e := range entries {
+		b, err := json.Marshal(e)
+		require.NoError(t, err)
+		raw[i] = b
+	}
+	return raw
+}
+
+func TestRedisAuditLog_Record(t *testing.T) {
+	pool := mockPool(t)
+	conn := new(txConn)
+	pool.On("Conn").Return(conn)
+
+	require.NoError(t, newAuditLog().Record(context.Background(), &auditEntry{Command: "console", UserID: "1"}))
+
+	// The entry is stored and the log trimmed in one transaction.
+	require.Equal(t, []string{"MULTI", "LPUSH " + auditKey, "LTRIM " + auditKey}, conn.queued)
+	require.True(t, conn.exec)
+}
+
+func TestRedisAuditLog_Entries(t *testing.T) {
+	pool := mockPool(t)
+	pool.On("DoCtx", mock.Anything, "LRANGE", auditKey, 0, 1).
+		Return(rawAuditEntries(t, &auditEntry{Command: "console", UserID: "1"}, &auditEntry{Command: "server stop", UserID: "2"}), nil)
+
+	// Without a filter only the requested page is read.
+	entries, err := newAuditLog().Entries(context.Background(), &auditFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "console", entries[0].Command)
+}
+
+func TestRedisAuditLog_EntriesFiltered(t *testing.T) {
+	pool := mockPool(t)
+
+	page := make([]*auditEntry, auditPageSize)
+	for i := range page {
+		page[i] = &auditEntry{Command: "console", UserID: "1"}
+	}
+	page[auditPageSize-1] = &auditEntry{Command: "server stop", UserID: "2"}
+
+	pool.On("DoCtx", mock.Anything, "LRANGE", auditKey, 0, auditPageSize-1).Return(rawAuditEntries(t, page...), nil)
+	pool.On("DoCtx", mock.Anything, "LRANGE", auditKey, auditPageSize, 2*auditPageSize-1).
+		Return(rawAuditEntries(t, &auditEntry{Command: "server start", UserID: "2"}), nil)
+
+	// The log is read a page at a time until the limit is filled or the log ends.
+	entries, err := newAuditLog().Entries(context.Background(), &auditFilter{Command: "server", Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "server stop", entries[0].Command)
+	require.Equal(t, "server start", entries[1].Command)
+}
@@ -1,29 +1,502 @@
 package bot
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"context"
+	"fmt"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+)
 
 const (
 	serverInfoCmdID        = "server-info"
 	serverCredentialsCmdID = "server-credentials"
 	severDetailsCmdID      = "server-details"
+	alertsCmdID            = "alerts"
+	auditCmdID             = "audit"
+	serverCmdID            = "server"
+	consoleCmdID           = "console"
+	savesCmdID             = "saves"
+	playersCmdID           = "players"
+)
+
+const (
+	alertsMuteSubCmd    = "mute"
+	alertsUnmuteSubCmd  = "unmute"
+	alertsListSubCmd    = "list"
+	alertsHistorySubCmd = "history"
+)
+
+const (
+	serverCredentialsShowSubCmd    = "show"
+	serverCredentialsSetSubCmd     = "set"
+	serverCredentialsRotateSubCmd  = "rotate"
+	serverCredentialsHistorySubCmd = "history"
 )
 
-var (
-	commands = []*discordgo.ApplicationCommand{
+const (
+	serverStartSubCmd   = "start"
+	serverStopSubCmd    = "stop"
+	serverRestartSubCmd = "restart"
+	serverSaveSubCmd    = "save"
+	serverOptionsSubCmd = "options"
+	serverRenameSubCmd  = "rename"
+	serverAutoloadCmd   = "autoload"
+)
+
+const (
+	savesListSubCmd     = "list"
+	savesDownloadSubCmd = "download"
+	savesUploadSubCmd   = "upload"
+	savesLoadSubCmd     = "load"
+	savesDeleteSubCmd   = "delete"
+)
+
+const (
+	categoryOption    = "category"
+	destinationOption = "destination"
+	durationOption    = "duration"
+	sinceOption       = "since"
+	untilOption       = "until"
+	commandOption     = "command"
+	userOption        = "user"
+	limitOption       = "limit"
+	passwordOption    = "password"
+	nameOption        = "name"
+	sessionOption     = "session"
+	saveOption        = "save"
+	fileOption        = "file"
+	loadOption        = "load"
+	publicOption      = "public"
+
+	autosaveMinutesOption  = "autosave_minutes"
+	networkQualityOption   = "network_quality"
+	autoPauseOption        = "pause_when_empty"
+	saveOnLeaveOption      = "save_when_player_leaves"
+	sendGameplayDataOption = "send_gameplay_data"
+)
+
+// newCommandRegistry declares the commands and message components of the bot.
+func (s *service) newCommandRegistry() *registry {
+	r := newRegistry()
+	r.audit = s.audit
+	for name, policy := range s.policies {
+		r.policies[name] = policy
+	}
+
+	r.addCommand(&command{
+		name:        serverInfoCmdID,
+		description: "Server Info",
+		options:     []*option{publicCommandOption()},
+		handler:     s.onStatusCommand(serverInfoCmdID),
+	})
+
+	r.addCommand(&command{
+		name:        serverCredentialsCmdID,
+		description: "Server Credentials",
+		permissions: utils.Ptr(int64(discordgo.PermissionManageServer)),
+		sensitive:   true,
+		subcommands: []*command{
+			{
+				name:        serverCredentialsShowSubCmd,
+				description: "Show the address and password of the server",
+				// The credentials are never posted publicly.
+				handler: s.onStatusCommand(serverCredentialsCmdID),
+			},
+			{
+				name:        serverCredentialsSetSubCmd,
+				description: "Set the password players need to join the server",
+				permissions: utils.Ptr(int64(discordgo.PermissionAdministrator)),
+				options: []*option{
+					{
+						name:        passwordOption,
+						description: "The new password",
+						kind:        discordgo.ApplicationCommandOptionString,
+						required:    true,
+					},
+				},
+				handler: s.ephemeralHandler(serverCredentialsCmdID+" "+serverCredentialsSetSubCmd, s.setPassword),
+			},
+			{
+				name:        serverCredentialsRotateSubCmd,
+				description: "Replace the password players need to join the server with a random one",
+				permissions: utils.Ptr(int64(discordgo.PermissionAdministrator)),
+				handler:     s.ephemeralHandler(serverCredentialsCmdID+" "+serverCredentialsRotateSubCmd, s.rotatePassword),
+			},
+			{
+				name:        serverCredentialsHistorySubCmd,
+				description: "Show who changed the server password",
+				permissions: utils.Ptr(int64(discordgo.PermissionAdministrator)),
+				handler:     s.ephemeralHandler(serverCredentialsCmdID+" "+serverCredentialsHistorySubCmd, s.credentialHistory),
+			},
+		},
+	})
+
+	r.addCommand(&command{
+		name:        severDetailsCmdID,
+		description: "Server Details",
+		options:     []*option{publicCommandOption()},
+		handler:     s.onStatusCommand(severDetailsCmdID),
+	})
+
+	r.addCommand(&command{
+		name:        playersCmdID,
+		description: "Show who is online and who played recently",
+		options:     []*option{publicCommandOption()},
+		handler:     s.onStatusCommand(playersCmdID),
+	})
+
+	r.addCommand(&command{
+		name:        alertsCmdID,
+		description: "Manage alerts",
+		permissions: utils.Ptr(int64(discordgo.PermissionManageServer)),
+		sensitive:   true,
+		subcommands: []*command{
+			{
+				name:        alertsMuteSubCmd,
+				description: "Mute alerts for a while",
+				options: []*option{
+					{
+						name:        durationOption,
+						description: "How long to mute for, such as 30m or 2h",
+						kind:        discordgo.ApplicationCommandOptionString,
+						required:    true,
+					},
+					categoryCommandOption(),
+					destinationCommandOption(s.configuredDestinations),
+				},
+				handler: s.ephemeralHandler(alertsCmdID+" "+alertsMuteSubCmd, s.muteAlerts),
+			},
+			{
+				name:        alertsUnmuteSubCmd,
+				description: "Remove a mute",
+				options: []*option{
+					categoryCommandOption(),
+					destinationCommandOption(s.mutedDestinations),
+				},
+				handler: s.ephemeralHandler(alertsCmdID+" "+alertsUnmuteSubCmd, s.unmuteAlerts),
+			},
+			{
+				name:        alertsListSubCmd,
+				description: "List the active mutes",
+				handler:     s.ephemeralHandler(alertsCmdID+" "+alertsListSubCmd, s.listMutes),
+			},
+			{
+				name:        alertsHistorySubCmd,
+				description: "Show the alerts that were sent",
+				options: []*option{
+					{
+						name:        sinceOption,
+						description: "How far back to look, such as 2h or 48h, the last day when not set",
+						kind:        discordgo.ApplicationCommandOptionString,
+					},
+					{
+						name:        untilOption,
+						description: "How long ago to stop looking, such as 1h, now when not set",
+						kind:        discordgo.ApplicationCommandOptionString,
+					},
+					categoryCommandOption(),
+				},
+				handler: s.ephemeralHandler(alertsCmdID+" "+alertsHistorySubCmd, s.alertHistory),
+			},
+		},
+	})
+
+	r.addCommand(&command{
+		name:        auditCmdID,
+		description: "Show who used the sensitive commands",
+		permissions: utils.Ptr(int64(discordgo.PermissionAdministrator)),
+		options: []*option{
+			{
+				name:        commandOption,
+				description: "Only show the command, such as server-credentials",
+				kind:        discordgo.ApplicationCommandOptionString,
+				autocomplete: func(_ context.Context, _ *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+					return r.sensitiveCommandChoices(value), nil
+				},
+			},
+			{
+				name:        userOption,
+				description: "Only show the user",
+				kind:        discordgo.ApplicationCommandOptionUser,
+			},
+			{
+				name:        limitOption,
+				description: fmt.Sprintf("The number of entries to show, %d when not set", defaultAuditLimit),
+				kind:        discordgo.ApplicationCommandOptionInteger,
+			},
+		},
+		handler:   s.ephemeralHandler(auditCmdID, s.auditEntries),
+		sensitive: true,
+	})
+
+	// Anyone can see the server command, the control roles decide who can use it.
+	r.addCommand(&command{
+		name:        serverCmdID,
+		description: "Control the dedicated server",
+		sensitive:   true,
+		subcommands: []*command{
+			{
+				name:        serverStartSubCmd,
+				description: "Start the server",
+				handler:     s.onServerLifecycle(serverStartSubCmd),
+			},
+			{
+				name:        serverStopSubCmd,
+				description: "Save the game and stop the server",
+				handler:     s.onServerLifecycle(serverStopSubCmd),
+			},
+			{
+				name:        serverRestartSubCmd,
+				description: "Save the game and restart the server",
+				handler:     s.onServerLifecycle(serverRestartSubCmd),
+			},
+			{
+				name:        serverSaveSubCmd,
+				description: "Save the game",
+				options: []*option{
+					{
+						name:        nameOption,
+						description: "The name of the save",
+						kind:        discordgo.ApplicationCommandOptionString,
+						required:    true,
+					},
+				},
+				handler: s.slowEphemeralHandler(serverCmdID+" "+serverSaveSubCmd, serverAPITimeout, s.serverAdmin(s.saveGame)),
+			},
+			{
+				name:        serverOptionsSubCmd,
+				description: "Show the server options, or change the ones given",
+				options:     serverOptionCommandOptions(),
+				handler:     s.slowEphemeralHandler(serverCmdID+" "+serverOptionsSubCmd, serverAPITimeout, s.serverAdmin(s.serverOptions)),
+			},
+			{
+				name:        serverRenameSubCmd,
+				description: "Rename the server",
+				options: []*option{
+					{
+						name:        nameOption,
+						description: "The new name of the server",
+						kind:        discordgo.ApplicationCommandOptionString,
+						required:    true,
+					},
+				},
+				handler: s.slowEphemeralHandler(serverCmdID+" "+serverRenameSubCmd, serverAPITimeout, s.serverAdmin(s.renameServer)),
+			},
+			{
+				name:        serverAutoloadCmd,
+				description: "Set the session the server loads when it starts",
+				options: []*option{
+					{
+						name:         sessionOption,
+						description:  "The session to load",
+						kind:         discordgo.ApplicationCommandOptionString,
+						required:     true,
+						autocomplete: s.sessionChoices,
+					},
+				},
+				handler: s.slowEphemeralHandler(serverCmdID+" "+serverAutoloadCmd, serverAPITimeout, s.serverAdmin(s.setAutoload)),
+			},
+		},
+	})
+
+	r.addCommand(&command{
+		name:        consoleCmdID,
+		description: "Run a console command on the server",
+		permissions: utils.Ptr(int64(discordgo.PermissionAdministrator)),
+		options: []*option{
+			{
+				name:        commandOption,
+				description: "The console command, such as FG.AutosaveInterval",
+				kind:        discordgo.ApplicationCommandOptionString,
+				required:    true,
+			},
+		},
+		handler:      s.onConsole,
+		sensitive:    true,
+		auditOptions: true,
+	})
+
+	r.addCommand(&command{
+		name:        savesCmdID,
+		description: "Browse, download, upload, load and delete saves",
+		subcommands: []*command{
+			{
+				name:        savesListSubCmd,
+				description: "List the saves on the server",
+				options: []*option{
+					{
+						name:         sessionOption,
+						description:  "Only list the saves of this session",
+						kind:         discordgo.ApplicationCommandOptionString,
+						autocomplete: s.anySessionChoices,
+					},
+				},
+				handler: s.onSavesList,
+			},
+			{
+				name:        savesDownloadSubCmd,
+				description: "Get a save in a direct message, to play in single player",
+				options: []*option{
+					{
+						name:         sessionOption,
+						description:  "The session of the save",
+						kind:         discordgo.ApplicationCommandOptionString,
+						required:     true,
+						autocomplete: s.anySessionChoices,
+					},
+					{
+						name:         saveOption,
+						description:  "The save to download",
+						kind:         discordgo.ApplicationCommandOptionString,
+						required:     true,
+						autocomplete: s.saveChoices,
+					},
+				},
+				handler: s.onSaveDownload,
+			},
+			{
+				name:        savesUploadSubCmd,
+				description: "Upload a save to the server",
+				options: []*option{
+					{
+						name:        fileOption,
+						description: "The .sav file",
+						kind:        discordgo.ApplicationCommandOptionAttachment,
+						required:    true,
+					},
+					{
+						name:        nameOption,
+						description: "The name to save it as, the name of the file when not set",
+						kind:        discordgo.ApplicationCommandOptionString,
+					},
+					{
+						name:        loadOption,
+						description: "Load the save straight away",
+						kind:        discordgo.ApplicationCommandOptionBoolean,
+					},
+				},
+				handler: s.slowEphemeralHandler(savesCmdID+" "+savesUploadSubCmd, saveTransferTimeout, s.serverAdmin(s.uploadSave)),
+			},
+			{
+				name:        savesLoadSubCmd,
+				description: "Load a save, saving the game being played first",
+				options:     s.saveCommandOptions("The save to load"),
+				handler:     s.slowEphemeralHandler(savesCmdID+" "+savesLoadSubCmd, serverAPITimeout, s.serverAdmin(s.loadSave)),
+			},
+			{
+				name:        savesDeleteSubCmd,
+				description: "Delete a save from the server",
+				options:     s.saveCommandOptions("The save to delete"),
+				handler:     s.slowEphemeralHandler(savesCmdID+" "+savesDeleteSubCmd, serverAPITimeout, s.serverAdmin(s.deleteSave)),
+			},
+		},
+		sensitive:    true,
+		auditOptions: true,
+	})
+
+	r.addComponent(alertAckComponentID, s.onAlertAck)
+	r.addComponent(alertResolveComponentID, s.onAlertResolve)
+	r.addComponent(serverControlComponentID, s.onServerControl)
+	r.addComponent(consolePageComponentID, s.onConsolePage)
+	r.addComponent(savesPageComponentID, s.onSavesPage)
+	r.addComponent(statusRefreshComponentID, s.onStatusRefresh)
+
+	return r
+}
+
+// publicCommandOption returns the option showing the response of a status command to everyone in the channel.
+func publicCommandOption() *option {
+	return &option{
+		name:        publicOption,
+		description: "Show the response to everyone in the channel, only you see it when not set",
+		kind:        discordgo.ApplicationCommandOptionBoolean,
+	}
+}
+
+// saveCommandOptions returns the options choosing a save to change, suggesting them to members that can control the
+// server.
+func (s *service) saveCommandOptions(description string) []*option {
+	return []*option{
 		{
-			Name:        serverInfoCmdID,
-			Type:        discordgo.ChatApplicationCommand,
-			Description: "Server Info",
+			name:         sessionOption,
+			description:  "The session of the save",
+			kind:         discordgo.ApplicationCommandOptionString,
+			required:     true,
+			autocomplete: s.sessionChoices,
 		},
 		{
-			Name:        serverCredentialsCmdID,
-			Type:        discordgo.ChatApplicationCommand,
-			Description: "Server Credentials",
+			name:         saveOption,
+			description:  description,
+			kind:         discordgo.ApplicationCommandOptionString,
+			required:     true,
+			autocomplete: s.saveChoices,
 		},
+	}
+}
+
+func serverOptionCommandOptions() []*option {
+	qualities := make([]*discordgo.ApplicationCommandOptionChoice, len(networkQualities))
+	for i, q := range networkQualities {
+		qualities[i] = &discordgo.ApplicationCommandOptionChoice{
+			Name:  q,
+			Value: i,
+		}
+	}
+
+	return []*option{
 		{
-			Name:        severDetailsCmdID,
-			Type:        discordgo.ChatApplicationCommand,
-			Description: "Server Details",
+			name:        autosaveMinutesOption,
+			description: "Minutes between autosaves, 0 to turn autosave off",
+			kind:        discordgo.ApplicationCommandOptionInteger,
+		},
+		{
+			name:        networkQualityOption,
+			description: "The network quality of the server",
+			kind:        discordgo.ApplicationCommandOptionInteger,
+			choices:     qualities,
+		},
+		{
+			name:        autoPauseOption,
+			description: "Pause the game when nobody is playing",
+			kind:        discordgo.ApplicationCommandOptionBoolean,
+		},
+		{
+			name:        saveOnLeaveOption,
+			description: "Save the game when a player leaves",
+			kind:        discordgo.ApplicationCommandOptionBoolean,
+		},
+		{
+			name:        sendGameplayDataOption,
+			description: "Send gameplay data to the developers",
+			kind:        discordgo.ApplicationCommandOptionBoolean,
 		},
 	}
-)
+}
+
+func categoryCommandOption() *option {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(alerts.Categories))
+	for i, c := range alerts.Categories {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{
+			Name:  string(c),
+			Value: string(c),
+		}
+	}
+
+	return &option{
+		name:        categoryOption,
+		description: "The alert category, every category when not set",
+		kind:        discordgo.ApplicationCommandOptionString,
+		choices:     choices,
+	}
+}
+
+func destinationCommandOption(autocomplete autocompleteFunc) *option {
+	return &option{
+		name:         destinationOption,
+		description:  "The alert destination, every destination when not set",
+		kind:         discordgo.ApplicationCommandOptionString,
+		autocomplete: autocomplete,
+	}
+}
@@ -0,0 +1,258 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// consolePageComponentID prefixes the custom ID of the buttons paging through console output. The argument is the
+	// ID of the output and the page to show.
+	consolePageComponentID = "console-page"
+
+	// consoleOutputKey is the redis key the pages of the output of a console command are kept under, by the ID of the
+	// interaction that ran it.
+	consoleOutputKey = "bot:console:%s"
+
+	// consoleOutputTTL is how long the output of a console command can be paged through.
+	consoleOutputTTL = 15 * time.Minute
+
+	// consolePageSize is the most output shown on a page, leaving room in the message for the code block and footer.
+	consolePageSize = maxMessageLength - 100
+)
+
+// errConsoleNotAllowed is returned when no console commands have been allowed.
+var errConsoleNotAllowed = errors.New("no console commands are allowed, configure bot.console.allow to allow them")
+
+// ConsolePolicy limits the console commands that can be run from discord. Patterns are matched against the name of the
+// console command, ignoring case, and can use the wildcards of path.Match, such as "FG.*".
+type ConsolePolicy struct {
+	// Allow are the patterns of the commands that can be run. No command can be run when there are none.
+	Allow []string `mapstructure:"allow"`
+
+	// Deny are the patterns of the commands that can't be run, even when they are allowed.
+	Deny []string `mapstructure:"deny"`
+}
+
+// Check returns an error if the console command can't be run. Every command is refused when there is no policy.
+func (p *ConsolePolicy) Check(command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return fmt.Errorf("%s must not be empty", commandOption)
+	} else if p == nil || len(p.Allow) == 0 {
+		return errConsoleNotAllowed
+	}
+
+	name := strings.ToLower(fields[0])
+	if matchesAny(p.Deny, name) {
+		return fmt.Errorf("the console command %s is denied", fields[0])
+	} else if !matchesAny(p.Allow, name) {
+		return fmt.Errorf("the console command %s is not allowed", fields[0])
+	}
+
+	return nil
+}
+
+// matchesAny returns true if the name matches one of the patterns, ignoring case.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(strings.ToLower(pattern), name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) onConsole(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to console", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverAPITimeout)
+	defer cancel()
+
+	// The console is checked like the other server commands, as guilds can change who can run it.
+	var pages []string
+	_, err = s.serverAdmin(func(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+		var err error
+		pages, err = s.runConsoleCommand(ctx, options[commandOption])
+		return "", err
+	})(ctx, i, optionValues(i))
+
+	edit := new(discordgo.WebhookEdit)
+	if err != nil {
+		slog.Error("Error running console command", slog.String(logging.KeyError, err.Error()))
+		edit.Content = utils.Ptr("Error: " + err.Error())
+	} else {
+		content, components := consolePage(i.ID, pages, 0)
+		edit.Content = &content
+		edit.Components = &components
+	}
+
+	if len(pages) > 1 {
+		if err := storeConsoleOutput(ctx, i.ID, pages); err != nil {
+			slog.Error("Error storing console output", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+
+	if _, err := s.s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		slog.Error("Error editing console response", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// runConsoleCommand runs the console command if the policy allows it, returning the pages of its output.
+func (s *service) runConsoleCommand(ctx context.Context, command string) ([]string, error) {
+	if s.serverAPI == nil {
+		return nil, errServerAPINotConfigured
+	}
+
+	command = strings.TrimSpace(command)
+	if err := s.consolePolicy.Check(command); err != nil {
+		slog.Warn("Console command refused", slog.String("command", command), slog.String(logging.KeyError, err.Error()))
+		return nil, err
+	}
+
+	output, err := s.serverAPI.RunCommand(ctx, command)
+	if err != nil {
+		return nil, fmt.Errorf("run console command: %w", err)
+	}
+
+	return paginate(output, consolePageSize), nil
+}
+
+func (s *service) onConsolePage(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	id, pageArg, _ := strings.Cut(componentArg(i), ":")
+	page, err := strconv.Atoi(pageArg)
+	if err != nil {
+		slog.Error("Error parsing console page", slog.String("page", pageArg), slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data := &discordgo.InteractionResponseData{
+		Components: []discordgo.MessageComponent{},
+	}
+	pages, err := consoleOutput(ctx, id)
+	if err != nil {
+		slog.Error("Error getting console output", slog.String(logging.KeyError, err.Error()))
+		data.Content = "This output has expired, run the command again"
+	} else {
+		data.Content, data.Components = consolePage(id, pages, page)
+	}
+
+	err = s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	})
+	if err != nil {
+		slog.Error("Error responding to console page", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// storeConsoleOutput keeps the pages of the output of a console command so they can be paged through.
+func storeConsoleOutput(ctx context.Context, id string, pages []string) error {
+	raw, err := json.Marshal(pages)
+	if err != nil {
+		return fmt.Errorf("marshal console output: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "SET", fmt.Sprintf(consoleOutputKey, id), raw, "PX", consoleOutputTTL.Milliseconds()); err != nil {
+		return fmt.Errorf("store console output: %w", err)
+	}
+
+	return nil
+}
+
+// consoleOutput returns the pages of the output of a console command.
+func consoleOutput(ctx context.Context, id string) ([]string, error) {
+	raw, err := redisgo.Bytes(goredis.DoCtx(ctx, "GET", fmt.Sprintf(consoleOutputKey, id)))
+	if err != nil {
+		return nil, fmt.Errorf("get console output: %w", err)
+	}
+
+	pages := make([]string, 0)
+	if err := json.Unmarshal(raw, &pages); err != nil {
+		return nil, fmt.Errorf("unmarshal console output: %w", err)
+	}
+
+	return pages, nil
+}
+
+// consolePage returns the message showing the page of the output, with buttons to move between the pages when there
+// is more than one.
+func consolePage(id string, pages []string, page int) (string, []discordgo.MessageComponent) {
+	if len(pages) == 0 || (len(pages) == 1 && strings.TrimSpace(pages[0]) == "") {
+		return "The command returned no output", []discordgo.MessageComponent{}
+	}
+	page = max(0, min(page, len(pages)-1))
+
+	// Output containing a code fence would end the code block early.
+	content := "```\n" + strings.ReplaceAll(pages[page], "```", "`\u200b``") + "\n```"
+	if len(pages) == 1 {
+		return content, []discordgo.MessageComponent{}
+	}
+
+	content += fmt.Sprintf("\nPage %d of %d", page+1, len(pages))
+	return content, pageButtons(func(page int) string {
+		return fmt.Sprintf("%s:%s:%d", consolePageComponentID, id, page)
+	}, page, len(pages))
+}
+
+// paginate splits the output into pages of at most size bytes, breaking between lines where it can.
+func paginate(output string, size int) []string {
+	output = strings.TrimRight(output, "\n")
+
+	pages := make([]string, 0)
+	current := new(strings.Builder)
+	for _, line := range strings.Split(output, "\n") {
+		// A line longer than a page is split wherever it has to be.
+		for len(line) > size {
+			if current.Len() > 0 {
+				pages = append(pages, current.String())
+				current.Reset()
+			}
+
+			// Don't split a character in two.
+			cut := size
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			pages = append(pages, line[:cut])
+			line = line[cut:]
+		}
+
+		if current.Len() > 0 && current.Len()+1+len(line) > size {
+			pages = append(pages, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteByte('\n')
+		}
+		current.WriteString(line)
+	}
+
+	return append(pages, current.String())
+}
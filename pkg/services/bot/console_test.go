@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolePolicy_Check(t *testing.T) {
+	policy := &ConsolePolicy{
+		Allow: []string{"FG.*", "Stat"},
+		Deny:  []string{"fg.ServerRestartTimeSlot"},
+	}
+
+	tests := []struct {
+		name    string
+		policy  *ConsolePolicy
+		command string
+		wantErr bool
+	}{
+		{name: "allowed", policy: policy, command: "FG.AutosaveInterval 300"},
+		{name: "allowed ignoring case", policy: policy, command: "stat fps"},
+		{name: "not allowed", policy: policy, command: "Quit", wantErr: true},
+		{name: "denied", policy: policy, command: "FG.ServerRestartTimeSlot 4", wantErr: true},
+		{name: "empty", policy: policy, command: "  ", wantErr: true},
+		{name: "no policy", policy: nil, command: "Quit", wantErr: true},
+		{name: "deny only", policy: &ConsolePolicy{Deny: []string{"quit", "exit"}}, command: "FG.NetworkQuality", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.command)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	require.Equal(t, []string{"one\ntwo"}, paginate("one\ntwo\n", 20))
+	require.Equal(t, []string{"one\ntwo", "three"}, paginate("one\ntwo\nthree", 8))
+	require.Equal(t, []string{"ab", "cdefgh", "ijkl"}, paginate("ab\ncdefghijkl", 6), "a line longer than a page is split")
+	require.Equal(t, []string{""}, paginate("", 10))
+
+	// Characters are not split in two.
+	for _, page := range paginate(strings.Repeat("é", 10), 5) {
+		require.True(t, strings.Trim(page, "é") == "", "page %q splits a character", page)
+	}
+}
+
+func TestConsolePage(t *testing.T) {
+	content, components := consolePage("1", []string{"FG.AutosaveInterval = \"300\""}, 0)
+	require.Equal(t, "```\nFG.AutosaveInterval = \"300\"\n```", content)
+	require.Empty(t, components)
+
+	content, components = consolePage("1", []string{"a", "b", "c"}, 2)
+	require.Equal(t, "```\nc\n```\nPage 3 of 3", content)
+	buttons := components[0].(discordgo.ActionsRow).Components
+	require.Equal(t, "console-page:1:1", buttons[0].(discordgo.Button).CustomID)
+	require.False(t, buttons[0].(discordgo.Button).Disabled)
+	require.True(t, buttons[1].(discordgo.Button).Disabled)
+
+	content, _ = consolePage("1", []string{""}, 0)
+	require.Equal(t, "The command returned no output", content)
+}
+
+func TestService_RunConsoleCommand(t *testing.T) {
+	s, api := testService(WithConsolePolicy(&ConsolePolicy{Allow: []string{"*"}, Deny: []string{"quit"}}))
+	api.output = strings.Repeat("line\n", 1000)
+
+	pages, err := s.runConsoleCommand(context.Background(), " FG.AutosaveInterval ")
+	require.NoError(t, err)
+	require.Greater(t, len(pages), 1)
+	for _, page := range pages {
+		require.LessOrEqual(t, len(page), consolePageSize)
+	}
+
+	_, err = s.runConsoleCommand(context.Background(), "Quit")
+	require.Error(t, err)
+	require.Equal(t, []string{"FG.AutosaveInterval"}, api.commands, "denied commands must not reach the server")
+}
+
+func TestRegistry_AuditOptions(t *testing.T) {
+	var called string
+	r := testRegistry(&called)
+	r.byName["ping"].sensitive = true
+	r.byName["ping"].auditOptions = true
+
+	audit := new(memoryAuditLog)
+	r.audit = audit
+
+	r.handle(nil, commandInteraction("ping", &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  "command",
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: "FG.AutosaveInterval 300",
+	}))
+
+	require.Len(t, audit.entries, 1)
+	require.Equal(t, map[string]string{"command": "FG.AutosaveInterval 300"}, audit.entries[0].Options)
+	require.Equal(t, " command:FG.AutosaveInterval 300", formatAuditOptions(audit.entries[0].Options))
+}
@@ -0,0 +1,247 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/secrets"
+	"github.com/bwmarrin/discordgo"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// credentialsKey is the redis hash of the address players connect to, and the password before it was managed by
+	// the bot.
+	credentialsKey = "server_credentials"
+
+	// credentialHistoryKey is the redis list of the changes to the server password, newest first.
+	credentialHistoryKey = "server_credentials:history"
+
+	// maxCredentialChanges is the number of changes to the server password that are kept.
+	maxCredentialChanges = 100
+
+	// credentialHistoryLimit is the number of changes shown by the history command.
+	credentialHistoryLimit = 15
+
+	// passwordSecretKey is the key of the password in the credentials secret.
+	passwordSecretKey = "password"
+
+	// generatedPasswordLength is the length of the passwords generated by the rotate command.
+	generatedPasswordLength = 16
+
+	// maxPasswordLength is the longest password that can be set.
+	maxPasswordLength = 64
+
+	// passwordAlphabet are the characters of generated passwords. Characters that look alike are left out so the
+	// password is easy to type into the game.
+	passwordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	// errCredentialsNotConfigured is returned when the bot can't change the server password.
+	errCredentialsNotConfigured = errors.New("changing the server password is not configured")
+
+	// errPasswordNotStored is returned when the server accepted a new password that could not be stored.
+	errPasswordNotStored = errors.New("the server accepted the password but it could not be stored")
+)
+
+// credentialChange records a change to the server password. The password itself is never recorded.
+type credentialChange struct {
+	// Action is the subcommand that changed the password.
+	Action string `json:"action"`
+
+	// UserID is the ID of the user that changed the password.
+	UserID string `json:"user_id"`
+
+	// User is the name of the user that changed the password.
+	User string `json:"user"`
+
+	// At is when the password was changed.
+	At time.Time `json:"at"`
+}
+
+// credentialLog records who changed the server password.
+type credentialLog interface {
+	// Record stores the change.
+	Record(ctx context.Context, change *credentialChange) error
+
+	// Changes returns the most recent changes, newest first.
+	Changes(ctx context.Context, limit int) ([]*credentialChange, error)
+}
+
+type redisCredentialLog struct{}
+
+// newCredentialLog creates a new credentialLog backed by redis.
+func newCredentialLog() credentialLog {
+	return new(redisCredentialLog)
+}
+
+func (c *redisCredentialLog) Record(ctx context.Context, change *credentialChange) error {
+	raw, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("marshal credential change: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "LPUSH", credentialHistoryKey, raw); err != nil {
+		return fmt.Errorf("store credential change: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "LTRIM", credentialHistoryKey, 0, maxCredentialChanges-1); err != nil {
+		return fmt.Errorf("trim credential history: %w", err)
+	}
+
+	return nil
+}
+
+func (c *redisCredentialLog) Changes(ctx context.Context, limit int) ([]*credentialChange, error) {
+	raw, err := redisgo.ByteSlices(goredis.DoCtx(ctx, "LRANGE", credentialHistoryKey, 0, limit-1))
+	if err != nil {
+		return nil, fmt.Errorf("get credential history: %w", err)
+	}
+
+	changes := make([]*credentialChange, len(raw))
+	for i, r := range raw {
+		changes[i] = new(credentialChange)
+		if err := json.Unmarshal(r, changes[i]); err != nil {
+			return nil, fmt.Errorf("unmarshal credential change: %w", err)
+		}
+	}
+
+	return changes, nil
+}
+
+func (s *service) credentialsView(ctx context.Context) (*discordgo.MessageEmbed, error) {
+	serverCredentials, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", credentialsKey))
+	if err != nil {
+		return nil, fmt.Errorf("get server credentials: %w", err)
+	}
+
+	password := serverCredentials["password"]
+	if s.credentials != nil {
+		secret, err := s.credentials.Get(ctx, s.credentialsPath)
+		switch {
+		case errors.Is(err, secrets.ErrSecretNotFound):
+			// The password has not been set by the bot yet.
+		case err != nil:
+			return nil, fmt.Errorf("get server password: %w", err)
+		default:
+			password = secret[passwordSecretKey]
+		}
+	}
+
+	return credentialsEmbed(serverCredentials["ip"], serverCredentials["port"], password), nil
+}
+
+// credentialsEmbed returns the embed showing how to join the server.
+func credentialsEmbed(ip, port, password string) *discordgo.MessageEmbed {
+	if password != "" {
+		password = "`" + password + "`"
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: "Server credentials",
+		Color: colourUnknown,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "IP", Value: valueOrDash(ip), Inline: true},
+			{Name: "Port", Value: valueOrDash(port), Inline: true},
+			{Name: "Password", Value: valueOrDash(password), Inline: true},
+		},
+	}
+}
+
+func (s *service) setPassword(ctx context.Context, i *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	password := options[passwordOption]
+	if password == "" || len(password) > maxPasswordLength {
+		return "", fmt.Errorf("password must be between 1 and %d characters", maxPasswordLength)
+	}
+
+	if err := s.changePassword(ctx, i, serverCredentialsSetSubCmd, password); err != nil {
+		return "", err
+	}
+
+	return "Server password set", nil
+}
+
+func (s *service) rotatePassword(ctx context.Context, i *discordgo.InteractionCreate, _ map[string]string) (string, error) {
+	password, err := generatePassword(generatedPasswordLength)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.changePassword(ctx, i, serverCredentialsRotateSubCmd, password)
+	if errors.Is(err, errPasswordNotStored) {
+		// Nobody would know the password of the server otherwise.
+		return "", fmt.Errorf("%w, the new password is: %s", err, password)
+	} else if err != nil {
+		return "", err
+	}
+
+	return "Server password rotated, the new password is: " + password, nil
+}
+
+// changePassword applies the password to the server, then stores it and records who changed it. The server is
+// changed first so the stored password is never one the server doesn't accept.
+func (s *service) changePassword(ctx context.Context, i *discordgo.InteractionCreate, action, password string) error {
+	if s.serverAPI == nil || s.credentials == nil {
+		return errCredentialsNotConfigured
+	}
+
+	if err := s.serverAPI.SetClientPassword(ctx, password); err != nil {
+		return fmt.Errorf("set server password: %w", err)
+	}
+
+	if err := s.credentials.Put(ctx, s.credentialsPath, map[string]string{passwordSecretKey: password}); err != nil {
+		return fmt.Errorf("%w: %w", errPasswordNotStored, err)
+	}
+
+	if err := s.credentialLog.Record(ctx, &credentialChange{
+		Action: action,
+		UserID: interactionUserID(i),
+		User:   interactionUser(i),
+		At:     time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("the password was changed but the change could not be recorded: %w", err)
+	}
+
+	return nil
+}
+
+func (s *service) credentialHistory(ctx context.Context, _ *discordgo.InteractionCreate, _ map[string]string) (string, error) {
+	changes, err := s.credentialLog.Changes(ctx, credentialHistoryLimit)
+	if err != nil {
+		return "", err
+	} else if len(changes) == 0 {
+		return "The server password has not been changed by the bot", nil
+	}
+
+	sb := new(strings.Builder)
+	sb.WriteString("Server password changes, newest first:")
+	for _, c := range changes {
+		fmt.Fprintf(sb, "\n- <t:%d:f> <@%s> used `%s`", c.At.Unix(), c.UserID, c.Action)
+	}
+
+	return sb.String(), nil
+}
+
+// generatePassword returns a random password of the length.
+func generatePassword(length int) (string, error) {
+	size := big.NewInt(int64(len(passwordAlphabet)))
+
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("generate password: %w", err)
+		}
+		b[i] = passwordAlphabet[n.Int64()]
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/secrets"
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySecrets is a secrets.Store kept in memory.
+type memorySecrets struct {
+	values map[string]map[string]string
+	err    error
+}
+
+func (m *memorySecrets) Get(_ context.Context, path string) (map[string]string, error) {
+	values, ok := m.values[path]
+	if !ok {
+		return nil, secrets.ErrSecretNotFound
+	}
+	return values, nil
+}
+
+func (m *memorySecrets) Put(_ context.Context, path string, values map[string]string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.values[path] = values
+	return nil
+}
+
+// memoryCredentialLog is a credentialLog kept in memory.
+type memoryCredentialLog struct {
+	changes []*credentialChange
+}
+
+func (m *memoryCredentialLog) Record(_ context.Context, change *credentialChange) error {
+	m.changes = append([]*credentialChange{change}, m.changes...)
+	return nil
+}
+
+func (m *memoryCredentialLog) Changes(_ context.Context, limit int) ([]*credentialChange, error) {
+	return m.changes[:min(limit, len(m.changes))], nil
+}
+
+func credentialsService() (*service, *fakeServerAPI, *memorySecrets, *memoryCredentialLog) {
+	api := new(fakeServerAPI)
+	store := &memorySecrets{values: make(map[string]map[string]string)}
+	log := new(memoryCredentialLog)
+
+	s := NewService("token", WithServerAPI(api), WithCredentials(store, "satisfactory/server")).(*service)
+	s.credentialLog = log
+
+	return s, api, store, log
+}
+
+func TestService_SetPassword(t *testing.T) {
+	s, api, store, log := credentialsService()
+
+	msg, err := s.setPassword(context.Background(), memberInteraction("g1", "1"), map[string]string{passwordOption: "hunter2"})
+	require.NoError(t, err)
+	require.Equal(t, "Server password set", msg)
+
+	require.Equal(t, "hunter2", api.password)
+	require.Equal(t, map[string]string{passwordSecretKey: "hunter2"}, store.values["satisfactory/server"])
+	require.Len(t, log.changes, 1)
+	require.Equal(t, serverCredentialsSetSubCmd, log.changes[0].Action)
+	require.Equal(t, "1", log.changes[0].UserID)
+
+	_, err = s.setPassword(context.Background(), memberInteraction("g1", "1"), map[string]string{})
+	require.Error(t, err)
+}
+
+func TestService_SetPassword_ServerRejects(t *testing.T) {
+	s, api, store, log := credentialsService()
+	api.err = errors.New("insufficient scope")
+
+	_, err := s.setPassword(context.Background(), memberInteraction("g1", "1"), map[string]string{passwordOption: "hunter2"})
+	require.Error(t, err)
+
+	// Nothing is stored when the server didn't take the password.
+	require.Empty(t, store.values)
+	require.Empty(t, log.changes)
+}
+
+func TestService_RotatePassword(t *testing.T) {
+	s, api, store, log := credentialsService()
+
+	msg, err := s.rotatePassword(context.Background(), memberInteraction("g1", "1"), nil)
+	require.NoError(t, err)
+
+	require.Len(t, api.password, generatedPasswordLength)
+	require.Contains(t, msg, api.password)
+	require.Equal(t, api.password, store.values["satisfactory/server"][passwordSecretKey])
+	require.Equal(t, serverCredentialsRotateSubCmd, log.changes[0].Action)
+}
+
+func TestService_RotatePassword_NotStored(t *testing.T) {
+	s, api, store, _ := credentialsService()
+	store.err = errors.New("vault sealed")
+
+	_, err := s.rotatePassword(context.Background(), memberInteraction("g1", "1"), nil)
+	require.ErrorIs(t, err, errPasswordNotStored)
+
+	// The server has the new password, so the user must be told it.
+	require.Contains(t, err.Error(), api.password)
+}
+
+func TestService_ChangePassword_NotConfigured(t *testing.T) {
+	s := NewService("token").(*service)
+
+	_, err := s.rotatePassword(context.Background(), memberInteraction("g1", "1"), nil)
+	require.ErrorIs(t, err, errCredentialsNotConfigured)
+}
+
+func TestRegistry_HandleSubcommandPermissions(t *testing.T) {
+	var called string
+	r := testRegistry(&called)
+	r.byName["alerts"].subcommands[0].permissions = utils.Ptr(int64(discordgo.PermissionManageServer))
+
+	var responded string
+	r.respondError = func(_ *discordgo.Session, _ *discordgo.InteractionCreate, msg string) {
+		responded = msg
+	}
+
+	data := discordgo.ApplicationCommandInteractionData{
+		Name: "alerts",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "mute", Type: discordgo.ApplicationCommandOptionSubCommand},
+		},
+	}
+
+	member := memberInteraction("g1", "1")
+	member.Type = discordgo.InteractionApplicationCommand
+	member.Data = data
+
+	r.handle(nil, member)
+	require.Empty(t, called)
+	require.Equal(t, accessDeniedResponse, responded)
+
+	member.Member.Permissions = discordgo.PermissionManageServer
+	r.handle(nil, member)
+	require.Equal(t, "alerts mute", called)
+
+	called = ""
+	admin := memberInteraction("g1", "2")
+	admin.Type = discordgo.InteractionApplicationCommand
+	admin.Data = data
+	admin.Member.Permissions = discordgo.PermissionAdministrator
+
+	r.handle(nil, admin)
+	require.Equal(t, "alerts mute", called)
+
+	// A policy on the subcommand applies on top of the permissions.
+	called = ""
+	r.policies["alerts mute"] = &AccessPolicy{Users: []string{"1"}}
+	r.handle(nil, admin)
+	require.Empty(t, called)
+}
@@ -0,0 +1,22 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+)
+
+// lastUpdated describes when data was last received from the source, marking it when it is stale. Discord shows the
+// time relative to now and keeps it current.
+func lastUpdated(f *state.Freshness) string {
+	if f == nil || f.LastSeen.IsZero() {
+		return "never"
+	}
+
+	updated := fmt.Sprintf("<t:%d:R>", f.LastSeen.Unix())
+	if f.Stale {
+		return "⚠️ " + updated + ", the data is stale"
+	}
+
+	return updated
+}
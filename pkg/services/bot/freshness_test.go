@@ -0,0 +1,18 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastUpdated(t *testing.T) {
+	seen := time.Unix(1725976344, 0)
+
+	require.Equal(t, "never", lastUpdated(nil))
+	require.Equal(t, "never", lastUpdated(&state.Freshness{Source: state.SourceDockerInfo}))
+	require.Equal(t, "<t:1725976344:R>", lastUpdated(&state.Freshness{LastSeen: seen}))
+	require.Equal(t, "⚠️ <t:1725976344:R>, the data is stale", lastUpdated(&state.Freshness{LastSeen: seen, Stale: true}))
+}
@@ -0,0 +1,235 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// serverControlComponentID prefixes the custom ID of the buttons confirming a server lifecycle command. The
+	// argument is the action and when it was asked for, or serverControlCancel.
+	serverControlComponentID = "server-control"
+
+	// serverControlCancel is the argument of the button cancelling a server lifecycle command.
+	serverControlCancel = "cancel"
+
+	// confirmationTimeout is how long a server lifecycle command can be confirmed for.
+	confirmationTimeout = time.Minute
+
+	// lifecycleTimeout is how long saving the game and starting or stopping the server can take.
+	lifecycleTimeout = 5 * time.Minute
+)
+
+var (
+	// errControllerNotConfigured is returned when the bot can't start or stop the server.
+	errControllerNotConfigured = errors.New("controlling the server is not configured")
+
+	// errConfirmationExpired is returned when a server lifecycle command is confirmed too late.
+	errConfirmationExpired = errors.New("the confirmation expired, run the command again")
+)
+
+// lifecycleVerbs are how the actions are described while they happen, and once they are done.
+var lifecycleVerbs = map[string][2]string{
+	serverStartSubCmd:   {"Starting", "started"},
+	serverStopSubCmd:    {"Stopping", "stopped"},
+	serverRestartSubCmd: {"Restarting", "restarted"},
+}
+
+// onServerLifecycle returns the handler of a server lifecycle command, which asks the user to confirm the action.
+func (s *service) onServerLifecycle(action string) handlerFunc {
+	return func(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+		if s.controller == nil {
+			s.respondEphemeral(i, "Error: "+errControllerNotConfigured.Error())
+			return
+		} else if !s.canControlServer(i) {
+			s.respondEphemeral(i, accessDeniedResponse)
+			return
+		}
+
+		style := discordgo.DangerButton
+		warning := " Everyone playing will be disconnected."
+		if action == serverStartSubCmd {
+			style = discordgo.SuccessButton
+			warning = ""
+		}
+
+		err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("Are you sure you want to %s the server?%s", action, warning),
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{
+						Components: []discordgo.MessageComponent{
+							discordgo.Button{
+								Label:    strings.ToUpper(action[:1]) + action[1:],
+								Style:    style,
+								CustomID: fmt.Sprintf("%s:%s:%d", serverControlComponentID, action, time.Now().Unix()),
+							},
+							discordgo.Button{
+								Label:    "Cancel",
+								Style:    discordgo.SecondaryButton,
+								CustomID: serverControlComponentID + ":" + serverControlCancel,
+							},
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			slog.Error("Error asking to confirm server lifecycle", slog.String("action", action), slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// onServerControl carries out a confirmed server lifecycle command, reporting progress by editing the confirmation.
+func (s *service) onServerControl(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	arg := componentArg(i)
+	if arg == serverControlCancel {
+		s.updateControlMessage(i, "Cancelled")
+		return
+	}
+
+	if !s.canControlServer(i) {
+		s.respondEphemeral(i, accessDeniedResponse)
+		return
+	}
+
+	action, err := parseControlArg(arg, time.Now())
+	if err != nil {
+		s.updateControlMessage(i, "Error: "+err.Error())
+		return
+	}
+
+	s.commands.record(newAuditEntry(i, serverCmdID+" "+action+" confirm", true))
+
+	err = s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		slog.Error("Error responding to server control", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	progress := func(msg string) {
+		_, err := s.s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content:    &msg,
+			Components: &[]discordgo.MessageComponent{},
+		})
+		if err != nil {
+			slog.Error("Error reporting server control progress", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lifecycleTimeout)
+	defer cancel()
+
+	if err := s.controlServer(ctx, action, progress); err != nil {
+		slog.Error("Error controlling server", slog.String("action", action), slog.String(logging.KeyError, err.Error()))
+		progress("Error: " + err.Error())
+		return
+	}
+
+	slog.Info("Server lifecycle command carried out", slog.String("action", action), slog.String("user", interactionUser(i)))
+	progress("Server " + lifecycleVerbs[action][1])
+}
+
+// controlServer carries out the action. The game is saved before the server is stopped or restarted, and the action
+// is abandoned if it can't be.
+func (s *service) controlServer(ctx context.Context, action string, progress func(msg string)) error {
+	if s.controller == nil {
+		return errControllerNotConfigured
+	}
+
+	verbs, ok := lifecycleVerbs[action]
+	if !ok {
+		return fmt.Errorf("unknown server action: %s", action)
+	}
+
+	if action != serverStartSubCmd {
+		if s.serverAPI == nil {
+			return errors.New("the game can't be saved before the server is stopped, the server API is not configured")
+		}
+
+		name := fmt.Sprintf("bot_%s_%s", action, time.Now().UTC().Format("20060102_150405"))
+		progress(fmt.Sprintf("Saving the game as `%s`...", name))
+		if err := s.serverAPI.SaveGame(ctx, name); err != nil {
+			return fmt.Errorf("save game: %w", err)
+		}
+	}
+
+	progress(verbs[0] + " the server...")
+
+	var err error
+	switch action {
+	case serverStartSubCmd:
+		err = s.controller.Start(ctx)
+	case serverStopSubCmd:
+		err = s.controller.Stop(ctx)
+	case serverRestartSubCmd:
+		err = s.controller.Restart(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("%s server: %w", action, err)
+	}
+
+	return nil
+}
+
+// canControlServer returns true if the member that created the interaction can start and stop the server. Members
+// need one of the control roles, or to be an administrator.
+func (s *service) canControlServer(i *discordgo.InteractionCreate) bool {
+	if i.Member == nil {
+		return false
+	} else if i.Member.Permissions&discordgo.PermissionAdministrator != 0 {
+		return true
+	}
+
+	for _, role := range i.Member.Roles {
+		if slices.Contains(s.controlRoles, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseControlArg returns the action of a confirmation button, checking it has not expired.
+func parseControlArg(arg string, now time.Time) (string, error) {
+	action, askedAt, ok := strings.Cut(arg, ":")
+	if _, known := lifecycleVerbs[action]; !ok || !known {
+		return "", fmt.Errorf("unknown server action: %s", arg)
+	}
+
+	unix, err := strconv.ParseInt(askedAt, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("parse confirmation time: %w", err)
+	} else if now.Sub(time.Unix(unix, 0)) > confirmationTimeout {
+		return "", errConfirmationExpired
+	}
+
+	return action, nil
+}
+
+// updateControlMessage replaces the confirmation with the message, removing the buttons.
+func (s *service) updateControlMessage(i *discordgo.InteractionCreate, msg string) {
+	err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    msg,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		slog.Error("Error updating server control message", slog.String(logging.KeyError, err.Error()))
+	}
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeController records the actions taken on the container, and how many saves were made before each.
+type fakeController struct {
+	api     *fakeServerAPI
+	actions []string
+	saved   []int
+}
+
+func (f *fakeController) record(action string) error {
+	f.actions = append(f.actions, action)
+	f.saved = append(f.saved, len(f.api.saves))
+	return nil
+}
+
+func (f *fakeController) Start(context.Context) error   { return f.record("start") }
+func (f *fakeController) Stop(context.Context) error    { return f.record("stop") }
+func (f *fakeController) Restart(context.Context) error { return f.record("restart") }
+
+func (f *fakeController) State(context.Context) (string, error) {
+	return "running", nil
+}
+
+func lifecycleService() (*service, *fakeController, *fakeServerAPI) {
+	api := new(fakeServerAPI)
+	controller := &fakeController{api: api}
+	s := NewService("token", WithServerAPI(api), WithServerControl(controller, []string{"operator"})).(*service)
+	return s, controller, api
+}
+
+func TestService_ControlServer(t *testing.T) {
+	s, controller, api := lifecycleService()
+
+	var progress []string
+	report := func(msg string) {
+		progress = append(progress, msg)
+	}
+
+	require.NoError(t, s.controlServer(context.Background(), serverStopSubCmd, report))
+	require.Equal(t, []string{"stop"}, controller.actions)
+	require.Equal(t, []int{1}, controller.saved, "the game must be saved before the server stops")
+	require.Len(t, progress, 2)
+	require.Contains(t, progress[0], api.saves[0])
+	require.Equal(t, "Stopping the server...", progress[1])
+
+	// Starting doesn't save, there is no game running to save.
+	require.NoError(t, s.controlServer(context.Background(), serverStartSubCmd, report))
+	require.Equal(t, []string{"stop", "start"}, controller.actions)
+	require.Len(t, api.saves, 1)
+}
+
+func TestService_ControlServer_SaveFails(t *testing.T) {
+	s, controller, api := lifecycleService()
+	api.err = errors.New("server busy")
+
+	err := s.controlServer(context.Background(), serverRestartSubCmd, func(string) {})
+	require.ErrorContains(t, err, "server busy")
+	require.Empty(t, controller.actions, "the server must not restart without a save")
+}
+
+func TestService_ControlServer_NoAPI(t *testing.T) {
+	controller := new(fakeController)
+	s := NewService("token", WithServerControl(controller, nil)).(*service)
+
+	require.Error(t, s.controlServer(context.Background(), serverStopSubCmd, func(string) {}))
+	require.Empty(t, controller.actions)
+}
+
+func TestService_CanControlServer(t *testing.T) {
+	s, _, _ := lifecycleService()
+
+	require.True(t, s.canControlServer(memberInteraction("g1", "1", "player", "operator")))
+	require.False(t, s.canControlServer(memberInteraction("g1", "1", "player")))
+
+	admin := memberInteraction("g1", "1")
+	admin.Member.Permissions = discordgo.PermissionAdministrator
+	require.True(t, s.canControlServer(admin))
+
+	dm := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{User: &discordgo.User{ID: "1"}}}
+	require.False(t, s.canControlServer(dm))
+}
+
+func TestParseControlArg(t *testing.T) {
+	now := time.Now()
+	askedAt := strconv.FormatInt(now.Unix(), 10)
+
+	action, err := parseControlArg("restart:"+askedAt, now.Add(10*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, serverRestartSubCmd, action)
+
+	_, err = parseControlArg("stop:"+askedAt, now.Add(2*confirmationTimeout))
+	require.ErrorIs(t, err, errConfirmationExpired)
+
+	_, err = parseControlArg("delete:"+askedAt, now)
+	require.Error(t, err)
+
+	_, err = parseControlArg("stop", now)
+	require.Error(t, err)
+}
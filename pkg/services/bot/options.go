@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/container"
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/Jacobbrewer1/satisfactory/pkg/secrets"
+)
+
+type ServiceOption func(s *service)
+
+// WithGuild registers the commands in the guild instead of globally. Changes to guild commands apply immediately,
+// which is useful during development.
+func WithGuild(guildID string) ServiceOption {
+	return func(s *service) {
+		s.guildID = guildID
+	}
+}
+
+// WithGlobalCommandRemoval removes the global commands that the guild commands replace when the commands are
+// registered in a guild. Only use it when the bot is moved to a guild for good, as it removes the commands from every
+// other server the application is in.
+func WithGlobalCommandRemoval() ServiceOption {
+	return func(s *service) {
+		s.removeGlobalCommands = true
+	}
+}
+
+// WithAccessPolicies limits who can use the commands, keyed by the name of the command.
+func WithAccessPolicies(policies map[string]*AccessPolicy) ServiceOption {
+	return func(s *service) {
+		for name, policy := range policies {
+			s.policies[name] = policy
+		}
+	}
+}
+
+// WithServerAPI lets the commands that change the dedicated server call its HTTPS API.
+func WithServerAPI(client satisfactory.APIClient) ServiceOption {
+	return func(s *service) {
+		s.serverAPI = client
+	}
+}
+
+// WithServerControl lets members with one of the roles, and administrators, start, stop and restart the server.
+func WithServerControl(controller container.Controller, roles []string) ServiceOption {
+	return func(s *service) {
+		s.controller = controller
+		s.controlRoles = roles
+	}
+}
+
+// WithConsolePolicy limits the console commands that can be run from discord.
+func WithConsolePolicy(policy *ConsolePolicy) ServiceOption {
+	return func(s *service) {
+		s.consolePolicy = policy
+	}
+}
+
+// WithMaxAttachmentSize raises the largest file sent to discord, for servers boosted to allow larger uploads.
+func WithMaxAttachmentSize(size int) ServiceOption {
+	return func(s *service) {
+		if size > 0 {
+			s.maxAttachmentSize = size
+		}
+	}
+}
+
+// WithCredentials stores the server password in the secret store at the path, so it can be set and rotated.
+func WithCredentials(store secrets.Store, path string) ServiceOption {
+	return func(s *service) {
+		s.credentials = store
+		s.credentialsPath = path
+	}
+}
+
+// WithAlertChannel posts the alerts handed to the bot through the queue to the channel, with buttons to acknowledge
+// and resolve them.
+func WithAlertChannel(channelID, queue string) ServiceOption {
+	return func(s *service) {
+		s.alertChannelID = channelID
+		if queue != "" {
+			s.alertQueue = queue
+		}
+	}
+}
+
+// WithAlertDestinations sets the names of the configured alert destinations, so the alerts of each can be muted.
+func WithAlertDestinations(names []string) ServiceOption {
+	return func(s *service) {
+		s.alertDestinations = names
+	}
+}
+
+// WithEscalation mentions the role when a posted alert has not been acknowledged within the timeout. Everyone in the
+// channel is mentioned when the role is empty.
+func WithEscalation(roleID string, timeout time.Duration) ServiceOption {
+	return func(s *service) {
+		s.escalationRoleID = roleID
+		if timeout > 0 {
+			s.ackTimeout = timeout
+		}
+	}
+}
+
+// WithStatusPanel keeps a pinned message showing the state of the server in the channel.
+func WithStatusPanel(channelID string) ServiceOption {
+	return func(s *service) {
+		s.statusChannelID = channelID
+	}
+}
@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/bwmarrin/discordgo"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// statusPanelKey is the redis hash holding the channel and ID of the status panel message, so the bot edits the
+	// same message after it restarts.
+	statusPanelKey = "bot:status_panel"
+
+	// statusPanelInterval is how often the status panel is checked for changes.
+	statusPanelInterval = 15 * time.Second
+
+	// statusPanelRefresh is how often the status panel is edited when nothing changed, to keep the last update time
+	// current.
+	statusPanelRefresh = 5 * time.Minute
+)
+
+// panelFields returns the fields of the status panel.
+func (st *serverStatus) panelFields() []*discordgo.MessageEmbedField {
+	return []*discordgo.MessageEmbedField{
+		{Name: "State", Value: st.gameState(), Inline: true},
+		{Name: "Session", Value: valueOrDash(st.Session), Inline: true},
+		{Name: "Players", Value: fmt.Sprintf("%d / %d", st.Players, st.PlayerLimit), Inline: true},
+		{Name: "Tech tier", Value: strconv.Itoa(st.TechTier), Inline: true},
+		{Name: "Tick rate", Value: strconv.FormatFloat(st.TickRate, 'f', 1, 64), Inline: true},
+		{Name: "Uptime", Value: valueOrDash(st.Uptime), Inline: true},
+	}
+}
+
+// panelEmbed returns the status panel showing the state.
+func (st *serverStatus) panelEmbed() *discordgo.MessageEmbed {
+	return statusEmbed("Server status", st.colour(), st.panelFields(), st.Details)
+}
+
+// sameAs returns true if the panel would show the same state, ignoring when it was last updated.
+func (st *serverStatus) sameAs(other *serverStatus) bool {
+	return reflect.DeepEqual(st.panelFields(), other.panelFields()) && st.Details.Stale == other.Details.Stale
+}
+
+// statusPanel is the message in the status channel that shows the state of the server.
+type statusPanel struct {
+	// messageID is the ID of the message. It is empty until the message is found or created.
+	messageID string
+
+	// shown is the state the message shows.
+	shown *serverStatus
+
+	// editedAt is when the message was last edited.
+	editedAt time.Time
+}
+
+// watchStatusPanel keeps the status panel up to date, recreating it when it is deleted.
+func (s *service) watchStatusPanel() {
+	ticker := time.NewTicker(statusPanelInterval)
+	defer ticker.Stop()
+
+	panel := new(statusPanel)
+	for {
+		if err := s.updateStatusPanel(panel); err != nil {
+			slog.Error("Error updating status panel", slog.String(logging.KeyError, err.Error()))
+		}
+
+		select {
+		case <-s.ctx.Done():
+			slog.Debug("Context done")
+			return
+		case <-ticker.C:
+		case id := <-s.panelDeleted:
+			if id == panel.messageID {
+				slog.Info("Status panel was deleted, recreating it")
+				panel.messageID = ""
+			}
+		}
+	}
+}
+
+// updateStatusPanel edits the status panel when the state changed, creating it when there isn't one.
+func (s *service) updateStatusPanel(panel *statusPanel) error {
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	status, err := getServerStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if panel.messageID == "" {
+		panel.messageID, err = s.storedStatusPanel(ctx)
+		if err != nil {
+			return err
+		}
+		panel.shown = nil
+	}
+
+	now := time.Now()
+	if panel.messageID != "" && panel.shown != nil && status.sameAs(panel.shown) && now.Sub(panel.editedAt) < statusPanelRefresh {
+		return nil
+	}
+
+	if panel.messageID != "" {
+		_, err := s.s.ChannelMessageEditEmbed(s.statusChannelID, panel.messageID, status.panelEmbed(), discordgo.WithContext(ctx))
+		if err == nil {
+			panel.shown, panel.editedAt = status, now
+			return nil
+		} else if !isUnknownMessage(err) {
+			return fmt.Errorf("edit status panel: %w", err)
+		}
+		slog.Info("Status panel not found, recreating it")
+	}
+
+	msg, err := s.s.ChannelMessageSendEmbed(s.statusChannelID, status.panelEmbed(), discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("send status panel: %w", err)
+	}
+	panel.messageID, panel.shown, panel.editedAt = msg.ID, status, now
+
+	if _, err := goredis.DoCtx(ctx, "HSET", statusPanelKey, "channel_id", s.statusChannelID, "message_id", msg.ID); err != nil {
+		return fmt.Errorf("store status panel: %w", err)
+	}
+
+	// The panel still works unpinned, pinning needs the manage messages permission.
+	if err := s.s.ChannelMessagePin(s.statusChannelID, msg.ID, discordgo.WithContext(ctx)); err != nil {
+		slog.Error("Error pinning status panel", slog.String(logging.KeyError, err.Error()))
+	}
+
+	return nil
+}
+
+// storedStatusPanel returns the ID of the status panel message posted before the bot restarted. It is empty when
+// there isn't one in the status channel.
+func (s *service) storedStatusPanel(ctx context.Context) (string, error) {
+	stored, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", statusPanelKey))
+	if err != nil {
+		return "", fmt.Errorf("get status panel: %w", err)
+	} else if stored["channel_id"] != s.statusChannelID {
+		return "", nil
+	}
+	return stored["message_id"], nil
+}
+
+// onMessageDelete recreates the status panel when it is deleted.
+func (s *service) onMessageDelete(_ *discordgo.Session, m *discordgo.MessageDelete) {
+	if s.statusChannelID == "" || m.ChannelID != s.statusChannelID {
+		return
+	}
+
+	select {
+	case s.panelDeleted <- m.ID:
+	default:
+	}
+}
+
+// isUnknownMessage returns true if discord rejected the request because the message doesn't exist.
+func isUnknownMessage(err error) bool {
+	restErr := new(discordgo.RESTError)
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage
+}
@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerStatus_PanelEmbed(t *testing.T) {
+	st := &serverStatus{
+		State:       "running",
+		Uptime:      "3 hours",
+		Running:     true,
+		Session:     "Factory",
+		Players:     2,
+		PlayerLimit: 4,
+		TickRate:    30,
+		Details:     &state.Freshness{LastSeen: time.Unix(1725976344, 0)},
+	}
+
+	embed := st.panelEmbed()
+	require.Equal(t, colourUp, embed.Color)
+	require.Equal(t, "Players", embed.Fields[2].Name)
+	require.Equal(t, "2 / 4", embed.Fields[2].Value)
+	require.Equal(t, "30.0", embed.Fields[4].Value)
+	require.Equal(t, "<t:1725976344:R>", embed.Fields[6].Value)
+
+	st.Details.Stale = true
+	require.Equal(t, colourUnknown, st.panelEmbed().Color)
+}
+
+func TestServerStatus_SameAs(t *testing.T) {
+	a := &serverStatus{State: "running", Players: 1, Details: &state.Freshness{LastSeen: time.Now()}}
+	b := &serverStatus{State: "running", Players: 1, Details: &state.Freshness{LastSeen: time.Now().Add(time.Minute)}}
+	require.True(t, a.sameAs(b))
+
+	// The panel doesn't show the play time.
+	b.GameDuration = time.Hour
+	require.True(t, a.sameAs(b))
+
+	b.Players = 2
+	require.False(t, a.sameAs(b))
+
+	b.Players = 1
+	b.Details.Stale = true
+	require.False(t, a.sameAs(b))
+}
+
+func TestIsUnknownMessage(t *testing.T) {
+	err := &discordgo.RESTError{
+		Response: &http.Response{StatusCode: http.StatusNotFound},
+		Message:  &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownMessage},
+	}
+	require.True(t, isUnknownMessage(err))
+
+	err.Message.Code = discordgo.ErrCodeMissingPermissions
+	require.False(t, isUnknownMessage(err))
+}
+
+func TestService_OnMessageDelete(t *testing.T) {
+	s := NewService("token", WithStatusPanel("status")).(*service)
+
+	s.onMessageDelete(nil, &discordgo.MessageDelete{Message: &discordgo.Message{ID: "elsewhere", ChannelID: "general"}})
+	require.Empty(t, s.panelDeleted)
+
+	s.onMessageDelete(nil, &discordgo.MessageDelete{Message: &discordgo.Message{ID: "panel", ChannelID: "status"}})
+	s.onMessageDelete(nil, &discordgo.MessageDelete{Message: &discordgo.Message{ID: "other", ChannelID: "status"}})
+	require.Equal(t, "panel", <-s.panelDeleted)
+}
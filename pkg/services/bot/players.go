@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// recentPlayersShown is the number of players that left shown by the players command.
+	recentPlayersShown = 10
+
+	// maxEmbedFieldLength is the longest value discord accepts in an embed field.
+	maxEmbedFieldLength = 1024
+)
+
+func (s *service) playersView(ctx context.Context) (*discordgo.MessageEmbed, error) {
+	st, err := getServerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	online, err := state.OnlinePlayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := state.RecentPlayers(ctx, recentPlayersShown)
+	if err != nil {
+		return nil, err
+	}
+
+	return playersEmbed(st, online, recent, time.Now()), nil
+}
+
+// playersEmbed returns the embed listing the players online and the players that left recently. The server only
+// reports how many players are online, so the players the watcher didn't see join are counted without their names.
+func playersEmbed(st *serverStatus, online, recent []*state.PlayerSession, now time.Time) *discordgo.MessageEmbed {
+	lines := make([]string, 0, len(online)+1)
+	for _, p := range online {
+		lines = append(lines, fmt.Sprintf("- **%s** for %s", p.Name, formatPlayTime(p.Duration(now))))
+	}
+
+	switch unknown := st.Players - len(online); {
+	case unknown > 0 && len(online) == 0:
+		lines = append(lines, fmt.Sprintf("%d online, their names aren't known", unknown))
+	case unknown > 0:
+		lines = append(lines, fmt.Sprintf("and %d more whose names aren't known", unknown))
+	case len(online) == 0:
+		lines = append(lines, "Nobody is online")
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: fmt.Sprintf("Online (%d / %d)", max(st.Players, len(online)), st.PlayerLimit), Value: joinLines(lines)},
+	}
+
+	if len(recent) > 0 {
+		lines = make([]string, 0, len(recent))
+		for _, p := range recent {
+			lines = append(lines, fmt.Sprintf("- **%s** played %s, left <t:%d:R>", p.Name, formatPlayTime(p.Duration(now)), p.LeftAt.Unix()))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Recently", Value: joinLines(lines)})
+	}
+
+	return statusEmbed("Players", st.colour(), fields, st.Details)
+}
+
+// joinLines joins the lines, leaving out the lines that don't fit in an embed field.
+func joinLines(lines []string) string {
+	sb := new(strings.Builder)
+	for _, line := range lines {
+		if sb.Len()+len(line)+1 > maxEmbedFieldLength {
+			break
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
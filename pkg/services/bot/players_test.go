@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayersEmbed(t *testing.T) {
+	now := time.Date(2024, time.September, 10, 14, 0, 0, 0, time.UTC)
+	st := &serverStatus{State: "running", Running: true, Players: 3, PlayerLimit: 4, Details: new(state.Freshness)}
+	online := []*state.PlayerSession{
+		{Name: "Pioneer", JoinedAt: now.Add(-65 * time.Minute)},
+		{Name: "Ficsit", JoinedAt: now.Add(-5 * time.Minute)},
+	}
+	recent := []*state.PlayerSession{
+		{Name: "Engineer", JoinedAt: now.Add(-3 * time.Hour), LeftAt: now.Add(-2 * time.Hour)},
+	}
+
+	embed := playersEmbed(st, online, recent, now)
+	require.Equal(t, "Online (3 / 4)", embed.Fields[0].Name)
+	require.Equal(t, "- **Pioneer** for 1h 05m\n- **Ficsit** for 0h 05m\nand 1 more whose names aren't known", embed.Fields[0].Value)
+	require.Equal(t, "Recently", embed.Fields[1].Name)
+	require.Equal(t, "- **Engineer** played 1h 00m, left <t:1725969600:R>", embed.Fields[1].Value)
+	require.Equal(t, "Last updated", embed.Fields[2].Name)
+}
+
+func TestPlayersEmbed_CountOnly(t *testing.T) {
+	st := &serverStatus{State: "running", Running: true, Players: 2, PlayerLimit: 4, Details: new(state.Freshness)}
+
+	embed := playersEmbed(st, nil, nil, time.Now())
+	require.Equal(t, "2 online, their names aren't known", embed.Fields[0].Value)
+	require.Len(t, embed.Fields, 2, "there is no recent players field without recent players")
+
+	st.Players = 0
+	require.Equal(t, "Nobody is online", playersEmbed(st, nil, nil, time.Now()).Fields[0].Value)
+}
+
+func TestJoinLines(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = strings.Repeat("x", 20)
+	}
+
+	got := joinLines(lines)
+	require.LessOrEqual(t, len(got), maxEmbedFieldLength)
+	require.Equal(t, 48, strings.Count(got, "\n")+1)
+}
@@ -0,0 +1,468 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxAutocompleteChoices is the most choices discord accepts in an autocomplete response.
+	maxAutocompleteChoices = 25
+
+	// panicResponse is shown to the user when the handler of their interaction panics.
+	panicResponse = "Something went wrong, please try again later"
+)
+
+// handlerFunc handles an interaction.
+type handlerFunc func(s *discordgo.Session, i *discordgo.InteractionCreate)
+
+// autocompleteFunc returns the choices matching the partial value the user typed into an option.
+type autocompleteFunc func(ctx context.Context, i *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error)
+
+// command is a slash command, or a subcommand, declared with everything needed to register and handle it.
+type command struct {
+	// name is the name the command is invoked by.
+	name string
+
+	// description is shown to users when they pick the command.
+	description string
+
+	// permissions are the permissions a member needs to use the command. Anyone can use the command when it is nil.
+	// Discord only hides commands from members without them, and guilds can override that, so they are checked again
+	// when the command is invoked.
+	permissions *int64
+
+	// options are the options of the command. Commands with subcommands have no options of their own.
+	options []*option
+
+	// subcommands are the subcommands of the command. A subcommand with subcommands of its own is a group.
+	subcommands []*command
+
+	// handler handles the command. Commands with subcommands have no handler of their own.
+	handler handlerFunc
+
+	// sensitive is true if every invocation of the command is audited. It only applies to top level commands.
+	sensitive bool
+
+	// auditOptions is true if the options given to a sensitive command are audited too. Commands taking secrets, such
+	// as passwords, must leave it unset. It only applies to top level commands.
+	auditOptions bool
+}
+
+// option is an option of a command.
+type option struct {
+	// name is the name of the option.
+	name string
+
+	// description is shown to users when they fill in the option.
+	description string
+
+	// kind is the type of the value of the option.
+	kind discordgo.ApplicationCommandOptionType
+
+	// required is true if the command can't be invoked without the option.
+	required bool
+
+	// choices are the only values the option accepts. Any value is accepted when there are none.
+	choices []*discordgo.ApplicationCommandOptionChoice
+
+	// autocomplete suggests values as the user types. It can't be set together with choices.
+	autocomplete autocompleteFunc
+}
+
+// registry holds the commands and message components of the bot and dispatches interactions to their handlers.
+type registry struct {
+	// commands are the top level commands in the order they were added.
+	commands []*command
+
+	// byName are the top level commands keyed by name.
+	byName map[string]*command
+
+	// components are the handlers of the message components, keyed by the prefix of their custom ID.
+	components map[string]handlerFunc
+
+	// policies limit who can use the commands and components, keyed by the name of the top level command, the command
+	// and subcommands separated by spaces, or the prefix of the component.
+	policies map[string]*AccessPolicy
+
+	// audit records the invocations of the sensitive commands. Nothing is recorded when it is nil.
+	audit auditLog
+
+	// respondError tells the user their interaction failed. It is replaced in tests.
+	respondError func(s *discordgo.Session, i *discordgo.InteractionCreate, msg string)
+}
+
+func newRegistry() *registry {
+	return &registry{
+		byName:       make(map[string]*command),
+		components:   make(map[string]handlerFunc),
+		policies:     make(map[string]*AccessPolicy),
+		respondError: respondInteractionError,
+	}
+}
+
+// addCommand adds a top level command.
+func (r *registry) addCommand(cmd *command) {
+	if _, ok := r.byName[cmd.name]; ok {
+		panic(fmt.Sprintf("command %q registered twice", cmd.name))
+	}
+
+	r.commands = append(r.commands, cmd)
+	r.byName[cmd.name] = cmd
+}
+
+// addComponent adds the handler of the message components with the custom ID prefix. Component custom IDs are the
+// prefix followed by a colon and the argument of the handler.
+func (r *registry) addComponent(prefix string, handler handlerFunc) {
+	if _, ok := r.components[prefix]; ok {
+		panic(fmt.Sprintf("component %q registered twice", prefix))
+	}
+
+	r.components[prefix] = handler
+}
+
+// applicationCommands returns the commands to register with discord.
+func (r *registry) applicationCommands() []*discordgo.ApplicationCommand {
+	cmds := make([]*discordgo.ApplicationCommand, len(r.commands))
+	for i, c := range r.commands {
+		cmds[i] = &discordgo.ApplicationCommand{
+			Name:                     c.name,
+			Type:                     discordgo.ChatApplicationCommand,
+			Description:              c.description,
+			DefaultMemberPermissions: r.permissions(c),
+			Options:                  c.commandOptions(),
+		}
+
+		// Permissions only exist in guilds, so the sensitive commands can't be used in direct messages.
+		if c.sensitive {
+			cmds[i].DMPermission = utils.Ptr(false)
+		}
+	}
+	return cmds
+}
+
+// permissions returns the permissions a member needs to use the top level command, which its policy can replace.
+func (r *registry) permissions(c *command) *int64 {
+	if p, ok := r.policies[c.name]; ok && p.DefaultMemberPermissions != nil {
+		return p.DefaultMemberPermissions
+	}
+	return c.permissions
+}
+
+// commandOptions returns the options of the command, which are its subcommands when it has any.
+func (c *command) commandOptions() []*discordgo.ApplicationCommandOption {
+	if len(c.subcommands) > 0 {
+		opts := make([]*discordgo.ApplicationCommandOption, len(c.subcommands))
+		for i, sub := range c.subcommands {
+			kind := discordgo.ApplicationCommandOptionSubCommand
+			if len(sub.subcommands) > 0 {
+				kind = discordgo.ApplicationCommandOptionSubCommandGroup
+			}
+
+			opts[i] = &discordgo.ApplicationCommandOption{
+				Name:        sub.name,
+				Type:        kind,
+				Description: sub.description,
+				Options:     sub.commandOptions(),
+			}
+		}
+		return opts
+	}
+
+	opts := make([]*discordgo.ApplicationCommandOption, len(c.options))
+	for i, o := range c.options {
+		opts[i] = &discordgo.ApplicationCommandOption{
+			Name:         o.name,
+			Type:         o.kind,
+			Description:  o.description,
+			Required:     o.required,
+			Choices:      o.choices,
+			Autocomplete: o.autocomplete != nil,
+		}
+	}
+	return opts
+}
+
+// resolve returns the command or subcommand invoked by the interaction data, and the options given to it.
+func (r *registry) resolve(data discordgo.ApplicationCommandInteractionData) (*command, []*discordgo.ApplicationCommandInteractionDataOption, error) {
+	cmd, ok := r.byName[data.Name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown command: %s", data.Name)
+	}
+
+	opts := data.Options
+	path := data.Name
+	for len(cmd.subcommands) > 0 {
+		if len(opts) == 0 {
+			return nil, nil, fmt.Errorf("missing subcommand of %s", path)
+		}
+
+		var sub *command
+		for _, s := range cmd.subcommands {
+			if s.name == opts[0].Name {
+				sub = s
+				break
+			}
+		}
+		if sub == nil {
+			return nil, nil, fmt.Errorf("unknown subcommand: %s %s", path, opts[0].Name)
+		}
+
+		path += " " + sub.name
+		cmd = sub
+		opts = opts[0].Options
+	}
+
+	return cmd, opts, nil
+}
+
+// handle dispatches the interaction to its handler by the type of the interaction.
+func (r *registry) handle(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		data := i.ApplicationCommandData()
+		cmd, _, err := r.resolve(data)
+		if err != nil {
+			slog.Error("Error resolving command", slog.String(logging.KeyError, err.Error()))
+			return
+		} else if cmd.handler == nil {
+			slog.Error("No handler found for command", slog.String("command", data.Name))
+			return
+		}
+
+		path := commandPath(data)
+		top := r.byName[data.Name]
+		// Guilds can override the permissions discord checks, so they are checked again for the top level command and
+		// the invoked subcommand.
+		allowed := r.allows(i, data.Name, path) && hasPermissions(i, r.permissions(top)) && (cmd == top || cmd.permitted(i))
+		if top.sensitive {
+			entry := newAuditEntry(i, path, allowed)
+			if top.auditOptions {
+				entry.Options = optionValues(i)
+			}
+			r.record(entry)
+		}
+		if !allowed {
+			slog.Warn("Command denied by access policy", slog.String("command", path), slog.String("user_id", interactionUserID(i)))
+			r.respondError(s, i, accessDeniedResponse)
+			return
+		}
+
+		r.safely(s, i, data.Name, func() {
+			cmd.handler(s, i)
+		})
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		data := i.ApplicationCommandData()
+		if !r.allows(i, data.Name, commandPath(data)) {
+			return
+		}
+
+		r.safely(s, i, data.Name, func() {
+			r.autocomplete(s, i, data)
+		})
+	case discordgo.InteractionMessageComponent:
+		customID := i.MessageComponentData().CustomID
+		prefix, _, _ := strings.Cut(customID, ":")
+		handler, ok := r.components[prefix]
+		if !ok {
+			slog.Error("No handler found for component", slog.String("component", customID))
+			return
+		}
+
+		if !r.policies[prefix].Allows(i) {
+			r.respondError(s, i, accessDeniedResponse)
+			return
+		}
+
+		r.safely(s, i, prefix, func() {
+			handler(s, i)
+		})
+	default:
+		slog.Debug("Ignoring interaction", slog.String("type", i.Type.String()))
+	}
+}
+
+// allows returns true if the policies of the top level command and of the invoked subcommand let the user that
+// created the interaction use it.
+func (r *registry) allows(i *discordgo.InteractionCreate, name, path string) bool {
+	if !r.policies[name].Allows(i) {
+		return false
+	}
+	return path == name || r.policies[path].Allows(i)
+}
+
+// permitted returns true if the member that created the interaction has the permissions of the command.
+func (c *command) permitted(i *discordgo.InteractionCreate) bool {
+	return hasPermissions(i, c.permissions)
+}
+
+// hasPermissions returns true if the member that created the interaction has the permissions. Anyone has them when
+// they are nil or empty.
+func hasPermissions(i *discordgo.InteractionCreate, permissions *int64) bool {
+	if permissions == nil || *permissions == 0 {
+		return true
+	} else if i.Member == nil {
+		// Permissions only exist in guilds, so a direct message can't have them.
+		return false
+	}
+
+	return i.Member.Permissions&discordgo.PermissionAdministrator != 0 ||
+		i.Member.Permissions&*permissions == *permissions
+}
+
+// sensitiveCommandChoices returns the names of the sensitive commands starting with the prefix.
+func (r *registry) sensitiveCommandChoices(prefix string) []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0)
+	for _, c := range r.commands {
+		if c.sensitive && strings.HasPrefix(c.name, prefix) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  c.name,
+				Value: c.name,
+			})
+		}
+	}
+	return choices
+}
+
+// record stores the audit entry, logging any error so the command is not held up by the audit log.
+func (r *registry) record(entry *auditEntry) {
+	if r.audit == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := r.audit.Record(ctx, entry); err != nil {
+		slog.Error("Error recording audit entry", slog.String("command", entry.Command), slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// commandPath returns the command and the subcommands invoked by the interaction data, separated by spaces.
+func commandPath(data discordgo.ApplicationCommandInteractionData) string {
+	path := data.Name
+	opts := data.Options
+	for len(opts) > 0 && (opts[0].Type == discordgo.ApplicationCommandOptionSubCommand ||
+		opts[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup) {
+		path += " " + opts[0].Name
+		opts = opts[0].Options
+	}
+	return path
+}
+
+// autocomplete responds with the choices for the option the user is typing into.
+func (r *registry) autocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	cmd, opts, err := r.resolve(data)
+	if err != nil {
+		slog.Error("Error resolving command", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	for _, o := range opts {
+		if o.Focused {
+			focused = o
+			break
+		}
+	}
+	if focused == nil {
+		return
+	}
+
+	var provider autocompleteFunc
+	for _, o := range cmd.options {
+		if o.name == focused.Name {
+			provider = o.autocomplete
+			break
+		}
+	}
+	if provider == nil {
+		slog.Error("No autocomplete found for option", slog.String("command", data.Name), slog.String("option", focused.Name))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	choices, err := provider(ctx, i, focused.StringValue())
+	if err != nil {
+		slog.Error("Error getting autocomplete choices", slog.String("option", focused.Name), slog.String(logging.KeyError, err.Error()))
+	}
+	if len(choices) > maxAutocompleteChoices {
+		choices = choices[:maxAutocompleteChoices]
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to autocomplete", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// safely runs the handler, recovering from a panic so one broken handler can't take down the bot.
+func (r *registry) safely(s *discordgo.Session, i *discordgo.InteractionCreate, name string, fn func()) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			slog.Error("Interaction handler panicked",
+				slog.String("handler", name),
+				slog.Any("panic", rec),
+				slog.String("stack", string(debug.Stack())),
+			)
+			r.respondError(s, i, panicResponse)
+		}
+	}()
+
+	fn()
+}
+
+// respondInteractionError tells the user their interaction failed, whether or not it was already responded to.
+func respondInteractionError(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: msg,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err == nil {
+		return
+	}
+
+	// The handler responded before it panicked, so replace that response instead.
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &msg,
+	}); err != nil {
+		slog.Error("Error responding to failed interaction", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// optionValues returns the values of the options given to the invoked command or subcommand, keyed by name.
+func optionValues(i *discordgo.InteractionCreate) map[string]string {
+	opts := i.ApplicationCommandData().Options
+	for len(opts) > 0 && (opts[0].Type == discordgo.ApplicationCommandOptionSubCommand ||
+		opts[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup) {
+		opts = opts[0].Options
+	}
+
+	values := make(map[string]string, len(opts))
+	for _, o := range opts {
+		values[o.Name] = fmt.Sprint(o.Value)
+	}
+	return values
+}
@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+// commandInteraction returns the interaction of invoking the command with the options.
+func commandInteraction(name string, opts ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionApplicationCommand,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name:    name,
+				Options: opts,
+			},
+		},
+	}
+}
+
+func testRegistry(called *string) *registry {
+	handler := func(name string) handlerFunc {
+		return func(*discordgo.Session, *discordgo.InteractionCreate) {
+			*called = name
+		}
+	}
+
+	r := newRegistry()
+	r.addCommand(&command{
+		name:        "ping",
+		description: "Ping",
+		handler:     handler("ping"),
+	})
+	r.addCommand(&command{
+		name:        "alerts",
+		description: "Manage alerts",
+		subcommands: []*command{
+			{
+				name:        "mute",
+				description: "Mute alerts",
+				options: []*option{
+					{
+						name:        "destination",
+						description: "The destination",
+						kind:        discordgo.ApplicationCommandOptionString,
+						required:    true,
+						autocomplete: func(context.Context, *discordgo.InteractionCreate, string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+							return nil, nil
+						},
+					},
+				},
+				handler: handler("alerts mute"),
+			},
+			{
+				name:        "list",
+				description: "List mutes",
+				handler: func(*discordgo.Session, *discordgo.InteractionCreate) {
+					panic("broken")
+				},
+			},
+		},
+	})
+	r.addComponent("alert-ack", handler("alert-ack"))
+
+	return r
+}
+
+func TestRegistry_ApplicationCommands(t *testing.T) {
+	var called string
+	cmds := testRegistry(&called).applicationCommands()
+
+	require.Len(t, cmds, 2)
+	require.Equal(t, "ping", cmds[0].Name)
+	require.Empty(t, cmds[0].Options)
+
+	require.Equal(t, "alerts", cmds[1].Name)
+	require.Len(t, cmds[1].Options, 2)
+
+	mute := cmds[1].Options[0]
+	require.Equal(t, discordgo.ApplicationCommandOptionSubCommand, mute.Type)
+	require.Len(t, mute.Options, 1)
+	require.True(t, mute.Options[0].Required)
+	require.True(t, mute.Options[0].Autocomplete)
+}
+
+func TestRegistry_Handle(t *testing.T) {
+	var called string
+	r := testRegistry(&called)
+
+	r.handle(nil, commandInteraction("ping"))
+	require.Equal(t, "ping", called)
+
+	r.handle(nil, commandInteraction("alerts", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "mute",
+		Type: discordgo.ApplicationCommandOptionSubCommand,
+	}))
+	require.Equal(t, "alerts mute", called)
+
+	r.handle(nil, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionMessageComponent,
+			Data: discordgo.MessageComponentInteractionData{
+				CustomID: "alert-ack:123",
+			},
+		},
+	})
+	require.Equal(t, "alert-ack", called)
+}
+
+func TestRegistry_HandleRecoversPanic(t *testing.T) {
+	var called string
+	r := testRegistry(&called)
+
+	var responded string
+	r.respondError = func(_ *discordgo.Session, _ *discordgo.InteractionCreate, msg string) {
+		responded = msg
+	}
+
+	require.NotPanics(t, func() {
+		r.handle(nil, commandInteraction("alerts", &discordgo.ApplicationCommandInteractionDataOption{
+			Name: "list",
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+		}))
+	})
+	require.Equal(t, panicResponse, responded)
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	var called string
+	r := testRegistry(&called)
+
+	_, _, err := r.resolve(discordgo.ApplicationCommandInteractionData{Name: "unknown"})
+	require.EqualError(t, err, "unknown command: unknown")
+
+	_, _, err = r.resolve(discordgo.ApplicationCommandInteractionData{Name: "alerts"})
+	require.EqualError(t, err, "missing subcommand of alerts")
+
+	cmd, opts, err := r.resolve(discordgo.ApplicationCommandInteractionData{
+		Name: "alerts",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{
+				Name: "mute",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "destination", Type: discordgo.ApplicationCommandOptionString, Value: "discord"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "mute", cmd.name)
+	require.Len(t, opts, 1)
+}
@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+// ephemeralCommand handles a command, returning the message shown to the user.
+type ephemeralCommand func(ctx context.Context, i *discordgo.InteractionCreate, options map[string]string) (string, error)
+
+// defaultCommandTimeout is how long a command has to respond.
+const defaultCommandTimeout = 5 * time.Second
+
+// ephemeralHandler returns the handler of a command that responds to the user privately. Errors are shown to the user.
+func (s *service) ephemeralHandler(name string, fn ephemeralCommand) handlerFunc {
+	return s.slowEphemeralHandler(name, defaultCommandTimeout, fn)
+}
+
+// slowEphemeralHandler is an ephemeralHandler for commands that can take longer than the default timeout, such as
+// those waiting on the game server.
+func (s *service) slowEphemeralHandler(name string, timeout time.Duration, fn ephemeralCommand) handlerFunc {
+	return func(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+		err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags: discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Error("Error responding to command", slog.String("command", name), slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		msg, err := fn(ctx, i, optionValues(i))
+		if err != nil {
+			slog.Error("Error handling command", slog.String("command", name), slog.String(logging.KeyError, err.Error()))
+			msg = "Error: " + err.Error()
+		}
+
+		_, err = s.s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: utils.Ptr(msg),
+		})
+		if err != nil {
+			slog.Error("Error editing command response", slog.String("command", name), slog.String(logging.KeyError, err.Error()))
+			return
+		}
+	}
+}
+
+// pageButtons returns the buttons moving to the previous and next page, with the custom ID of the button showing each
+// page.
+func pageButtons(customID func(page int) string, page, pages int) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: customID(page - 1),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.SecondaryButton,
+					CustomID: customID(page + 1),
+					Disabled: page == pages-1,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,448 @@
+package bot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// defaultMaxAttachmentSize is the largest file discord accepts from a bot in a server without boosts.
+	defaultMaxAttachmentSize = 10 << 20
+
+	// maxSaveSize is the largest save that is downloaded or uploaded, so a broken server can't exhaust memory.
+	maxSaveSize = 1 << 30
+
+	// saveTransferTimeout is how long downloading or uploading a save can take.
+	saveTransferTimeout = 5 * time.Minute
+
+	// saveExtension is the extension of save files.
+	saveExtension = ".sav"
+
+	// savesPageComponentID prefixes the custom ID of the buttons paging through the saves. The argument is the page to
+	// show and the session the list is limited to.
+	savesPageComponentID = "saves-page"
+
+	// savesPageSize is the most of the list of saves shown on a page, leaving room in the message for the footer.
+	savesPageSize = maxMessageLength - 100
+)
+
+// errSaveTooLarge is returned when a save is larger than maxSaveSize.
+var errSaveTooLarge = fmt.Errorf("the save is larger than %d MiB", maxSaveSize>>20)
+
+func (s *service) onSavesList(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to saves list", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverAPITimeout)
+	defer cancel()
+
+	s.editSavesPage(ctx, i, optionValues(i)[sessionOption], 0)
+}
+
+func (s *service) onSavesPage(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	pageArg, session, _ := strings.Cut(componentArg(i), ":")
+	page, err := strconv.Atoi(pageArg)
+	if err != nil {
+		slog.Error("Error parsing saves page", slog.String("page", pageArg), slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	// Listing the saves can take longer than discord waits for a response.
+	err = s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		slog.Error("Error responding to saves page", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverAPITimeout)
+	defer cancel()
+
+	s.editSavesPage(ctx, i, session, page)
+}
+
+// editSavesPage edits the response to the interaction to show the page of the saves.
+func (s *service) editSavesPage(ctx context.Context, i *discordgo.InteractionCreate, session string, page int) {
+	edit := new(discordgo.WebhookEdit)
+	content, components, err := s.savesPage(ctx, session, page)
+	if err != nil {
+		slog.Error("Error listing saves", slog.String(logging.KeyError, err.Error()))
+		edit.Content = utils.Ptr("Error: " + err.Error())
+	} else {
+		edit.Content = &content
+		edit.Components = &components
+	}
+
+	if _, err := s.s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		slog.Error("Error editing saves list response", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// savesPage returns the message showing the page of the saves on the server, limited to the session when it is set,
+// with buttons to move between the pages when there is more than one.
+func (s *service) savesPage(ctx context.Context, session string, page int) (string, []discordgo.MessageComponent, error) {
+	if s.serverAPI == nil {
+		return "", nil, errServerAPINotConfigured
+	} else if session != "" {
+		// The session is in the custom IDs of the buttons, which discord limits in length.
+		if _, err := validName(session); err != nil {
+			return "", nil, err
+		}
+	}
+
+	sessions, err := s.serverAPI.EnumerateSessions(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("enumerate sessions: %w", err)
+	}
+
+	pages := savePages(sessions, session)
+	if len(pages) == 0 {
+		if session != "" {
+			return fmt.Sprintf("There are no saves in `%s`", session), []discordgo.MessageComponent{}, nil
+		}
+		return "There are no saves on the server", []discordgo.MessageComponent{}, nil
+	}
+	page = max(0, min(page, len(pages)-1))
+
+	if len(pages) == 1 {
+		return pages[0], []discordgo.MessageComponent{}, nil
+	}
+
+	content := pages[page] + fmt.Sprintf("\nPage %d of %d", page+1, len(pages))
+	return content, pageButtons(func(page int) string {
+		return fmt.Sprintf("%s:%d:%s", savesPageComponentID, page, session)
+	}, page, len(pages)), nil
+}
+
+// savePages lists the saves of each session, newest first, split into pages.
+func savePages(sessions []*satisfactory.Session, session string) []string {
+	lines := make([]string, 0)
+	for _, sess := range sessions {
+		if (session != "" && sess.Name != session) || len(sess.Saves) == 0 {
+			continue
+		}
+
+		saves := slices.Clone(sess.Saves)
+		// The save dates sort the same as the times they are.
+		slices.SortStableFunc(saves, func(a, b *satisfactory.SaveHeader) int {
+			return strings.Compare(b.SaveDateTime, a.SaveDateTime)
+		})
+
+		lines = append(lines, fmt.Sprintf("**%s**", sess.Name))
+		for _, save := range saves {
+			lines = append(lines, fmt.Sprintf("- `%s` · %s played · saved %s", save.Name, formatPlayTime(save.PlayDuration()), formatSaveDate(save)))
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return paginate(strings.Join(lines, "\n"), savesPageSize)
+}
+
+// formatPlayTime returns the play time in hours and minutes, the way the game shows it.
+func formatPlayTime(d time.Duration) string {
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// formatSaveDate returns when the save was made, shown in the time zone of the user.
+func formatSaveDate(save *satisfactory.SaveHeader) string {
+	savedAt, err := save.SavedAt()
+	if err != nil {
+		return save.SaveDateTime
+	}
+	return fmt.Sprintf("<t:%d:f>", savedAt.Unix())
+}
+
+func (s *service) onSaveDownload(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to save download", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), saveTransferTimeout)
+	defer cancel()
+
+	msg, err := s.sendSave(ctx, i, optionValues(i)[saveOption])
+	if err != nil {
+		slog.Error("Error sending save", slog.String(logging.KeyError, err.Error()))
+		msg = "Error: " + err.Error()
+	}
+
+	if _, err := s.s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: utils.Ptr(msg),
+	}); err != nil {
+		slog.Error("Error editing save download response", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// sendSave sends the save to the user in a direct message, in parts if it is too large for one attachment.
+func (s *service) sendSave(ctx context.Context, i *discordgo.InteractionCreate, name string) (string, error) {
+	name, save, err := s.downloadSave(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer func(save io.ReadCloser) {
+		if err := save.Close(); err != nil {
+			slog.Error("Error closing save", slog.String(logging.KeyError, err.Error()))
+		}
+	}(save)
+
+	channel, err := s.s.UserChannelCreate(interactionUserID(i), discordgo.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("open direct message: %w", err)
+	}
+
+	var sendErr error
+	parts, err := sendParts(save, name+saveExtension, s.maxAttachmentSize, func(file *discordgo.File, part int, split bool) error {
+		send := &discordgo.MessageSend{
+			Files: []*discordgo.File{file},
+		}
+		if part == 1 {
+			send.Content = saveMessage(name, split)
+		}
+
+		if _, sendErr = s.s.ChannelMessageSendComplex(channel.ID, send, discordgo.WithContext(ctx)); sendErr != nil {
+			return fmt.Errorf("send part %d: %w", part, sendErr)
+		}
+		return nil
+	})
+	switch {
+	case err != nil && parts == 0 && sendErr != nil:
+		slog.Error("Error sending save", slog.String("save", name), slog.String(logging.KeyError, err.Error()))
+		return "I couldn't send you a direct message, check you allow direct messages from this server", nil
+	case err != nil && parts > 0:
+		// The parts sent can't be joined into the save, so don't let them be mistaken for all of it.
+		return "", fmt.Errorf("only the first %d parts of `%s` were sent, delete them and try again: %w", parts, name, err)
+	case err != nil:
+		return "", err
+	case parts > 1:
+		return fmt.Sprintf("Sent `%s` to your direct messages in %d parts", name, parts), nil
+	}
+
+	return fmt.Sprintf("Sent `%s` to your direct messages", name), nil
+}
+
+// downloadSave starts downloading the save, returning its validated name and contents. The caller must close it.
+func (s *service) downloadSave(ctx context.Context, name string) (string, io.ReadCloser, error) {
+	if s.serverAPI == nil {
+		return "", nil, errServerAPINotConfigured
+	}
+
+	name, err := validName(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	save, err := s.serverAPI.DownloadSaveGame(ctx, name)
+	if err != nil {
+		return "", nil, fmt.Errorf("download save: %w", err)
+	}
+
+	return name, save, nil
+}
+
+// saveMessage returns the message sent with the save, explaining how to join the parts when it is split.
+func saveMessage(name string, split bool) string {
+	if !split {
+		return fmt.Sprintf("Here is `%s`", name)
+	}
+
+	file := name + saveExtension
+	return fmt.Sprintf("`%s` is too large to send in one piece, so it is split into parts. Once you have them all, "+
+		"join them with `copy /b \"%s.001\"+\"%s.002\"... \"%s\"` on Windows or `cat \"%s\".* > \"%s\"` on Linux and "+
+		"macOS.", name, file, file, file, file, file)
+}
+
+// sendParts reads the save in parts of at most size bytes and calls send with each one as it is read, so only one
+// part is held in memory. The part is named after the save when it is the only one, and is numbered when the save is
+// split. It returns how many parts were sent.
+func sendParts(save io.Reader, name string, size int, send func(file *discordgo.File, part int, split bool) error) (int, error) {
+	r := bufio.NewReader(io.LimitReader(save, maxSaveSize+1))
+	buf := make([]byte, size)
+	total := 0
+	for part := 1; ; part++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return part - 1, fmt.Errorf("read save: %w", err)
+		}
+
+		total += n
+		if total > maxSaveSize {
+			return part - 1, errSaveTooLarge
+		}
+
+		// Peek ahead to tell whether this is the last part.
+		_, err = r.Peek(1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return part - 1, fmt.Errorf("read save: %w", err)
+		}
+		last := err != nil
+		split := part > 1 || !last
+
+		file := &discordgo.File{
+			Name:        name,
+			ContentType: "application/octet-stream",
+			Reader:      bytes.NewReader(buf[:n]),
+		}
+		if split {
+			file.Name = fmt.Sprintf("%s.%03d", name, part)
+		}
+
+		if err := send(file, part, split); err != nil {
+			return part - 1, err
+		} else if last {
+			return part, nil
+		}
+	}
+}
+
+func (s *service) uploadSave(ctx context.Context, i *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	var attachment *discordgo.MessageAttachment
+	if resolved := i.ApplicationCommandData().Resolved; resolved != nil {
+		attachment = resolved.Attachments[options[fileOption]]
+	}
+	if attachment == nil {
+		return "", errors.New("attach the save to upload")
+	}
+
+	ext := filepath.Ext(attachment.Filename)
+	if !strings.EqualFold(ext, saveExtension) {
+		return "", fmt.Errorf("%s is not a save, saves end in %s", attachment.Filename, saveExtension)
+	} else if attachment.Size > maxSaveSize {
+		return "", errSaveTooLarge
+	}
+
+	name := options[nameOption]
+	if name == "" {
+		name = strings.TrimSuffix(attachment.Filename, ext)
+	}
+	name, err := validName(name)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, attachment.URL, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("create attachment request: %w", err)
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download attachment: %w", err)
+	}
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			slog.Error("Error closing attachment body", slog.String(logging.KeyError, err.Error()))
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download attachment: status %d", res.StatusCode)
+	}
+
+	load := options[loadOption] == "true"
+	if err := s.serverAPI.UploadSaveGame(ctx, name, io.LimitReader(res.Body, maxSaveSize), load); err != nil {
+		return "", fmt.Errorf("upload save: %w", err)
+	}
+
+	if load {
+		return fmt.Sprintf("Uploaded `%s` and loaded it, everyone playing will reconnect", name), nil
+	}
+	return fmt.Sprintf("Uploaded `%s`", name), nil
+}
+
+// saveChoices suggests the saves starting with the value, from the session given to the command when there is one.
+func (s *service) saveChoices(ctx context.Context, i *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+	if s.serverAPI == nil {
+		return nil, nil
+	}
+
+	sessions, err := s.serverAPI.EnumerateSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("enumerate sessions: %w", err)
+	}
+
+	session := optionValues(i)[sessionOption]
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0)
+	for _, sess := range sessions {
+		if session != "" && sess.Name != session {
+			continue
+		}
+
+		for _, save := range sess.Saves {
+			if strings.HasPrefix(strings.ToLower(save.Name), strings.ToLower(value)) {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  save.Name,
+					Value: save.Name,
+				})
+			}
+		}
+	}
+
+	return choices, nil
+}
+
+// loadSave loads the save, saving the game being played first so loading the wrong save loses nothing.
+func (s *service) loadSave(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	name, err := validName(options[saveOption])
+	if err != nil {
+		return "", err
+	}
+
+	backup := "bot_load_" + time.Now().UTC().Format("20060102_150405")
+	if err := s.serverAPI.SaveGame(ctx, backup); err != nil {
+		return "", fmt.Errorf("save game before loading: %w", err)
+	}
+
+	if err := s.serverAPI.LoadGame(ctx, name); err != nil {
+		return "", fmt.Errorf("load save: %w", err)
+	}
+
+	return fmt.Sprintf("Loading `%s`, everyone playing will reconnect. The game was saved as `%s` first", name, backup), nil
+}
+
+func (s *service) deleteSave(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	name, err := validName(options[saveOption])
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.serverAPI.DeleteSaveFile(ctx, name); err != nil {
+		return "", fmt.Errorf("delete save: %w", err)
+	}
+
+	return fmt.Sprintf("Deleted `%s`", name), nil
+}
@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+// sentParts returns the names and contents of the parts the save is sent in.
+func sentParts(t *testing.T, save string, size int) ([]string, []string) {
+	names := make([]string, 0)
+	contents := make([]string, 0)
+	parts, err := sendParts(strings.NewReader(save), "Factory.sav", size, func(file *discordgo.File, part int, split bool) error {
+		require.Equal(t, len(names)+1, part)
+		require.Equal(t, size < len(save), split)
+
+		got, err := io.ReadAll(file.Reader)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(got), size)
+
+		names = append(names, file.Name)
+		contents = append(contents, string(got))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, len(names), parts)
+	return names, contents
+}
+
+func TestSendParts(t *testing.T) {
+	names, contents := sentParts(t, "0123456789", 10)
+	require.Equal(t, []string{"Factory.sav"}, names)
+	require.Equal(t, []string{"0123456789"}, contents)
+
+	names, contents = sentParts(t, "0123456789", 4)
+	require.Equal(t, []string{"Factory.sav.001", "Factory.sav.002", "Factory.sav.003"}, names)
+	require.Equal(t, "0123456789", strings.Join(contents, ""))
+
+	names, _ = sentParts(t, "01234567", 4)
+	require.Equal(t, []string{"Factory.sav.001", "Factory.sav.002"}, names, "no empty part after an exact multiple")
+}
+
+func TestSendParts_Failed(t *testing.T) {
+	parts, err := sendParts(strings.NewReader("0123456789"), "Factory.sav", 4, func(_ *discordgo.File, part int, _ bool) error {
+		if part == 2 {
+			return errors.New("cannot send messages to this user")
+		}
+		return nil
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, parts, "only the parts sent must be counted")
+}
+
+func TestService_DownloadSave(t *testing.T) {
+	s, api := testService(WithMaxAttachmentSize(6))
+	api.files["Factory_autosave_0"] = []byte("0123456789")
+
+	name, save, err := s.downloadSave(context.Background(), " Factory_autosave_0 ")
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, save.Close()) })
+	require.Equal(t, "Factory_autosave_0", name)
+
+	parts, err := sendParts(save, name+saveExtension, s.maxAttachmentSize, func(*discordgo.File, int, bool) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, parts)
+
+	_, _, err = s.downloadSave(context.Background(), "Missing")
+	require.ErrorContains(t, err, "file_not_found")
+}
+
+// attachmentInteraction returns an interaction uploading the attachment served at the URL.
+func attachmentInteraction(filename, url string) *discordgo.InteractionCreate {
+	i := operator()
+	i.Type = discordgo.InteractionApplicationCommand
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name: savesCmdID,
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+			Attachments: map[string]*discordgo.MessageAttachment{
+				"a1": {ID: "a1", Filename: filename, URL: url, Size: 13},
+			},
+		},
+	}
+	return i
+}
+
+func TestService_UploadSave(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("save contents"))
+	}))
+	t.Cleanup(cdn.Close)
+
+	s, api := testService()
+
+	msg, err := s.uploadSave(context.Background(), attachmentInteraction("Single Player.sav", cdn.URL), map[string]string{
+		fileOption: "a1",
+		loadOption: "true",
+	})
+	require.NoError(t, err)
+	require.Contains(t, msg, "loaded")
+	require.Equal(t, "save contents", string(api.files["Single Player"]))
+	require.Equal(t, "Single Player", api.loaded)
+
+	_, err = s.uploadSave(context.Background(), attachmentInteraction("Single Player.sav", cdn.URL), map[string]string{
+		fileOption: "a1",
+		nameOption: "Imported",
+	})
+	require.NoError(t, err)
+	require.Contains(t, api.files, "Imported")
+	require.Equal(t, "Single Player", api.loaded, "the save must only be loaded when asked")
+
+	_, err = s.uploadSave(context.Background(), attachmentInteraction("notes.txt", cdn.URL), map[string]string{fileOption: "a1"})
+	require.Error(t, err)
+}
+
+func TestService_SaveChoices(t *testing.T) {
+	s, api := testService()
+	api.sessions = []*satisfactory.Session{
+		{Name: "Factory", Saves: []*satisfactory.SaveHeader{{Name: "Factory_autosave_0"}, {Name: "Factory_manual"}}},
+		{Name: "Farm", Saves: []*satisfactory.SaveHeader{{Name: "Farm_autosave_0"}}},
+	}
+
+	i := commandInteraction(savesCmdID, &discordgo.ApplicationCommandInteractionDataOption{
+		Name: savesDownloadSubCmd,
+		Type: discordgo.ApplicationCommandOptionSubCommand,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: sessionOption, Type: discordgo.ApplicationCommandOptionString, Value: "Factory"},
+		},
+	})
+
+	choices, err := s.saveChoices(context.Background(), i, "factory_a")
+	require.NoError(t, err)
+	require.Len(t, choices, 1)
+	require.Equal(t, "Factory_autosave_0", choices[0].Value)
+}
+
+func TestSavePages(t *testing.T) {
+	sessions := []*satisfactory.Session{
+		{Name: "Factory", Saves: []*satisfactory.SaveHeader{
+			{Name: "Factory_autosave_0", PlayDurationSeconds: 3600, SaveDateTime: "2024.09.10-13.52.24"},
+			{Name: "Factory_autosave_1", PlayDurationSeconds: 7500, SaveDateTime: "2024.09.11-08.00.00"},
+		}},
+		{Name: "Empty"},
+		{Name: "Farm", Saves: []*satisfactory.SaveHeader{{Name: "Farm_autosave_0", SaveDateTime: "unknown"}}},
+	}
+
+	pages := savePages(sessions, "")
+	require.Equal(t, []string{"**Factory**\n" +
+		"- `Factory_autosave_1` · 2h 05m played · saved <t:1726041600:f>\n" +
+		"- `Factory_autosave_0` · 1h 00m played · saved <t:1725976344:f>\n" +
+		"**Farm**\n" +
+		"- `Farm_autosave_0` · 0h 00m played · saved unknown"}, pages)
+
+	pages = savePages(sessions, "Farm")
+	require.Len(t, pages, 1)
+	require.NotContains(t, pages[0], "Factory")
+
+	require.Empty(t, savePages(sessions, "Empty"))
+}
+
+func TestService_SavesPage(t *testing.T) {
+	s, api := testService()
+	saves := make([]*satisfactory.SaveHeader, 100)
+	for n := range saves {
+		saves[n] = &satisfactory.SaveHeader{Name: fmt.Sprintf("Factory_%03d", n), SaveDateTime: "2024.09.10-13.52.24"}
+	}
+	api.sessions = []*satisfactory.Session{{Name: "Factory", Saves: saves}}
+
+	content, components, err := s.savesPage(context.Background(), "Factory", 1)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(content), maxMessageLength)
+	require.Contains(t, content, "Page 2 of")
+
+	buttons := components[0].(discordgo.ActionsRow).Components
+	require.Equal(t, "saves-page:0:Factory", buttons[0].(discordgo.Button).CustomID)
+	require.Equal(t, "saves-page:2:Factory", buttons[1].(discordgo.Button).CustomID)
+
+	content, components, err = s.savesPage(context.Background(), "Farm", 0)
+	require.NoError(t, err)
+	require.Equal(t, "There are no saves in `Farm`", content)
+	require.Empty(t, components)
+}
+
+func TestService_LoadSave(t *testing.T) {
+	s, api := testService()
+
+	msg, err := s.serverAdmin(s.loadSave)(context.Background(), operator(), map[string]string{saveOption: "Factory_autosave_0"})
+	require.NoError(t, err)
+	require.Equal(t, "Factory_autosave_0", api.loaded)
+
+	// The game being played is saved before it is replaced.
+	saves := api.saves
+	require.Len(t, saves, 1)
+	require.True(t, strings.HasPrefix(saves[0], "bot_load_"))
+	require.Contains(t, msg, saves[0])
+
+	api.loaded = ""
+	api.err = errors.New("disk full")
+	_, err = s.serverAdmin(s.loadSave)(context.Background(), operator(), map[string]string{saveOption: "Factory_autosave_1"})
+	require.ErrorContains(t, err, "disk full")
+	require.Empty(t, api.loaded, "the save must not be loaded when the game wasn't saved")
+}
+
+func TestService_DeleteSave(t *testing.T) {
+	s, api := testService()
+
+	_, err := s.serverAdmin(s.deleteSave)(context.Background(), memberInteraction("g1", "2", "player"), map[string]string{saveOption: "Factory_autosave_0"})
+	require.ErrorIs(t, err, errAccessDenied)
+	require.Empty(t, api.deleted)
+
+	msg, err := s.serverAdmin(s.deleteSave)(context.Background(), operator(), map[string]string{saveOption: "Factory_autosave_0"})
+	require.NoError(t, err)
+	require.Equal(t, "Deleted `Factory_autosave_0`", msg)
+	require.Equal(t, []string{"Factory_autosave_0"}, api.deleted)
+}
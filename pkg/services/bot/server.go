@@ -2,139 +2,358 @@ package bot
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Jacobbrewer1/goredis"
 	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
 	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
 	"github.com/bwmarrin/discordgo"
 	redisgo "github.com/gomodule/redigo/redis"
 )
 
-func (s *service) onServerInfo(_ *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Respond to the user with "Just getting the server info"
-	err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+const (
+	// statusRefreshComponentID prefixes the custom ID of the button refreshing the response to a status command. The
+	// argument is the name of the command.
+	statusRefreshComponentID = "status-refresh"
+
+	// containerStateRunning is the docker state of a running container.
+	containerStateRunning = "running"
+)
+
+// The colours of the status embeds.
+const (
+	colourUp      = 0x2ecc71
+	colourWaiting = 0xf1c40f
+	colourDown    = 0xe74c3c
+	colourUnknown = 0x95a5a6
+)
+
+// statusView renders the response to a status command.
+type statusView func(ctx context.Context) (*discordgo.MessageEmbed, error)
+
+// serverStatus is the state of the server recorded by the watcher, as the status commands and panel show it.
+type serverStatus struct {
+	// State is the state of the container, such as running.
+	State string
+
+	// Status is the status of the container as docker describes it, such as "Up 3 hours".
+	Status string
+
+	// RunningFor is how long ago the container was created, as docker describes it.
+	RunningFor string
+
+	// Uptime is how long the container has been up, as docker describes it. It is empty when the container is down.
+	Uptime string
+
+	// Build is the build of the server. It is 0 when the build hasn't been seen.
+	Build int
+
+	// Running is true when a session is loaded.
+	Running bool
+
+	// Paused is true when the game is paused, such as when nobody is playing.
+	Paused bool
+
+	Session     string
+	Players     int
+	PlayerLimit int
+	TechTier    int
+
+	// GameDuration is how long the session has been played for.
+	GameDuration time.Duration
+
+	// TickRate is the average tick rate, rounded to one decimal place so small changes don't edit the panel.
+	TickRate float64
+
+	// Info and Details are how fresh the docker info and the server details are.
+	Info    *state.Freshness
+	Details *state.Freshness
+}
+
+// getServerStatus returns the state of the server recorded by the watcher.
+func getServerStatus(ctx context.Context) (*serverStatus, error) {
+	info, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", state.SourceDockerInfo))
 	if err != nil {
-		slog.Error("Error responding to server info", slog.String(logging.KeyError, err.Error()))
-		return
+		return nil, fmt.Errorf("get server info: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	details, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", state.SourceServerDetails))
+	if err != nil {
+		return nil, fmt.Errorf("get server details: %w", err)
+	}
 
-	// Get the server info from redis
-	serverInfo, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", "docker_info"))
+	infoFresh, err := state.GetFreshness(ctx, state.SourceDockerInfo)
 	if err != nil {
-		slog.Error("Error getting server info", slog.String(logging.KeyError, err.Error()))
-		return
+		return nil, fmt.Errorf("get server info freshness: %w", err)
+	}
+
+	detailsFresh, err := state.GetFreshness(ctx, state.SourceServerDetails)
+	if err != nil {
+		return nil, fmt.Errorf("get server details freshness: %w", err)
 	}
 
-	// Send the server info to the user
-	msg := "State: " + serverInfo["State"] + "\n" +
-		"RunningFor: " + serverInfo["RunningFor"] + "\n" +
-		"Status: " + serverInfo["Status"]
+	st := parseServerStatus(info, details, infoFresh, detailsFresh)
 
-	_, err = s.s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: utils.Ptr(msg),
-	})
+	version, err := state.GetServerVersion(ctx)
 	if err != nil {
-		slog.Error("Error deleting server info", slog.String(logging.KeyError, err.Error()))
-		return
+		return nil, fmt.Errorf("get server version: %w", err)
+	} else if version != nil {
+		st.Build = version.Build
 	}
+
+	return st, nil
 }
 
-func (s *service) onServerCredentials(_ *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Respond to the user with "Just getting the server credentials"
-	err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
-	if err != nil {
-		slog.Error("Error responding to server credentials", slog.String(logging.KeyError, err.Error()))
-		return
+// parseServerStatus returns the state of the server from the docker info and server details hashes. Missing or
+// invalid values are left empty.
+func parseServerStatus(info, details map[string]string, infoFresh, detailsFresh *state.Freshness) *serverStatus {
+	st := &serverStatus{
+		State:      info["State"],
+		Status:     info["Status"],
+		RunningFor: info["RunningFor"],
+		Session:    details["ActiveSessionName"],
+		Info:       infoFresh,
+		Details:    detailsFresh,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	if uptime, ok := strings.CutPrefix(st.Status, "Up "); ok {
+		st.Uptime = uptime
+	}
 
-	// Get the server credentials from redis
-	serverCredentials, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", "server_credentials"))
-	if err != nil {
-		slog.Error("Error getting server credentials", slog.String(logging.KeyError, err.Error()))
-		return
+	st.Running, _ = strconv.ParseBool(details["IsGameRunning"])
+	st.Paused, _ = strconv.ParseBool(details["IsGamePaused"])
+	st.Players, _ = strconv.Atoi(details["NumConnectedPlayers"])
+	st.PlayerLimit, _ = strconv.Atoi(details["PlayerLimit"])
+	st.TechTier, _ = strconv.Atoi(details["TechTier"])
+
+	// The server reports the duration in seconds.
+	if seconds, err := strconv.Atoi(details["TotalGameDuration"]); err == nil {
+		st.GameDuration = time.Duration(seconds) * time.Second
 	}
 
-	// Send the server credentials to the user
-	msg := "IP: " + serverCredentials["ip"] + "\n" +
-		"Port: " + serverCredentials["port"] + "\n" +
-		"Password: " + serverCredentials["password"]
+	if tickRate, err := strconv.ParseFloat(details["AverageTickRate"], 64); err == nil {
+		st.TickRate = math.Round(tickRate*10) / 10
+	}
 
-	_, err = s.s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: utils.Ptr(msg),
-	})
+	return st
+}
+
+// gameState describes whether the game is running.
+func (st *serverStatus) gameState() string {
+	switch {
+	case st.State != containerStateRunning:
+		if st.State == "" {
+			return "unknown"
+		}
+		return st.State
+	case !st.Running:
+		return "starting"
+	case st.Paused:
+		return "paused"
+	default:
+		return "running"
+	}
+}
+
+// colour returns the colour of the embeds showing the state of the game.
+func (st *serverStatus) colour() int {
+	if st.Details.Stale {
+		return colourUnknown
+	}
+
+	switch st.gameState() {
+	case "running":
+		return colourUp
+	case "starting", "paused":
+		return colourWaiting
+	case "unknown":
+		return colourUnknown
+	default:
+		return colourDown
+	}
+}
+
+// infoColour returns the colour of the embeds showing the state of the container.
+func (st *serverStatus) infoColour() int {
+	switch {
+	case st.Info.Stale || st.State == "":
+		return colourUnknown
+	case st.State == containerStateRunning:
+		return colourUp
+	default:
+		return colourDown
+	}
+}
+
+func (st *serverStatus) infoEmbed() *discordgo.MessageEmbed {
+	build := "unknown"
+	if st.Build != 0 {
+		build = strconv.Itoa(st.Build)
+	}
+
+	return statusEmbed("Server info", st.infoColour(), []*discordgo.MessageEmbedField{
+		{Name: "State", Value: valueOrDash(st.State), Inline: true},
+		{Name: "Running for", Value: valueOrDash(st.RunningFor), Inline: true},
+		{Name: "Status", Value: valueOrDash(st.Status), Inline: true},
+		{Name: "Version", Value: build, Inline: true},
+	}, st.Info)
+}
+
+func (st *serverStatus) detailsEmbed() *discordgo.MessageEmbed {
+	return statusEmbed("Server details", st.colour(), []*discordgo.MessageEmbedField{
+		{Name: "State", Value: st.gameState(), Inline: true},
+		{Name: "Session", Value: valueOrDash(st.Session), Inline: true},
+		{Name: "Play time", Value: formatPlayTime(st.GameDuration), Inline: true},
+		{Name: "Players", Value: fmt.Sprintf("%d / %d", st.Players, st.PlayerLimit), Inline: true},
+		{Name: "Tech tier", Value: strconv.Itoa(st.TechTier), Inline: true},
+		{Name: "Tick rate", Value: strconv.FormatFloat(st.TickRate, 'f', 1, 64), Inline: true},
+	}, st.Details)
+}
+
+// statusEmbed returns an embed showing the fields, with when the data they show was last updated.
+func statusEmbed(title string, colour int, fields []*discordgo.MessageEmbedField, fresh *state.Freshness) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:  title,
+		Color:  colour,
+		Fields: append(fields, &discordgo.MessageEmbedField{Name: "Last updated", Value: lastUpdated(fresh)}),
+	}
+}
+
+// valueOrDash returns the value, or a dash when it is empty. Discord rejects embed fields without a value.
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func (s *service) serverInfoView(ctx context.Context) (*discordgo.MessageEmbed, error) {
+	st, err := getServerStatus(ctx)
 	if err != nil {
-		slog.Error("Error deleting server credentials", slog.String(logging.KeyError, err.Error()))
-		return
+		return nil, err
 	}
+	return st.infoEmbed(), nil
 }
 
-func (s *service) onServerDetails(_ *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Respond to the user with "Just getting the server details"
-	err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-		},
-	})
+func (s *service) serverDetailsView(ctx context.Context) (*discordgo.MessageEmbed, error) {
+	st, err := getServerStatus(ctx)
 	if err != nil {
-		slog.Error("Error responding to server details", slog.String(logging.KeyError, err.Error()))
-		return
+		return nil, err
 	}
+	return st.detailsEmbed(), nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// statusViews returns the views of the status commands, keyed by the name of the command.
+func (s *service) statusViews() map[string]statusView {
+	return map[string]statusView{
+		serverInfoCmdID:        s.serverInfoView,
+		severDetailsCmdID:      s.serverDetailsView,
+		serverCredentialsCmdID: s.credentialsView,
+		playersCmdID:           s.playersView,
+	}
+}
 
-	// Get the server details from redis
-	serverDetails, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", "server_details"))
-	if err != nil {
-		slog.Error("Error getting server details", slog.String(logging.KeyError, err.Error()))
+// onStatusCommand returns the handler of the status command, responding with its view and a button to refresh it.
+// The response is only shown to the user unless they ask for it to be public.
+func (s *service) onStatusCommand(name string) handlerFunc {
+	return func(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+		data := new(discordgo.InteractionResponseData)
+		if public, _ := strconv.ParseBool(optionValues(i)[publicOption]); !public {
+			data.Flags = discordgo.MessageFlagsEphemeral
+		}
+
+		err := s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: data,
+		})
+		if err != nil {
+			slog.Error("Error responding to status command", slog.String("command", name), slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultCommandTimeout)
+		defer cancel()
+
+		edit := new(discordgo.WebhookEdit)
+		embed, components, err := s.renderStatus(ctx, name)
+		if err != nil {
+			slog.Error("Error rendering status", slog.String("command", name), slog.String(logging.KeyError, err.Error()))
+			edit.Content = utils.Ptr("Error: " + err.Error())
+		} else {
+			edit.Embeds = &[]*discordgo.MessageEmbed{embed}
+			edit.Components = &components
+		}
+
+		if _, err := s.s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+			slog.Error("Error editing status response", slog.String("command", name), slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+func (s *service) onStatusRefresh(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := componentArg(i)
+	if !refreshable(name) {
+		s.respondEphemeral(i, fmt.Sprintf("Error: %s can't be refreshed, use the command again", name))
 		return
 	}
 
-	duration, err := time.ParseDuration(serverDetails["TotalGameDuration"])
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCommandTimeout)
+	defer cancel()
+
+	data := new(discordgo.InteractionResponseData)
+	embed, components, err := s.renderStatus(ctx, name)
 	if err != nil {
-		slog.Error("Error parsing total game duration", slog.String(logging.KeyError, err.Error()))
-		duration = 0
+		slog.Error("Error rendering status", slog.String("command", name), slog.String(logging.KeyError, err.Error()))
+		data.Content = "Error: " + err.Error()
+	} else {
+		data.Embeds = []*discordgo.MessageEmbed{embed}
+		data.Components = components
 	}
 
-	running, err := strconv.ParseBool(serverDetails["IsGameRunning"])
+	err = s.s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	})
 	if err != nil {
-		slog.Error("Error parsing is game running", slog.String(logging.KeyError, err.Error()))
-		running = false
+		slog.Error("Error responding to status refresh", slog.String(logging.KeyError, err.Error()))
 	}
+}
 
-	// Send the server details to the user
-	msg := "Tech Tier: " + serverDetails["TechTier"] + "\n" +
-		"Active Session Name: " + serverDetails["ActiveSessionName"] + "\n" +
-		"Total Game Duration: " + duration.String() + "\n" +
-		"Players Connected: " + serverDetails["NumConnectedPlayers"] + "\n" +
-		"Player Limit: " + serverDetails["PlayerLimit"] + "\n" +
-		"Game Running: " + strconv.FormatBool(running)
+// refreshable returns true if the response to the status command has a button to refresh it. The button skips the
+// access checks and the audit of the command, so the sensitive credentials can only be shown by the command.
+func refreshable(name string) bool {
+	return name != serverCredentialsCmdID
+}
 
-	_, err = s.s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: utils.Ptr(msg),
-	})
+// renderStatus renders the view of the status command, with the button to refresh it when it can be refreshed.
+func (s *service) renderStatus(ctx context.Context, name string) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	view, ok := s.statusViews()[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown status command: %s", name)
+	}
+
+	embed, err := view(ctx)
 	if err != nil {
-		slog.Error("Error deleting server details", slog.String(logging.KeyError, err.Error()))
-		return
+		return nil, nil, err
+	} else if !refreshable(name) {
+		return embed, []discordgo.MessageComponent{}, nil
 	}
+
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Refresh",
+					Style:    discordgo.SecondaryButton,
+					CustomID: statusRefreshComponentID + ":" + name,
+				},
+			},
+		},
+	}, nil
 }
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerStatus(t *testing.T) {
+	info := &state.Freshness{Source: state.SourceDockerInfo}
+	details := &state.Freshness{Source: state.SourceServerDetails, LastSeen: time.Unix(1725976344, 0)}
+	st := parseServerStatus(
+		map[string]string{"State": "running", "Status": "Up 3 hours (healthy)", "RunningFor": "2 days ago"},
+		map[string]string{
+			"ActiveSessionName":   "Factory",
+			"IsGameRunning":       "true",
+			"IsGamePaused":        "false",
+			"NumConnectedPlayers": "2",
+			"PlayerLimit":         "4",
+			"TechTier":            "5",
+			"TotalGameDuration":   "93784",
+			"AverageTickRate":     "29.96",
+		},
+		info,
+		details,
+	)
+
+	require.Equal(t, &serverStatus{
+		State:        "running",
+		Status:       "Up 3 hours (healthy)",
+		RunningFor:   "2 days ago",
+		Uptime:       "3 hours (healthy)",
+		Running:      true,
+		Session:      "Factory",
+		Players:      2,
+		PlayerLimit:  4,
+		TechTier:     5,
+		GameDuration: 26*time.Hour + 3*time.Minute + 4*time.Second,
+		TickRate:     30,
+		Info:         info,
+		Details:      details,
+	}, st)
+	require.Equal(t, "running", st.gameState())
+
+	stopped := parseServerStatus(map[string]string{"State": "exited", "Status": "Exited (0) 3 minutes ago"}, nil, info, details)
+	require.Equal(t, "exited", stopped.gameState())
+	require.Empty(t, stopped.Uptime)
+}
+
+func TestServerStatus_Embeds(t *testing.T) {
+	st := &serverStatus{
+		State:        "running",
+		Status:       "Up 3 hours",
+		Running:      true,
+		Paused:       true,
+		Session:      "Factory",
+		GameDuration: 26*time.Hour + 3*time.Minute,
+		Build:        365306,
+		Info:         &state.Freshness{Stale: true, LastSeen: time.Unix(1725976344, 0)},
+		Details:      new(state.Freshness),
+	}
+
+	info := st.infoEmbed()
+	require.Equal(t, colourUnknown, info.Color)
+	require.Equal(t, "365306", info.Fields[3].Value)
+	require.Equal(t, "⚠️ <t:1725976344:R>, the data is stale", info.Fields[4].Value)
+
+	details := st.detailsEmbed()
+	require.Equal(t, colourWaiting, details.Color)
+	require.Equal(t, "paused", details.Fields[0].Value)
+	require.Equal(t, "26h 03m", details.Fields[2].Value)
+	require.Equal(t, "never", details.Fields[6].Value)
+
+	// Discord rejects embed fields without a value.
+	st = &serverStatus{Info: new(state.Freshness), Details: new(state.Freshness)}
+	for _, embed := range []*discordgo.MessageEmbed{st.infoEmbed(), st.detailsEmbed(), st.panelEmbed(), credentialsEmbed("", "", "")} {
+		for _, field := range embed.Fields {
+			require.NotEmpty(t, field.Value, field.Name)
+		}
+	}
+}
+
+func TestService_RenderStatus(t *testing.T) {
+	s := NewService("token").(*service)
+
+	_, _, err := s.renderStatus(context.Background(), "unknown")
+	require.Error(t, err)
+}
+
+func TestService_OnStatusRefreshCredentials(t *testing.T) {
+	session, discord := newFakeDiscord(t)
+	s := NewService("token").(*service)
+	s.s = session
+
+	// The credentials are only shown through the command, which checks who is asking and records it.
+	s.onStatusRefresh(nil, buttonInteraction("1", statusRefreshComponentID+":"+serverCredentialsCmdID))
+
+	resp := discord.sent()[0].response(t)
+	require.Equal(t, discordgo.InteractionResponseChannelMessageWithSource, resp.Type)
+	require.Equal(t, "Error: server-credentials can't be refreshed, use the command again", resp.Data.Content)
+	require.Empty(t, resp.Data.Embeds)
+	require.False(t, refreshable(serverCredentialsCmdID))
+	require.True(t, refreshable(serverInfoCmdID))
+}
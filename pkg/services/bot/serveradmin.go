@@ -0,0 +1,283 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// serverAPITimeout is how long a command calling the server API can take. Saving a large world takes a while.
+	serverAPITimeout = time.Minute
+
+	// maxNameLength is the longest save or session name that can be given.
+	maxNameLength = 64
+
+	// maxServerNameLength is the longest server name the server API accepts.
+	maxServerNameLength = 64
+)
+
+// errServerAPINotConfigured is returned when the bot can't call the server API.
+var errServerAPINotConfigured = errors.New("the server API is not configured")
+
+// errAccessDenied is returned when the user can't use a command.
+var errAccessDenied = errors.New("you don't have access to this command")
+
+// networkQualities are the labels of the network quality option values.
+var networkQualities = []string{"Low", "Medium", "High", "Ultra"}
+
+// serverOptionLabels are the server options shown to the user, in order, with their labels.
+var serverOptionLabels = []struct {
+	name  string
+	label string
+}{
+	{name: satisfactory.OptionAutosaveInterval, label: "Autosave interval"},
+	{name: satisfactory.OptionNetworkQuality, label: "Network quality"},
+	{name: satisfactory.OptionAutoPause, label: "Pause when empty"},
+	{name: satisfactory.OptionAutoSaveOnDisconnect, label: "Save when a player leaves"},
+	{name: satisfactory.OptionServerRestartTimeSlot, label: "Restart time slot"},
+	{name: satisfactory.OptionSendGameplayData, label: "Send gameplay data"},
+}
+
+// serverAdmin wraps a command calling the server API, checking the user can control the server first.
+func (s *service) serverAdmin(fn ephemeralCommand) ephemeralCommand {
+	return func(ctx context.Context, i *discordgo.InteractionCreate, options map[string]string) (string, error) {
+		if !s.canControlServer(i) {
+			return "", errAccessDenied
+		} else if s.serverAPI == nil {
+			return "", errServerAPINotConfigured
+		}
+		return fn(ctx, i, options)
+	}
+}
+
+func (s *service) saveGame(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	name, err := validName(options[nameOption])
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.serverAPI.SaveGame(ctx, name); err != nil {
+		return "", fmt.Errorf("save game: %w", err)
+	}
+
+	return fmt.Sprintf("Game saved as `%s`", name), nil
+}
+
+func (s *service) serverOptions(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	updates, err := serverOptionUpdates(options)
+	if err != nil {
+		return "", err
+	}
+
+	if len(updates) > 0 {
+		if err := s.serverAPI.ApplyServerOptions(ctx, updates); err != nil {
+			return "", fmt.Errorf("apply server options: %w", err)
+		}
+	}
+
+	current, err := s.serverAPI.GetServerOptions(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get server options: %w", err)
+	}
+
+	sb := new(strings.Builder)
+	if len(updates) > 0 {
+		sb.WriteString("Server options applied.\n")
+	}
+	sb.WriteString(formatServerOptions(current))
+
+	return sb.String(), nil
+}
+
+func (s *service) renameServer(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	name, err := validServerName(options[nameOption])
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.serverAPI.RenameServer(ctx, name); err != nil {
+		return "", fmt.Errorf("rename server: %w", err)
+	}
+
+	return fmt.Sprintf("Server renamed to `%s`", name), nil
+}
+
+func (s *service) setAutoload(ctx context.Context, _ *discordgo.InteractionCreate, options map[string]string) (string, error) {
+	session, err := validName(options[sessionOption])
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.serverAPI.SetAutoLoadSessionName(ctx, session); err != nil {
+		return "", fmt.Errorf("set autoload session: %w", err)
+	}
+
+	return fmt.Sprintf("The server will load `%s` when it starts", session), nil
+}
+
+// sessionChoices suggests the sessions saved on the server starting with the value, to members that can control the
+// server.
+func (s *service) sessionChoices(ctx context.Context, i *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+	if !s.canControlServer(i) {
+		return nil, nil
+	}
+	return s.anySessionChoices(ctx, i, value)
+}
+
+// anySessionChoices suggests the sessions saved on the server starting with the value, to anyone.
+func (s *service) anySessionChoices(ctx context.Context, _ *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+	if s.serverAPI == nil {
+		return nil, nil
+	}
+
+	sessions, err := s.serverAPI.EnumerateSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("enumerate sessions: %w", err)
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0)
+	for _, session := range sessions {
+		if strings.HasPrefix(strings.ToLower(session.Name), strings.ToLower(value)) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  session.Name,
+				Value: session.Name,
+			})
+		}
+	}
+
+	return choices, nil
+}
+
+// serverOptionUpdates returns the server options to apply from the options given to the command.
+func serverOptionUpdates(options map[string]string) (map[string]string, error) {
+	updates := make(map[string]string)
+
+	if got, ok := options[autosaveMinutesOption]; ok {
+		minutes, err := strconv.Atoi(got)
+		if err != nil || minutes < 0 {
+			return nil, fmt.Errorf("%s must be a number of minutes, or 0 to turn autosave off", autosaveMinutesOption)
+		}
+		updates[satisfactory.OptionAutosaveInterval] = strconv.Itoa(minutes * 60)
+	}
+
+	if got, ok := options[networkQualityOption]; ok {
+		quality, err := strconv.Atoi(got)
+		if err != nil || quality < 0 || quality >= len(networkQualities) {
+			return nil, fmt.Errorf("%s must be between 0 and %d", networkQualityOption, len(networkQualities)-1)
+		}
+		updates[satisfactory.OptionNetworkQuality] = strconv.Itoa(quality)
+	}
+
+	for option, name := range map[string]string{
+		autoPauseOption:        satisfactory.OptionAutoPause,
+		saveOnLeaveOption:      satisfactory.OptionAutoSaveOnDisconnect,
+		sendGameplayDataOption: satisfactory.OptionSendGameplayData,
+	} {
+		got, ok := options[option]
+		if !ok {
+			continue
+		}
+
+		b, err := strconv.ParseBool(got)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", option)
+		}
+		updates[name] = serverBool(b)
+	}
+
+	return updates, nil
+}
+
+// formatServerOptions describes the server options, and the changes waiting for the session to restart.
+func formatServerOptions(options *satisfactory.ServerOptions) string {
+	sb := new(strings.Builder)
+	sb.WriteString("Server options:")
+	for _, o := range serverOptionLabels {
+		value, ok := options.Current[o.name]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(sb, "\n- %s: %s", o.label, formatServerOption(o.name, value))
+		if pending, ok := options.Pending[o.name]; ok && pending != value {
+			fmt.Fprintf(sb, " (%s after the session restarts)", formatServerOption(o.name, pending))
+		}
+	}
+
+	return sb.String()
+}
+
+// formatServerOption returns the value of the server option in a form people read.
+func formatServerOption(name, value string) string {
+	switch name {
+	case satisfactory.OptionAutosaveInterval:
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return value
+		} else if seconds <= 0 {
+			return "off"
+		}
+		return (time.Duration(seconds) * time.Second).String()
+	case satisfactory.OptionNetworkQuality:
+		quality, err := strconv.Atoi(value)
+		if err != nil || quality < 0 || quality >= len(networkQualities) {
+			return value
+		}
+		return networkQualities[quality]
+	default:
+		if b, err := strconv.ParseBool(strings.ToLower(value)); err == nil {
+			if b {
+				return "yes"
+			}
+			return "no"
+		}
+		return value
+	}
+}
+
+// serverBool returns the boolean as the server writes it.
+func serverBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
+
+// validName returns the save or session name, checking the server can use it as a file name.
+func validName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", errors.New("name must not be empty")
+	case len(name) > maxNameLength:
+		return "", fmt.Errorf("name must be at most %d characters", maxNameLength)
+	case strings.ContainsAny(name, `/\:*?"<>|`) || slices.Contains([]string{".", ".."}, name):
+		return "", fmt.Errorf("name %q contains characters that can't be in a file name", name)
+	}
+	return name, nil
+}
+
+// validServerName returns the server name, checking the server API will accept it. Unlike save and
+// session names it is not a file name, so it may contain any printable characters.
+func validServerName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", errors.New("server name must not be empty")
+	case utf8.RuneCountInString(name) > maxServerNameLength:
+		return "", fmt.Errorf("server name must be at most %d characters", maxServerNameLength)
+	case strings.ContainsFunc(name, func(r rune) bool { return !unicode.IsPrint(r) }):
+		return "", fmt.Errorf("server name %q contains characters that can't be shown", name)
+	}
+	return name, nil
+}
@@ -0,0 +1,272 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"testing"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServerAPI records the calls made to the server API.
+type fakeServerAPI struct {
+	password string
+	saves    []string
+	options  map[string]string
+	pending  map[string]string
+	name     string
+	autoload string
+	sessions []*satisfactory.Session
+	commands []string
+
+	// files are the contents of the saves on the server, by name.
+	files map[string][]byte
+
+	// loaded is the save loaded last.
+	loaded string
+
+	// deleted are the names of the saves deleted.
+	deleted []string
+
+	// output is the output of every console command.
+	output string
+
+	// err fails every call when it is set.
+	err error
+}
+
+func (f *fakeServerAPI) SetClientPassword(_ context.Context, password string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.password = password
+	return nil
+}
+
+func (f *fakeServerAPI) SaveGame(_ context.Context, name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.saves = append(f.saves, name)
+	return nil
+}
+
+func (f *fakeServerAPI) GetServerOptions(context.Context) (*satisfactory.ServerOptions, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &satisfactory.ServerOptions{Current: maps.Clone(f.options), Pending: maps.Clone(f.pending)}, nil
+}
+
+func (f *fakeServerAPI) ApplyServerOptions(_ context.Context, options map[string]string) error {
+	if f.err != nil {
+		return f.err
+	}
+	for k, v := range options {
+		// The autosave interval waits for the session to restart, like on the real server.
+		if k == satisfactory.OptionAutosaveInterval {
+			f.pending[k] = v
+			continue
+		}
+		f.options[k] = v
+	}
+	return nil
+}
+
+func (f *fakeServerAPI) RenameServer(_ context.Context, name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.name = name
+	return nil
+}
+
+func (f *fakeServerAPI) SetAutoLoadSessionName(_ context.Context, session string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.autoload = session
+	return nil
+}
+
+func (f *fakeServerAPI) EnumerateSessions(context.Context) ([]*satisfactory.Session, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.sessions, nil
+}
+
+func (f *fakeServerAPI) RunCommand(_ context.Context, command string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.commands = append(f.commands, command)
+	return f.output, nil
+}
+
+func (f *fakeServerAPI) DownloadSaveGame(_ context.Context, name string) (io.ReadCloser, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	contents, ok := f.files[name]
+	if !ok {
+		return nil, &satisfactory.APIError{StatusCode: http.StatusNotFound, Code: "file_not_found", Message: name}
+	}
+	return io.NopCloser(bytes.NewReader(contents)), nil
+}
+
+func (f *fakeServerAPI) UploadSaveGame(_ context.Context, name string, save io.Reader, load bool) error {
+	if f.err != nil {
+		return f.err
+	}
+	contents, err := io.ReadAll(save)
+	if err != nil {
+		return err
+	}
+	f.files[name] = contents
+	if load {
+		f.loaded = name
+	}
+	return nil
+}
+
+func (f *fakeServerAPI) LoadGame(_ context.Context, name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.loaded = name
+	return nil
+}
+
+func (f *fakeServerAPI) DeleteSaveFile(_ context.Context, name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.files, name)
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+// testService returns a service calling a fake server API, which members with the operator role can control.
+func testService(opts ...ServiceOption) (*service, *fakeServerAPI) {
+	api := &fakeServerAPI{
+		options: map[string]string{
+			satisfactory.OptionAutosaveInterval: "300",
+			satisfactory.OptionNetworkQuality:   "3",
+			satisfactory.OptionAutoPause:        "True",
+		},
+		pending: make(map[string]string),
+		files:   make(map[string][]byte),
+	}
+
+	opts = append([]ServiceOption{WithServerAPI(api), WithServerControl(&fakeController{api: api}, []string{"operator"})}, opts...)
+	return NewService("token", opts...).(*service), api
+}
+
+// operator returns an interaction created by a member with the control role.
+func operator() *discordgo.InteractionCreate {
+	return memberInteraction("g1", "1", "operator")
+}
+
+func TestService_SaveGame(t *testing.T) {
+	s, api := testService()
+
+	msg, err := s.serverAdmin(s.saveGame)(context.Background(), operator(), map[string]string{nameOption: " before_update "})
+	require.NoError(t, err)
+	require.Equal(t, "Game saved as `before_update`", msg)
+	require.Equal(t, []string{"before_update"}, api.saves)
+
+	_, err = s.serverAdmin(s.saveGame)(context.Background(), operator(), map[string]string{nameOption: "../escape"})
+	require.Error(t, err)
+
+	_, err = s.serverAdmin(s.saveGame)(context.Background(), memberInteraction("g1", "2", "player"), map[string]string{nameOption: "mine"})
+	require.ErrorIs(t, err, errAccessDenied)
+	require.Len(t, api.saves, 1)
+}
+
+func TestService_ServerOptions(t *testing.T) {
+	s, api := testService()
+
+	msg, err := s.serverAdmin(s.serverOptions)(context.Background(), operator(), map[string]string{})
+	require.NoError(t, err)
+	require.Equal(t, "Server options:\n- Autosave interval: 5m0s\n- Network quality: Ultra\n- Pause when empty: yes", msg)
+
+	msg, err = s.serverAdmin(s.serverOptions)(context.Background(), operator(), map[string]string{
+		autosaveMinutesOption: "10",
+		networkQualityOption:  "1",
+		autoPauseOption:       "false",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Server options applied.\nServer options:\n"+
+		"- Autosave interval: 5m0s (10m0s after the session restarts)\n"+
+		"- Network quality: Medium\n"+
+		"- Pause when empty: no", msg)
+
+	require.Equal(t, "False", api.options[satisfactory.OptionAutoPause])
+	require.Equal(t, "600", api.pending[satisfactory.OptionAutosaveInterval])
+
+	_, err = s.serverAdmin(s.serverOptions)(context.Background(), operator(), map[string]string{networkQualityOption: "7"})
+	require.Error(t, err)
+}
+
+func TestService_RenameAndAutoload(t *testing.T) {
+	s, api := testService()
+
+	_, err := s.serverAdmin(s.renameServer)(context.Background(), operator(), map[string]string{nameOption: "Ficsit HQ"})
+	require.NoError(t, err)
+	require.Equal(t, "Ficsit HQ", api.name)
+
+	_, err = s.serverAdmin(s.renameServer)(context.Background(), operator(), map[string]string{nameOption: `Ficsit: HQ/2 "Alpha"`})
+	require.NoError(t, err)
+	require.Equal(t, `Ficsit: HQ/2 "Alpha"`, api.name)
+
+	_, err = s.serverAdmin(s.renameServer)(context.Background(), operator(), map[string]string{nameOption: "Ficsit\x00HQ"})
+	require.ErrorContains(t, err, "can't be shown")
+	require.Equal(t, `Ficsit: HQ/2 "Alpha"`, api.name)
+
+	_, err = s.serverAdmin(s.setAutoload)(context.Background(), operator(), map[string]string{sessionOption: "Factory"})
+	require.NoError(t, err)
+	require.Equal(t, "Factory", api.autoload)
+
+	api.err = errors.New("no such session")
+	_, err = s.serverAdmin(s.setAutoload)(context.Background(), operator(), map[string]string{sessionOption: "Missing"})
+	require.ErrorContains(t, err, "no such session")
+}
+
+func TestService_SessionChoices(t *testing.T) {
+	s, api := testService()
+	api.sessions = []*satisfactory.Session{{Name: "Factory"}, {Name: "Farm"}, {Name: "Creative"}}
+
+	choices, err := s.sessionChoices(context.Background(), operator(), "fa")
+	require.NoError(t, err)
+	require.Len(t, choices, 2)
+	require.Equal(t, "Factory", choices[0].Value)
+
+	// Players can't see the sessions.
+	choices, err = s.sessionChoices(context.Background(), memberInteraction("g1", "2"), "")
+	require.NoError(t, err)
+	require.Empty(t, choices)
+}
+
+func TestServerOptionUpdates(t *testing.T) {
+	updates, err := serverOptionUpdates(map[string]string{
+		autosaveMinutesOption:  "0",
+		saveOnLeaveOption:      "true",
+		sendGameplayDataOption: "false",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		satisfactory.OptionAutosaveInterval:     "0",
+		satisfactory.OptionAutoSaveOnDisconnect: "True",
+		satisfactory.OptionSendGameplayData:     "False",
+	}, updates)
+
+	_, err = serverOptionUpdates(map[string]string{autosaveMinutesOption: "-1"})
+	require.Error(t, err)
+}
@@ -1,6 +1,16 @@
 package bot
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/container"
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/Jacobbrewer1/satisfactory/pkg/secrets"
+	"github.com/bwmarrin/discordgo"
+)
 
 type Service interface {
 	// Start starts the bot
@@ -11,14 +21,89 @@ type Service interface {
 }
 
 type service struct {
-	token               string
-	s                   *discordgo.Session
-	interactionHandlers map[string]func(*discordgo.Session, *discordgo.InteractionCreate)
-	shutdownFunc        func()
+	ctx      context.Context
+	cancel   context.CancelFunc
+	token    string
+	s        *discordgo.Session
+	commands *registry
+	mutes    alerts.MuteStore
+	acks     alerts.AckStore
+	history  alerts.HistoryStore
+	audit    auditLog
+
+	// serverAPI calls the HTTPS API of the dedicated server. Commands that change the server fail when it is nil.
+	serverAPI satisfactory.APIClient
+
+	// controller starts and stops the server. The server can't be controlled from discord when it is nil.
+	controller container.Controller
+
+	// controlRoles are the IDs of the roles allowed to start and stop the server, as well as administrators.
+	controlRoles []string
+
+	// consolePolicy limits the console commands that can be run. No command can be run when it is nil.
+	consolePolicy *ConsolePolicy
+
+	// maxAttachmentSize is the largest file sent to discord. Larger saves are sent in parts.
+	maxAttachmentSize int
+
+	// httpClient fetches the files attached to commands.
+	httpClient *http.Client
+
+	// credentials stores the server password at credentialsPath. The password can't be changed when it is nil.
+	credentials     secrets.Store
+	credentialsPath string
+	credentialLog   credentialLog
+
+	// policies limit who can use the commands, keyed by the name of the command.
+	policies map[string]*AccessPolicy
+
+	// guildID is the guild the commands are registered in. The commands are registered globally when it is empty.
+	guildID string
+
+	// removeGlobalCommands removes the global commands the guild commands replace when guildID is set.
+	removeGlobalCommands bool
+
+	// alertChannelID is the channel acknowledgeable alerts are posted to. No alerts are posted when it is empty.
+	alertChannelID string
+
+	// alertDestinations are the names of the configured alert destinations. Only these can be muted on their own.
+	alertDestinations []string
+
+	// alertQueue is the redis list the alerts to post are read from.
+	alertQueue string
+
+	// escalationRoleID is the role mentioned when an alert is not acknowledged in time.
+	escalationRoleID string
+
+	// ackTimeout is how long an alert can go unacknowledged before it is escalated.
+	ackTimeout time.Duration
+
+	// statusChannelID is the channel the status panel is kept in. There is no status panel when it is empty.
+	statusChannelID string
+
+	// panelDeleted receives the IDs of the messages deleted from the status channel.
+	panelDeleted chan string
 }
 
-func NewService(token string) Service {
-	return &service{
-		token: token,
+func NewService(token string, opts ...ServiceOption) Service {
+	s := &service{
+		token:             token,
+		mutes:             alerts.NewMuteStore(),
+		acks:              alerts.NewAckStore(),
+		history:           alerts.NewHistoryStore(0),
+		audit:             newAuditLog(),
+		credentialLog:     newCredentialLog(),
+		maxAttachmentSize: defaultMaxAttachmentSize,
+		httpClient:        new(http.Client),
+		policies:          make(map[string]*AccessPolicy),
+		alertQueue:        alerts.DefaultBotQueue,
+		ackTimeout:        defaultAckTimeout,
+		panelDeleted:      make(chan string, 1),
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
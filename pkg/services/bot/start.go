@@ -14,6 +14,8 @@ import (
 )
 
 func (s *service) Start() error {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
 	session, err := discordgo.New("Bot " + s.token)
 	if err != nil {
 		return fmt.Errorf("failed to create discord session: %w", err)
@@ -29,80 +31,41 @@ func (s *service) Start() error {
 		return fmt.Errorf("failed to open discord session: %w", err)
 	}
 
-	s.removeAllCommands()
-
-	slog.Debug("Registering commands")
-	s.shutdownFunc, err = s.registerCommands()
-	if err != nil {
-		return fmt.Errorf("failed to register commands: %w", err)
+	slog.Debug("Syncing commands")
+	if err := s.syncCommands(); err != nil {
+		return fmt.Errorf("failed to sync commands: %w", err)
 	}
-	slog.Debug("Commands registered")
+	slog.Debug("Commands synced")
 
 	go s.handleBotStatus()
 
-	return nil
-}
-
-func (s *service) removeAllCommands() {
-	slog.Debug("Removing all commands")
-	cmds, err := s.s.ApplicationCommands(s.s.State.User.ID, "")
-	if err != nil {
-		slog.Error("Failed to get commands", slog.String(logging.KeyError, err.Error()))
-		return
+	if s.alertChannelID != "" {
+		go s.watchAlerts()
+		go s.watchEscalations()
+	} else {
+		slog.Debug("No alert channel configured, not posting alerts")
 	}
 
-	for _, v := range cmds {
-		err = s.s.ApplicationCommandDelete(s.s.State.User.ID, "", v.ID)
-		if err != nil {
-			slog.Error("Failed to delete command", slog.String("command", v.Name), slog.String(logging.KeyError, err.Error()))
-		}
+	if s.statusChannelID != "" {
+		go s.watchStatusPanel()
+	} else {
+		slog.Debug("No status channel configured, not showing the status panel")
 	}
-	slog.Debug("All commands removed")
+
+	return nil
 }
 
 func (s *service) registerHandlers() {
+	s.commands = s.newCommandRegistry()
+
 	s.s.AddHandler(s.onBotCreate)
 	s.s.AddHandler(s.onInteractionCreate)
-
-	s.interactionHandlers = map[string]func(*discordgo.Session, *discordgo.InteractionCreate){
-		serverInfoCmdID:        s.onServerInfo,
-		serverCredentialsCmdID: s.onServerCredentials,
-		severDetailsCmdID:      s.onServerDetails,
-	}
-}
-
-func (s *service) registerCommands() (func(), error) {
-	// Register commands here
-	registeredCommands := make([]*discordgo.ApplicationCommand, len(commands))
-
-	removeCommands := func() {
-		s.removeRegisteredCommands(registeredCommands)
-	}
-
-	for i, v := range commands {
-		cmd, err := s.s.ApplicationCommandCreate(s.s.State.User.ID, "", v) // GuildID is empty because we are creating global commands
-		if err != nil {
-			removeCommands()
-			return nil, fmt.Errorf("cannot create '%v' command: %w", v.Name, err)
-		}
-		registeredCommands[i] = cmd
-	}
-
-	return removeCommands, nil
-}
-
-func (s *service) removeRegisteredCommands(registeredCommands []*discordgo.ApplicationCommand) {
-	// Register commands here
-	for _, v := range registeredCommands {
-		err := s.s.ApplicationCommandDelete(s.s.State.User.ID, "", v.ID) // GuildID is empty because we are deleting global commands
-		if err != nil {
-			slog.Error("Failed to delete command", slog.String("command", v.Name), slog.String(logging.KeyError, err.Error()))
-		}
-	}
+	s.s.AddHandler(s.onMessageDelete)
 }
 
+// Stop stops the bot. The commands stay registered, so they keep working across restarts.
 func (s *service) Stop() error {
-	s.shutdownFunc()
+	s.cancel()
 	return s.s.Close()
 }
 
@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/bwmarrin/discordgo"
+)
+
+// commandSync is the changes needed to make the registered commands match the declared commands.
+type commandSync struct {
+	// create are the declared commands that are not registered.
+	create []*discordgo.ApplicationCommand
+
+	// update are the declared commands that are registered differently, with the ID of the registered command.
+	update []*discordgo.ApplicationCommand
+
+	// remove are the registered commands that are no longer declared.
+	remove []*discordgo.ApplicationCommand
+}
+
+// empty returns true if the registered commands already match.
+func (c *commandSync) empty() bool {
+	return len(c.create) == 0 && len(c.update) == 0 && len(c.remove) == 0
+}
+
+// syncCommands registers the declared commands, only creating, updating and removing the commands that changed so the
+// unchanged commands keep working while discord propagates the changes. Commands are registered in the guild when one
+// is set, where changes apply immediately, and globally otherwise.
+func (s *service) syncCommands() error {
+	appID := s.s.State.User.ID
+
+	if s.guildID != "" && s.removeGlobalCommands {
+		s.removeShadowedGlobalCommands(appID)
+	}
+
+	registered, err := s.s.ApplicationCommands(appID, s.guildID)
+	if err != nil {
+		return fmt.Errorf("get registered commands: %w", err)
+	}
+
+	sync := diffCommands(s.commands.applicationCommands(), registered)
+	if sync.empty() {
+		slog.Debug("Commands are up to date")
+		return nil
+	}
+
+	for _, cmd := range sync.create {
+		if _, err := s.s.ApplicationCommandCreate(appID, s.guildID, cmd); err != nil {
+			return fmt.Errorf("create command %s: %w", cmd.Name, err)
+		}
+		slog.Info("Command created", slog.String("command", cmd.Name))
+	}
+
+	for _, cmd := range sync.update {
+		if _, err := s.s.ApplicationCommandEdit(appID, s.guildID, cmd.ID, cmd); err != nil {
+			return fmt.Errorf("update command %s: %w", cmd.Name, err)
+		}
+		slog.Info("Command updated", slog.String("command", cmd.Name))
+	}
+
+	for _, cmd := range sync.remove {
+		if err := s.s.ApplicationCommandDelete(appID, s.guildID, cmd.ID); err != nil {
+			// The command is left for the next sync, the declared commands are all in place.
+			slog.Error("Failed to delete command", slog.String("command", cmd.Name), slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+		slog.Info("Command removed", slog.String("command", cmd.Name))
+	}
+
+	return nil
+}
+
+// removeShadowedGlobalCommands removes the global commands with the same name as a guild command, left from before
+// the commands were registered in the guild, so they aren't shown twice and don't skip the access settings of the
+// guild commands. Global commands the bot doesn't declare are left alone.
+func (s *service) removeShadowedGlobalCommands(appID string) {
+	global, err := s.s.ApplicationCommands(appID, "")
+	if err != nil {
+		// The stale commands are removed by the next sync, the guild commands can still be registered.
+		slog.Error("Failed to get global commands", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	declared := s.commands.applicationCommands()
+	for _, cmd := range global {
+		shadowed := slices.ContainsFunc(declared, func(d *discordgo.ApplicationCommand) bool {
+			return d.Name == cmd.Name
+		})
+		if !shadowed {
+			continue
+		}
+
+		if err := s.s.ApplicationCommandDelete(appID, "", cmd.ID); err != nil {
+			slog.Error("Failed to delete global command", slog.String("command", cmd.Name), slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+		slog.Warn("Global command removed in favour of the guild command", slog.String("command", cmd.Name))
+	}
+}
+
+// diffCommands compares the declared commands with the registered commands.
+func diffCommands(declared, registered []*discordgo.ApplicationCommand) *commandSync {
+	sync := new(commandSync)
+
+	byName := make(map[string]*discordgo.ApplicationCommand, len(registered))
+	for _, r := range registered {
+		byName[r.Name] = r
+	}
+
+	for _, d := range declared {
+		r, ok := byName[d.Name]
+		if !ok {
+			sync.create = append(sync.create, d)
+			continue
+		}
+		delete(byName, d.Name)
+
+		if !commandEqual(d, r) {
+			update := *d
+			update.ID = r.ID
+			sync.update = append(sync.update, &update)
+		}
+	}
+
+	for _, r := range registered {
+		if _, ok := byName[r.Name]; ok {
+			sync.remove = append(sync.remove, r)
+		}
+	}
+
+	return sync
+}
+
+// commandEqual returns true if the commands are registered the same way.
+func commandEqual(a, b *discordgo.ApplicationCommand) bool {
+	return a.Name == b.Name &&
+		a.Description == b.Description &&
+		commandType(a) == commandType(b) &&
+		permissionsEqual(a.DefaultMemberPermissions, b.DefaultMemberPermissions) &&
+		slices.EqualFunc(a.Options, b.Options, optionEqual)
+}
+
+// commandType returns the type of the command. Discord treats an unset type as a chat command.
+func commandType(c *discordgo.ApplicationCommand) discordgo.ApplicationCommandType {
+	if c.Type == 0 {
+		return discordgo.ChatApplicationCommand
+	}
+	return c.Type
+}
+
+func permissionsEqual(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func optionEqual(a, b *discordgo.ApplicationCommandOption) bool {
+	return a.Name == b.Name &&
+		a.Type == b.Type &&
+		a.Description == b.Description &&
+		a.Required == b.Required &&
+		a.Autocomplete == b.Autocomplete &&
+		slices.EqualFunc(a.Choices, b.Choices, choiceEqual) &&
+		slices.EqualFunc(a.Options, b.Options, optionEqual)
+}
+
+func choiceEqual(a, b *discordgo.ApplicationCommandOptionChoice) bool {
+	// Discord returns the values as decoded JSON, so compare them by how they print.
+	return a.Name == b.Name && fmt.Sprint(a.Value) == fmt.Sprint(b.Value)
+}
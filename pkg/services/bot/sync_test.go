@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffCommands(t *testing.T) {
+	declared := []*discordgo.ApplicationCommand{
+		{
+			Name:        "server-info",
+			Type:        discordgo.ChatApplicationCommand,
+			Description: "Server Info",
+		},
+		{
+			Name:                     "alerts",
+			Type:                     discordgo.ChatApplicationCommand,
+			Description:              "Manage alerts",
+			DefaultMemberPermissions: utils.Ptr(int64(discordgo.PermissionManageServer)),
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "category",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "The alert category",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "state", Value: "state"},
+						{Name: "game", Value: "game"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "players",
+			Type:        discordgo.ChatApplicationCommand,
+			Description: "Online players",
+		},
+	}
+
+	registered := []*discordgo.ApplicationCommand{
+		{
+			ID:          "1",
+			Name:        "server-info",
+			Description: "Server Info",
+		},
+		{
+			ID:                       "2",
+			Name:                     "alerts",
+			Type:                     discordgo.ChatApplicationCommand,
+			Description:              "Manage alerts",
+			DefaultMemberPermissions: utils.Ptr(int64(discordgo.PermissionManageServer)),
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "category",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "The alert category",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "state", Value: "state"},
+					},
+				},
+			},
+		},
+		{
+			ID:          "3",
+			Name:        "server-old",
+			Type:        discordgo.ChatApplicationCommand,
+			Description: "Removed command",
+		},
+	}
+
+	sync := diffCommands(declared, registered)
+
+	require.Len(t, sync.create, 1)
+	require.Equal(t, "players", sync.create[0].Name)
+
+	// The choices of alerts changed, server-info is unchanged even though discord left its type unset.
+	require.Len(t, sync.update, 1)
+	require.Equal(t, "alerts", sync.update[0].Name)
+	require.Equal(t, "2", sync.update[0].ID)
+	require.Empty(t, declared[1].ID, "the declared command must not be modified")
+
+	require.Len(t, sync.remove, 1)
+	require.Equal(t, "3", sync.remove[0].ID)
+}
+
+func TestDiffCommands_UpToDate(t *testing.T) {
+	var called string
+	declared := testRegistry(&called).applicationCommands()
+
+	registered := testRegistry(&called).applicationCommands()
+	for i, cmd := range registered {
+		cmd.ID = strconv.Itoa(i + 1)
+	}
+
+	require.True(t, diffCommands(declared, registered).empty())
+}
+
+// guildSync returns a service registering the test commands in a guild, with a global server-info and ping command.
+func guildSync(t *testing.T, opts ...ServiceOption) (*service, *fakeDiscord) {
+	session, discord := newFakeDiscord(t)
+	session.State.User = &discordgo.User{ID: "app"}
+	discord.replies["GET /applications/app/commands"] = `[{"id":"8","name":"server-info"},{"id":"9","name":"ping"}]`
+	discord.replies["GET /applications/app/guilds/g1/commands"] = `[]`
+
+	var called string
+	s := NewService("token", append([]ServiceOption{WithGuild("g1")}, opts...)...).(*service)
+	s.s = session
+	s.commands = testRegistry(&called)
+	return s, discord
+}
+
+// sentRequests returns the method and path of the requests made to discord.
+func sentRequests(discord *fakeDiscord) []string {
+	requests := make([]string, 0)
+	for _, r := range discord.sent() {
+		requests = append(requests, r.method+" "+r.path)
+	}
+	return requests
+}
+
+func TestService_SyncCommandsKeepsGlobalCommands(t *testing.T) {
+	s, discord := guildSync(t)
+
+	require.NoError(t, s.syncCommands())
+
+	// A guild used for development must not remove the commands every other server uses.
+	requests := sentRequests(discord)
+	require.NotContains(t, requests, "GET /applications/app/commands")
+	require.Contains(t, requests, "POST /applications/app/guilds/g1/commands")
+	for _, r := range requests {
+		require.False(t, strings.HasPrefix(r, "DELETE"), r)
+	}
+}
+
+func TestService_SyncCommandsRemovesGlobalCommands(t *testing.T) {
+	s, discord := guildSync(t, WithGlobalCommandRemoval())
+
+	require.NoError(t, s.syncCommands())
+
+	// Only the global command the guild command replaces is removed.
+	requests := sentRequests(discord)
+	require.Contains(t, requests, "DELETE /applications/app/commands/9")
+	require.NotContains(t, requests, "DELETE /applications/app/commands/8")
+	require.Contains(t, requests, "POST /applications/app/guilds/g1/commands")
+}
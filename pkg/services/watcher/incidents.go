@@ -0,0 +1,89 @@
+package watcher
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+)
+
+const (
+	// incidentContainerDown is the incident opened while the server container is not running.
+	incidentContainerDown = "container-down"
+
+	// incidentTickRateDegraded is the incident opened while the average tick rate is below the threshold.
+	incidentTickRateDegraded = "tick-rate-degraded"
+
+	// defaultTickRateThreshold is the average tick rate below which the server is considered degraded. The server
+	// targets 30 ticks per second.
+	defaultTickRateThreshold = 20
+
+	// tickRateUpdateStep is how much the tick rate of a degraded server has to change from the rate the incident shows
+	// before the incident is updated.
+	tickRateUpdateStep = 1
+)
+
+// handleTickRate opens, updates and resolves the degraded tick rate incident as the average tick rate changes.
+func (s *service) handleTickRate(c *state.Change) error {
+	current, ok := c.New.(float64)
+	if !ok {
+		return fmt.Errorf("unexpected tick rate type %T", c.New)
+	}
+
+	// A tick rate seen for the first time has nothing to compare with, so it is treated as healthy before.
+	prev := s.tickRateThreshold
+	if c.Old != nil {
+		prev, ok = c.Old.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected tick rate type %T", c.Old)
+		}
+	}
+
+	wasDegraded := prev < s.tickRateThreshold
+	degraded := current < s.tickRateThreshold
+
+	// The incident is updated once the tick rate has moved far enough from the rate it shows, so a slow drift is
+	// reported too. The rate it shows is not known after a restart, so the previous sample stands in for it.
+	reported := s.reportedTickRate
+	if reported == 0 {
+		reported = prev
+	}
+
+	var alert *alerts.Alert
+	switch {
+	case !wasDegraded && degraded:
+		slog.Warn("Tick rate degraded", slog.Float64("tick_rate", current))
+		alert = alerts.NewTemplatedAlert(alerts.SeverityWarning, alerts.TemplateTickRateDegraded, s.tickRateData(prev, current)).
+			WithIncident(incidentTickRateDegraded, alerts.IncidentOpen)
+	case wasDegraded && degraded && math.Abs(current-reported) >= tickRateUpdateStep:
+		alert = alerts.NewTemplatedAlert(alerts.SeverityWarning, alerts.TemplateTickRateDegraded, s.tickRateData(reported, current)).
+			WithIncident(incidentTickRateDegraded, alerts.IncidentOpen)
+	case wasDegraded && !degraded:
+		slog.Info("Tick rate recovered", slog.Float64("tick_rate", current))
+		alert = alerts.NewTemplatedAlert(alerts.SeverityOK, alerts.TemplateTickRateRecovered, s.tickRateData(prev, current)).
+			WithIncident(incidentTickRateDegraded, alerts.IncidentResolved)
+	default:
+		return nil
+	}
+
+	if err := s.alertManager.Notify(s.ctx, alert.WithCategory(alerts.CategoryPerformance)); err != nil {
+		return fmt.Errorf("send alert: %w", err)
+	}
+
+	s.reportedTickRate = 0
+	if degraded {
+		s.reportedTickRate = current
+	}
+
+	return nil
+}
+
+func (s *service) tickRateData(prev, current float64) map[string]any {
+	return map[string]any{
+		"Previous":  prev,
+		"Current":   current,
+		"Threshold": s.tickRateThreshold,
+	}
+}
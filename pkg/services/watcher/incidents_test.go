@@ -0,0 +1,82 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier records the alerts it is sent.
+type recordingNotifier struct {
+	alerts []*alerts.Alert
+}
+
+func (r *recordingNotifier) Name() string {
+	return "recording"
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, alert *alerts.Alert) error {
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func TestHandleTickRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		old, new   float64
+		wantAlert  bool
+		wantStatus alerts.IncidentStatus
+		wantTmpl   string
+	}{
+		{name: "healthy", old: 30, new: 29, wantAlert: false},
+		{name: "degraded", old: 30, new: 15, wantAlert: true, wantStatus: alerts.IncidentOpen, wantTmpl: alerts.TemplateTickRateDegraded},
+		{name: "small change while degraded", old: 15, new: 15.5, wantAlert: false},
+		{name: "worse while degraded", old: 15, new: 10, wantAlert: true, wantStatus: alerts.IncidentOpen, wantTmpl: alerts.TemplateTickRateDegraded},
+		{name: "recovered", old: 15, new: 25, wantAlert: true, wantStatus: alerts.IncidentResolved, wantTmpl: alerts.TemplateTickRateRecovered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := new(recordingNotifier)
+			s := NewService(context.Background(), notifier, "info", "details").(*service)
+
+			err := s.handleTickRate(&state.Change{Name: "AverageTickRate", Old: tt.old, New: tt.new})
+			require.NoError(t, err)
+
+			if !tt.wantAlert {
+				require.Empty(t, notifier.alerts)
+				return
+			}
+
+			require.Len(t, notifier.alerts, 1)
+			alert := notifier.alerts[0]
+			require.Equal(t, incidentTickRateDegraded, alert.IncidentID)
+			require.Equal(t, tt.wantStatus, alert.IncidentStatus)
+			require.Equal(t, tt.wantTmpl, alert.Template)
+		})
+	}
+}
+
+func TestHandleTickRate_Drift(t *testing.T) {
+	notifier := new(recordingNotifier)
+	s := NewService(context.Background(), notifier, "info", "details").(*service)
+
+	samples := []float64{30, 19.5, 19.0, 18.6, 18.2, 17.9}
+	for i := 1; i < len(samples); i++ {
+		require.NoError(t, s.handleTickRate(&state.Change{Name: "AverageTickRate", Old: samples[i-1], New: samples[i]}))
+	}
+
+	// No step moves the rate by a whole tick, but the drift from the rate the incident shows does.
+	require.Len(t, notifier.alerts, 2)
+	require.Equal(t, 19.5, notifier.alerts[0].Data["Current"])
+	require.Equal(t, 19.5, notifier.alerts[1].Data["Previous"])
+	require.Equal(t, 18.2, notifier.alerts[1].Data["Current"])
+	require.Equal(t, 18.2, s.reportedTickRate)
+
+	require.NoError(t, s.handleTickRate(&state.Change{Name: "AverageTickRate", Old: 17.9, New: 25.0}))
+	require.Len(t, notifier.alerts, 3)
+	require.Zero(t, s.reportedTickRate, "nothing is reported once the incident is resolved")
+}
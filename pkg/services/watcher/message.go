@@ -4,13 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"reflect"
 	"regexp"
-	"strconv"
+	"time"
 
-	"github.com/Jacobbrewer1/goredis"
-	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
-	redisgo "github.com/gomodule/redigo/redis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
 )
 
 func (s *service) processInfoMessage(msg []byte) error {
@@ -60,31 +58,42 @@ func (s *service) processInfoMessage(msg []byte) error {
 
 // Store and process the message
 func (s *service) handleDockerInfo(info dockerInfo) error {
-	// Get the current hash map of docker info
-	got, err := redisgo.StringMap(goredis.DoCtx(s.ctx, "HGETALL", "docker_info"))
+	diff, err := s.dockerState.Update(s.ctx, info)
 	if err != nil {
-		return fmt.Errorf("get docker info: %w", err)
+		return fmt.Errorf("update docker info: %w", err)
+	} else if diff.FirstObservation {
+		slog.Info("First docker info observed", slog.String("state", info.State))
+		return nil
 	}
 
-	// Compare the new info to the old info
-	if got["State"] != info.State {
-		slog.Debug("State changed", slog.String("old", got["State"]), slog.String("new", info.State))
-		err = s.alertManager.SendDiscordAlert(fmt.Sprintf("Server state changed from `%s` to `%s`", got["State"], info.State))
-		if err != nil {
-			return fmt.Errorf("send discord alert: %w", err)
-		}
+	for _, c := range diff.Changes {
+		slog.Debug("Docker info changed", slog.String("field", c.Name), slog.Any("old", c.Old), slog.Any("new", c.New))
 	}
 
-	// Store all the info
-	v := reflect.ValueOf(info)
-	values := make([]any, v.NumField()*2)
-	for i := 0; i < v.NumField(); i++ {
-		values[i*2] = v.Type().Field(i).Name
-		values[i*2+1] = v.Field(i).String()
-	}
+	if c, ok := diff.Change("State"); ok {
+		severity := alerts.SeverityCritical
+		if info.State == containerStateRunning {
+			severity = alerts.SeverityOK
+		}
 
-	if _, err := goredis.DoCtx(s.ctx, "HMSET", redisgo.Args{}.Add("docker_info").AddFlat(values)...); err != nil {
-		return fmt.Errorf("store docker info: %w", err)
+		alert := alerts.NewTemplatedAlert(severity, alerts.TemplateServerStateChanged, map[string]any{
+			"Old":        c.Old,
+			"New":        c.New,
+			"Status":     info.Status,
+			"RunningFor": info.RunningFor,
+			"Names":      info.Names,
+		}).WithCategory(alerts.CategoryState)
+
+		// A container that is not running is an incident that stays open, edited as the state changes, until the
+		// container is running again.
+		if info.State == containerStateRunning {
+			alert.WithIncident(incidentContainerDown, alerts.IncidentResolved)
+		} else {
+			alert.WithIncident(incidentContainerDown, alerts.IncidentOpen)
+		}
+		if err := s.alertManager.Notify(s.ctx, alert); err != nil {
+			return fmt.Errorf("send alert: %w", err)
+		}
 	}
 
 	return nil
@@ -136,65 +145,79 @@ func (s *service) processDetailsMessage(msg []byte) error {
 }
 
 func (s *service) handleServerDetails(details ServerGameState) error {
-	// Get the current hash map of server details
-	got, err := redisgo.StringMap(goredis.DoCtx(s.ctx, "HGETALL", "server_details"))
+	diff, err := s.detailsState.Update(s.ctx, details)
 	if err != nil {
-		return fmt.Errorf("get server details: %w", err)
+		return fmt.Errorf("update server details: %w", err)
 	}
 
-	// Compare the new details to the old details
-	if got["ActiveSessionName"] != details.ActiveSessionName {
-		slog.Debug("Active session name changed", slog.String("old", got["ActiveSessionName"]), slog.String("new", details.ActiveSessionName))
-		err = s.alertManager.SendDiscordAlert(fmt.Sprintf("Active session name changed from `%s` to `%s`", got["ActiveSessionName"], details.ActiveSessionName))
+	// Leaving isn't always logged, such as when the server stops, so nobody is online once the server says so, and
+	// players without a connection are gone once fewer are connected than online.
+	if details.NumConnectedPlayers == 0 {
+		if err := state.ClearOnlinePlayers(s.ctx, time.Now()); err != nil {
+			return fmt.Errorf("clear online players: %w", err)
+		}
+	} else {
+		ended, err := state.ReconcileOnlinePlayers(s.ctx, details.NumConnectedPlayers, time.Now())
 		if err != nil {
-			return fmt.Errorf("send discord alert: %w", err)
+			return fmt.Errorf("reconcile online players: %w", err)
+		}
+		for _, session := range ended {
+			slog.Info("Player no longer connected", slog.String("player", session.Name))
 		}
 	}
 
-	gameRunning, err := strconv.ParseBool(got["IsGameRunning"])
-	if err != nil {
-		slog.Error("Error parsing bool", slog.String("value", got["IsGameRunning"]), slog.String(logging.KeyError, err.Error()))
-	} else if gameRunning != details.IsGameRunning {
-		slog.Debug("Game running changed", slog.Bool("old", gameRunning), slog.Bool("new", details.IsGameRunning))
-		err = s.alertManager.SendDiscordAlert(fmt.Sprintf("Game running changed from `%t` to `%t`", gameRunning, details.IsGameRunning))
-		if err != nil {
-			return fmt.Errorf("send discord alert: %w", err)
+	if diff.FirstObservation {
+		slog.Info("First server details observed", slog.String("session", details.ActiveSessionName))
+		return nil
+	}
+
+	for _, c := range diff.Changes {
+		slog.Debug("Server details changed", slog.String("field", c.Name), slog.Any("old", c.Old), slog.Any("new", c.New))
+	}
+
+	if c, ok := diff.Change("ActiveSessionName"); ok {
+		alert := alerts.NewTemplatedAlert(alerts.SeverityInfo, alerts.TemplateSessionChanged, map[string]any{
+			"Old":         c.Old,
+			"New":         c.New,
+			"TechTier":    details.TechTier,
+			"Players":     details.NumConnectedPlayers,
+			"PlayerLimit": details.PlayerLimit,
+		}).WithCategory(alerts.CategorySession)
+		if err := s.alertManager.Notify(s.ctx, alert); err != nil {
+			return fmt.Errorf("send alert: %w", err)
 		}
 	}
 
-	gamePaused, err := strconv.ParseBool(got["IsGamePaused"])
-	if err != nil {
-		slog.Error("Error parsing bool", slog.String("value", got["IsGamePaused"]), slog.String(logging.KeyError, err.Error()))
-	} else if gamePaused != details.IsGamePaused {
-		slog.Debug("Game paused changed", slog.Bool("old", gamePaused), slog.Bool("new", details.IsGamePaused))
-		err = s.alertManager.SendDiscordAlert(fmt.Sprintf("Game paused changed from `%t` to `%t`", gamePaused, details.IsGamePaused))
-		if err != nil {
-			return fmt.Errorf("send discord alert: %w", err)
+	if _, ok := diff.Change("IsGameRunning"); ok {
+		data := map[string]any{"Session": details.ActiveSessionName}
+		alert := alerts.NewTemplatedAlert(alerts.SeverityOK, alerts.TemplateGameRunning, data)
+		if !details.IsGameRunning {
+			alert = alerts.NewTemplatedAlert(alerts.SeverityCritical, alerts.TemplateGameStopped, data)
+		}
+		alert.WithCategory(alerts.CategoryGame)
+
+		if err := s.alertManager.Notify(s.ctx, alert); err != nil {
+			return fmt.Errorf("send alert: %w", err)
 		}
 	}
 
-	// Store all the details
-	v := reflect.ValueOf(details)
-	values := make([]any, v.NumField()*2)
-	for i := 0; i < v.NumField(); i++ {
-		values[i*2] = v.Type().Field(i).Name
-
-		switch v.Type().Field(i).Type.Kind() {
-		case reflect.Int:
-			values[i*2+1] = v.Field(i).Int()
-		case reflect.Float64:
-			values[i*2+1] = v.Field(i).Float()
-		case reflect.String:
-			values[i*2+1] = v.Field(i).String()
-		case reflect.Bool:
-			values[i*2+1] = v.Field(i).Bool()
-		default:
-			return fmt.Errorf("unknown type: %s", v.Type().Field(i).Type.Kind())
+	if c, ok := diff.Change("AverageTickRate"); ok {
+		if err := s.handleTickRate(c); err != nil {
+			return fmt.Errorf("handle tick rate: %w", err)
 		}
 	}
 
-	if _, err := goredis.DoCtx(s.ctx, "HMSET", redisgo.Args{}.Add("server_details").AddFlat(values)...); err != nil {
-		return fmt.Errorf("store server details: %w", err)
+	if _, ok := diff.Change("IsGamePaused"); ok {
+		data := map[string]any{"Session": details.ActiveSessionName}
+		alert := alerts.NewTemplatedAlert(alerts.SeverityInfo, alerts.TemplateGameResumed, data)
+		if details.IsGamePaused {
+			alert = alerts.NewTemplatedAlert(alerts.SeverityInfo, alerts.TemplateGamePaused, data)
+		}
+		alert.WithCategory(alerts.CategoryGame)
+
+		if err := s.alertManager.Notify(s.ctx, alert); err != nil {
+			return fmt.Errorf("send alert: %w", err)
+		}
 	}
 
 	return nil
@@ -3,6 +3,8 @@ package watcher
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
 )
 
 type vectorMessage struct {
@@ -48,3 +50,40 @@ type ServerGameState struct {
 	AverageTickRate     float64 `json:"averageTickRate"`
 	AutoLoadSessionName string  `json:"autoLoadSessionName"`
 }
+
+// StateFields implements state.Observable.
+func (d dockerInfo) StateFields() []*state.Field {
+	return []*state.Field{
+		state.StringField("Command", d.Command),
+		state.StringField("CreatedAt", d.CreatedAt),
+		state.StringField("ID", d.ID),
+		state.StringField("Image", d.Image),
+		state.StringField("Labels", d.Labels),
+		state.StringField("LocalVolumes", d.LocalVolumes),
+		state.StringField("Mounts", d.Mounts),
+		state.StringField("Names", d.Names),
+		state.StringField("Networks", d.Networks),
+		state.StringField("Ports", d.Ports),
+		state.StringField("RunningFor", d.RunningFor),
+		state.StringField("Size", d.Size),
+		state.StringField("State", d.State),
+		state.StringField("Status", d.Status),
+	}
+}
+
+// StateFields implements state.Observable.
+func (s ServerGameState) StateFields() []*state.Field {
+	return []*state.Field{
+		state.StringField("ActiveSessionName", s.ActiveSessionName),
+		state.IntField("NumConnectedPlayers", s.NumConnectedPlayers),
+		state.IntField("PlayerLimit", s.PlayerLimit),
+		state.IntField("TechTier", s.TechTier),
+		state.StringField("ActiveSchematic", s.ActiveSchematic),
+		state.StringField("GamePhase", s.GamePhase),
+		state.BoolField("IsGameRunning", s.IsGameRunning),
+		state.IntField("TotalGameDuration", s.TotalGameDuration),
+		state.BoolField("IsGamePaused", s.IsGamePaused),
+		state.FloatField("AverageTickRate", s.AverageTickRate),
+		state.StringField("AutoLoadSessionName", s.AutoLoadSessionName),
+	}
+}
@@ -0,0 +1,59 @@
+package watcher
+
+import (
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+)
+
+type ServiceOption func(s *service)
+
+// WithStaleDeadline sets how long an ingest source can go without sending data before it is flagged as stale.
+func WithStaleDeadline(source string, deadline time.Duration) ServiceOption {
+	return func(s *service) {
+		if deadline <= 0 {
+			return
+		}
+		s.staleDeadlines[source] = deadline
+	}
+}
+
+// WithQueryClient polls the lightweight query API with the client at the given interval to track the server build.
+func WithQueryClient(client satisfactory.QueryClient, interval time.Duration) ServiceOption {
+	return func(s *service) {
+		if interval <= 0 {
+			interval = defaultVersionPollInterval
+		}
+		s.queryClient = client
+		s.versionPollInterval = interval
+	}
+}
+
+// WithServerLogsListName watches the redis list that vector pushes the server log lines to.
+func WithServerLogsListName(name string) ServiceOption {
+	return func(s *service) {
+		s.serverLogsListName = name
+	}
+}
+
+// WithTickRateThreshold sets the average tick rate below which the server is considered degraded.
+func WithTickRateThreshold(threshold float64) ServiceOption {
+	return func(s *service) {
+		if threshold <= 0 {
+			return
+		}
+		s.tickRateThreshold = threshold
+	}
+}
+
+// WithSaveRetention prunes the saves the policy doesn't keep through the server API at the given interval.
+func WithSaveRetention(client satisfactory.APIClient, policy RetentionPolicy, interval time.Duration) ServiceOption {
+	return func(s *service) {
+		if interval <= 0 {
+			interval = defaultRetentionInterval
+		}
+		s.serverAPI = client
+		s.retentionPolicy = policy
+		s.retentionInterval = interval
+	}
+}
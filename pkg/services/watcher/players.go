@@ -0,0 +1,83 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+)
+
+// The kinds of player event found in the server log.
+const (
+	playerLogin = iota
+	playerJoin
+	playerDisconnect
+)
+
+var (
+	// playerLoginPattern matches the log line of a player logging in, with their URL escaped name and unique ID.
+	playerLoginPattern = regexp.MustCompile(`LogNet: Login request: .*\?Name=([^?\s]+).* userId: (\S+)`)
+
+	// playerJoinPattern matches the log line of a player joining the game, with their name.
+	playerJoinPattern = regexp.MustCompile(`LogNet: Join succeeded: (.+?)\s*$`)
+
+	// playerDisconnectPattern matches the log line of a player connection closing, with the unique ID of the player.
+	playerDisconnectPattern = regexp.MustCompile(`LogNet: UNetConnection::Close: .*UniqueId: ([^,\s]+)`)
+)
+
+// playerEvent is a player logging in, joining or leaving, read from the server log.
+type playerEvent struct {
+	kind     int
+	name     string
+	uniqueID string
+}
+
+// parsePlayerEvent returns the player event if the log line is one.
+func parsePlayerEvent(line string) (*playerEvent, bool) {
+	if m := playerJoinPattern.FindStringSubmatch(line); m != nil {
+		return &playerEvent{kind: playerJoin, name: m[1]}, true
+	} else if m := playerLoginPattern.FindStringSubmatch(line); m != nil {
+		// The name is escaped in the login URL, but not when the player joins, where their session is named.
+		name, err := url.QueryUnescape(m[1])
+		if err != nil {
+			name = m[1]
+		}
+		return &playerEvent{kind: playerLogin, name: name, uniqueID: m[2]}, true
+	} else if m := playerDisconnectPattern.FindStringSubmatch(line); m != nil && m[1] != "INVALID" {
+		return &playerEvent{kind: playerDisconnect, uniqueID: m[1]}, true
+	}
+	return nil, false
+}
+
+// trackPlayers records the player event in the log line, if it is one.
+func trackPlayers(ctx context.Context, line string, at time.Time) error {
+	event, ok := parsePlayerEvent(line)
+	if !ok {
+		return nil
+	}
+
+	switch event.kind {
+	case playerLogin:
+		if err := state.RecordPlayerLogin(ctx, event.uniqueID, event.name); err != nil {
+			return fmt.Errorf("record player login: %w", err)
+		}
+	case playerJoin:
+		slog.Info("Player joined", slog.String("player", event.name))
+		if err := state.PlayerJoined(ctx, event.name, at); err != nil {
+			return fmt.Errorf("record player join: %w", err)
+		}
+	case playerDisconnect:
+		session, err := state.PlayerDisconnected(ctx, event.uniqueID, at)
+		if err != nil {
+			return fmt.Errorf("record player leave: %w", err)
+		} else if session != nil {
+			slog.Info("Player left", slog.String("player", session.Name), slog.Duration("duration", session.Duration(at)))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlayerEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   *playerEvent
+		wantOk bool
+	}{
+		{
+			name:   "login",
+			line:   "[2024.09.10-18.22.01:123][  0]LogNet: Login request: ?ClientIdentity=abc?EncryptionToken=def?Name=Pioneer userId: EOS:0002abcdef platform: EOS",
+			want:   &playerEvent{kind: playerLogin, name: "Pioneer", uniqueID: "EOS:0002abcdef"},
+			wantOk: true,
+		},
+		{
+			name:   "login with escaped name",
+			line:   "[2024.09.10-18.22.01:123][  0]LogNet: Login request: ?ClientIdentity=abc?Name=Pioneer%20One%21 userId: EOS:0002abcdef platform: EOS",
+			want:   &playerEvent{kind: playerLogin, name: "Pioneer One!", uniqueID: "EOS:0002abcdef"},
+			wantOk: true,
+		},
+		{
+			name:   "join",
+			line:   "[2024.09.10-18.22.02:123][  0]LogNet: Join succeeded: Pioneer One",
+			want:   &playerEvent{kind: playerJoin, name: "Pioneer One"},
+			wantOk: true,
+		},
+		{
+			name: "disconnect",
+			line: "[2024.09.10-19.22.02:123][  0]LogNet: UNetConnection::Close: [UNetConnection] RemoteAddr: 192.168.1.2:54321, " +
+				"Name: IpConnection_2147482345, Driver: GameNetDriver IpNetDriver_2147482389, IsServer: YES, " +
+				"PC: BP_PlayerController_C_2147482300, Owner: BP_PlayerController_C_2147482300, UniqueId: EOS:0002abcdef, Channels: 27",
+			want:   &playerEvent{kind: playerDisconnect, uniqueID: "EOS:0002abcdef"},
+			wantOk: true,
+		},
+		{
+			name: "disconnect before login",
+			line: "[2024.09.10-19.22.02:123][  0]LogNet: UNetConnection::Close: [UNetConnection] RemoteAddr: 192.168.1.2:54321, " +
+				"Name: IpConnection_2147482345, Driver: GameNetDriver IpNetDriver_2147482389, IsServer: YES, PC: NULL, Owner: NULL, UniqueId: INVALID",
+			wantOk: false,
+		},
+		{
+			name:   "other line",
+			line:   "[2024.09.10-18.22.01:123][  0]LogInit: Net CL: 365306",
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePlayerEvent(tt.line)
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package watcher
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+)
+
+const (
+	// defaultRetentionInterval is how often old saves are pruned when no interval is set.
+	defaultRetentionInterval = time.Hour
+
+	// retentionTimeout is how long a round of pruning can take.
+	retentionTimeout = 5 * time.Minute
+)
+
+// RetentionPolicy decides which saves are pruned from each session. A save is pruned when it isn't one of the newest
+// KeepLatest saves of its session and it is older than MaxAge. The newest save of a session is never pruned.
+type RetentionPolicy struct {
+	// KeepLatest is how many of the newest saves of each session are kept whatever their age.
+	KeepLatest int `mapstructure:"keep_latest"`
+
+	// MaxAge is how old a save must be before it is pruned. Every save past the newest KeepLatest is pruned when it
+	// is zero.
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// enabled returns true if the policy prunes anything.
+func (p RetentionPolicy) enabled() bool {
+	return p.KeepLatest > 0 || p.MaxAge > 0
+}
+
+// savesToPrune returns the names of the saves the policy prunes. Saves with a date that can't be read are kept.
+func savesToPrune(sessions []*satisfactory.Session, policy RetentionPolicy, now time.Time) []string {
+	if !policy.enabled() {
+		return nil
+	}
+	keep := max(policy.KeepLatest, 1)
+
+	type datedSave struct {
+		name    string
+		savedAt time.Time
+	}
+
+	prune := make([]string, 0)
+	for _, session := range sessions {
+		saves := make([]datedSave, 0, len(session.Saves))
+		for _, save := range session.Saves {
+			savedAt, err := save.SavedAt()
+			if err != nil {
+				continue
+			}
+			saves = append(saves, datedSave{name: save.Name, savedAt: savedAt})
+		}
+
+		slices.SortFunc(saves, func(a, b datedSave) int {
+			return b.savedAt.Compare(a.savedAt)
+		})
+
+		for n, save := range saves {
+			if n < keep || (policy.MaxAge > 0 && now.Sub(save.savedAt) <= policy.MaxAge) {
+				continue
+			}
+			prune = append(prune, save.name)
+		}
+	}
+
+	return prune
+}
+
+// watchSaveRetention periodically prunes the saves the retention policy doesn't keep.
+func (s *service) watchSaveRetention(ctx context.Context) {
+	if s.serverAPI == nil || !s.retentionPolicy.enabled() {
+		slog.Debug("No save retention policy configured, not pruning saves")
+		return
+	}
+
+	ticker := time.NewTicker(s.retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		s.pruneSaves(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Debug("Context done")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *service) pruneSaves(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, retentionTimeout)
+	defer cancel()
+
+	sessions, err := s.serverAPI.EnumerateSessions(ctx)
+	if err != nil {
+		slog.Error("Error enumerating sessions", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	for _, name := range savesToPrune(sessions, s.retentionPolicy, time.Now().UTC()) {
+		if err := s.serverAPI.DeleteSaveFile(ctx, name); err != nil {
+			slog.Error("Error deleting save", slog.String("save", name), slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+		slog.Info("Pruned save", slog.String("save", name))
+	}
+}
@@ -0,0 +1,59 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavesToPrune(t *testing.T) {
+	now := time.Date(2024, time.September, 20, 12, 0, 0, 0, time.UTC)
+	sessions := []*satisfactory.Session{
+		{Name: "Factory", Saves: []*satisfactory.SaveHeader{
+			{Name: "Factory_autosave_0", SaveDateTime: "2024.09.20-11.00.00"},
+			{Name: "Factory_autosave_1", SaveDateTime: "2024.09.19-11.00.00"},
+			{Name: "Factory_autosave_2", SaveDateTime: "2024.09.01-11.00.00"},
+			{Name: "Factory_manual", SaveDateTime: "2024.08.01-11.00.00"},
+			{Name: "Factory_broken", SaveDateTime: "unknown"},
+		}},
+		{Name: "Old", Saves: []*satisfactory.SaveHeader{
+			{Name: "Old_autosave_0", SaveDateTime: "2023.01.01-00.00.00"},
+			{Name: "Old_autosave_1", SaveDateTime: "2022.01.01-00.00.00"},
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []string
+	}{
+		{
+			name:   "disabled",
+			policy: RetentionPolicy{},
+			want:   nil,
+		},
+		{
+			name:   "keep latest",
+			policy: RetentionPolicy{KeepLatest: 2},
+			want:   []string{"Factory_autosave_2", "Factory_manual"},
+		},
+		{
+			name:   "max age",
+			policy: RetentionPolicy{MaxAge: 7 * 24 * time.Hour},
+			want:   []string{"Factory_autosave_2", "Factory_manual", "Old_autosave_1"},
+		},
+		{
+			name:   "keep latest and max age",
+			policy: RetentionPolicy{KeepLatest: 3, MaxAge: 7 * 24 * time.Hour},
+			want:   []string{"Factory_manual"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, savesToPrune(sessions, tt.policy, now))
+		})
+	}
+}
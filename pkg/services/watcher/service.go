@@ -2,8 +2,22 @@ package watcher
 
 import (
 	"context"
+	"time"
 
 	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/satisfactory"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+)
+
+const (
+	// dockerInfoKey is the redis key that the docker info is stored under.
+	dockerInfoKey = state.SourceDockerInfo
+
+	// serverDetailsKey is the redis key that the server details are stored under.
+	serverDetailsKey = state.SourceServerDetails
+
+	// containerStateRunning is the docker state of a running container.
+	containerStateRunning = "running"
 )
 
 type Service interface {
@@ -12,16 +26,41 @@ type Service interface {
 
 type service struct {
 	ctx                   context.Context
-	alertManager          alerts.DiscordManager
+	alertManager          alerts.Notifier
 	serverInfoListName    string
 	serverDetailsListName string
+	dockerState           state.Store[dockerInfo]
+	detailsState          state.Store[ServerGameState]
+	staleDeadlines        map[string]time.Duration
+	startedAt             time.Time
+	queryClient           satisfactory.QueryClient
+	versionPollInterval   time.Duration
+	serverLogsListName    string
+	tickRateThreshold     float64
+	serverAPI             satisfactory.APIClient
+	retentionPolicy       RetentionPolicy
+	retentionInterval     time.Duration
+
+	// reportedTickRate is the tick rate the open degraded tick rate incident shows. It is zero when there is no open
+	// incident, and only used by the goroutine watching the server details.
+	reportedTickRate float64
 }
 
-func NewService(ctx context.Context, alertManager alerts.DiscordManager, serverInfoListName, serverDetailsListName string) Service {
-	return &service{
+func NewService(ctx context.Context, alertManager alerts.Notifier, serverInfoListName, serverDetailsListName string, opts ...ServiceOption) Service {
+	s := &service{
 		ctx:                   ctx,
 		alertManager:          alertManager,
 		serverInfoListName:    serverInfoListName,
 		serverDetailsListName: serverDetailsListName,
+		dockerState:           state.NewStore[dockerInfo](dockerInfoKey),
+		detailsState:          state.NewStore[ServerGameState](serverDetailsKey),
+		staleDeadlines:        make(map[string]time.Duration),
+		tickRateThreshold:     defaultTickRateThreshold,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
@@ -0,0 +1,116 @@
+package watcher
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+)
+
+const (
+	// staleCheckInterval is how often the ingest sources are checked against their deadlines.
+	staleCheckInterval = 30 * time.Second
+)
+
+// sourceNames are the human-readable names of the ingest sources.
+var sourceNames = map[string]string{
+	state.SourceDockerInfo:    "docker info",
+	state.SourceServerDetails: "server details",
+}
+
+// received records that a message was received from the source and resolves the source if it was stale.
+func (s *service) received(ctx context.Context, source string) {
+	now := time.Now().UTC()
+
+	prev, err := state.GetFreshness(ctx, source)
+	if err != nil {
+		slog.Error("Error getting source freshness", slog.String("source", source), slog.String(logging.KeyError, err.Error()))
+		prev = &state.Freshness{Source: source}
+	}
+
+	if err := state.MarkSeen(ctx, source, now); err != nil {
+		slog.Error("Error marking source as seen", slog.String("source", source), slog.String(logging.KeyError, err.Error()))
+	}
+
+	wasStale, err := state.ClearStale(ctx, source)
+	if err != nil {
+		slog.Error("Error clearing stale flag", slog.String("source", source), slog.String(logging.KeyError, err.Error()))
+		return
+	} else if !wasStale {
+		return
+	}
+
+	slog.Info("Source is no longer stale", slog.String("source", source))
+	alert := alerts.NewTemplatedAlert(alerts.SeverityOK, alerts.TemplateDataReceived, map[string]any{
+		"Source":     source,
+		"SourceName": sourceNames[source],
+		"Downtime":   prev.Age(now),
+	}).WithCategory(alerts.CategoryStaleness)
+	if err := s.alertManager.Notify(ctx, alert); err != nil {
+		slog.Error("Error sending resolved alert", slog.String("source", source), slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// watchStaleness periodically checks every source with a deadline and flags the ones that missed it.
+func (s *service) watchStaleness(ctx context.Context) {
+	if len(s.staleDeadlines) == 0 {
+		slog.Debug("No stale deadlines configured")
+		return
+	}
+
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Debug("Context done")
+			return
+		case <-ticker.C:
+			s.checkStaleness(ctx)
+		}
+	}
+}
+
+func (s *service) checkStaleness(ctx context.Context) {
+	now := time.Now().UTC()
+
+	for source, deadline := range s.staleDeadlines {
+		f, err := state.GetFreshness(ctx, source)
+		if err != nil {
+			slog.Error("Error getting source freshness", slog.String("source", source), slog.String(logging.KeyError, err.Error()))
+			continue
+		} else if f.Stale {
+			continue
+		}
+
+		// Give every source the full deadline after the watcher starts, so a restart does not flag a source that
+		// has not been seen yet.
+		since := f.LastSeen
+		if since.Before(s.startedAt) {
+			since = s.startedAt
+		}
+
+		if now.Sub(since) < deadline {
+			continue
+		}
+
+		if err := state.SetStale(ctx, source, now); err != nil {
+			slog.Error("Error flagging source as stale", slog.String("source", source), slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+
+		slog.Warn("Source is stale", slog.String("source", source), slog.Duration("deadline", deadline))
+		alert := alerts.NewTemplatedAlert(alerts.SeverityWarning, alerts.TemplateDataStale, map[string]any{
+			"Source":     source,
+			"SourceName": sourceNames[source],
+			"Deadline":   deadline,
+		}).WithCategory(alerts.CategoryStaleness)
+		if err := s.alertManager.Notify(ctx, alert); err != nil {
+			slog.Error("Error sending stale alert", slog.String("source", source), slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
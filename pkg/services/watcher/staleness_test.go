@@ -0,0 +1,146 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// lastSeenKey and staleKey are the redis hashes the freshness of the sources is kept in.
+	lastSeenKey = "ingest_last_seen"
+	staleKey    = "ingest_stale"
+)
+
+// nilReply makes the mock pool return a nil reply, as redis does for a missing key.
+func nilReply(context.Context, string, ...any) (any, error) {
+	return nil, nil
+}
+
+// mockPool makes the mock the redis pool.
+func mockPool(t *testing.T) *goredis.MockPool {
+	pool := goredis.NewMockPool(t)
+	require.NoError(t, goredis.NewPool(
+		goredis.WithInitializedPool(pool),
+		goredis.WithAddress("localhost:6379"),
+		goredis.WithNetwork("tcp"),
+	))
+	return pool
+}
+
+// redisTime returns the time as it is stored in redis, or a nil reply when it is zero.
+func redisTime(at time.Time) any {
+	if at.IsZero() {
+		return nilReply
+	}
+	return []byte(at.UTC().Format(time.RFC3339Nano))
+}
+
+func TestCheckStaleness(t *testing.T) {
+	const deadline = 5 * time.Minute
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		startedAt time.Time
+		lastSeen  time.Time
+		stale     bool
+		wantStale bool
+	}{
+		{name: "fresh", startedAt: now.Add(-time.Hour), lastSeen: now.Add(-time.Minute)},
+		{name: "within deadline", startedAt: now.Add(-time.Hour), lastSeen: now.Add(-deadline + 10*time.Second)},
+		{name: "past deadline", startedAt: now.Add(-time.Hour), lastSeen: now.Add(-deadline - time.Second), wantStale: true},
+		{name: "already stale", startedAt: now.Add(-time.Hour), lastSeen: now.Add(-time.Hour), stale: true},
+		{name: "grace after start", startedAt: now.Add(-time.Minute), lastSeen: now.Add(-time.Hour)},
+		{name: "never seen within grace", startedAt: now.Add(-deadline + 10*time.Second)},
+		{name: "never seen past grace", startedAt: now.Add(-deadline - time.Second), wantStale: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := mockPool(t)
+			pool.On("DoCtx", mock.Anything, "HGET", lastSeenKey, state.SourceDockerInfo).Return(redisTime(tt.lastSeen), nil)
+
+			var staleSince time.Time
+			if tt.stale {
+				staleSince = now
+			}
+			pool.On("DoCtx", mock.Anything, "HGET", staleKey, state.SourceDockerInfo).Return(redisTime(staleSince), nil)
+			if tt.wantStale {
+				pool.On("DoCtx", mock.Anything, "HSET", staleKey, state.SourceDockerInfo, mock.Anything).Return(int64(1), nil)
+			}
+
+			notifier := new(recordingNotifier)
+			s := NewService(context.Background(), notifier, "info", "details",
+				WithStaleDeadline(state.SourceDockerInfo, deadline),
+			).(*service)
+			s.startedAt = tt.startedAt
+
+			s.checkStaleness(context.Background())
+
+			if !tt.wantStale {
+				require.Empty(t, notifier.alerts)
+				return
+			}
+
+			require.Len(t, notifier.alerts, 1)
+			alert := notifier.alerts[0]
+			require.Equal(t, alerts.SeverityWarning, alert.Severity)
+			require.Equal(t, alerts.TemplateDataStale, alert.Template)
+			require.Equal(t, alerts.CategoryStaleness, alert.Category)
+			require.Equal(t, "docker info", alert.Data["SourceName"])
+			require.Equal(t, deadline, alert.Data["Deadline"])
+		})
+	}
+}
+
+func TestReceived(t *testing.T) {
+	lastSeen := time.Now().Add(-10 * time.Minute)
+
+	tests := []struct {
+		name      string
+		wasStale  bool
+		wantAlert bool
+	}{
+		{name: "fresh", wasStale: false},
+		{name: "recovered", wasStale: true, wantAlert: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := mockPool(t)
+			pool.On("DoCtx", mock.Anything, "HGET", lastSeenKey, state.SourceServerDetails).Return(redisTime(lastSeen), nil)
+			pool.On("DoCtx", mock.Anything, "HGET", staleKey, state.SourceServerDetails).Return(nilReply, nil)
+			pool.On("DoCtx", mock.Anything, "HSET", lastSeenKey, state.SourceServerDetails, mock.Anything).Return(int64(0), nil)
+
+			var removed int64
+			if tt.wasStale {
+				removed = 1
+			}
+			pool.On("DoCtx", mock.Anything, "HDEL", staleKey, state.SourceServerDetails).Return(removed, nil)
+
+			notifier := new(recordingNotifier)
+			s := NewService(context.Background(), notifier, "info", "details").(*service)
+
+			s.received(context.Background(), state.SourceServerDetails)
+
+			if !tt.wantAlert {
+				require.Empty(t, notifier.alerts)
+				return
+			}
+
+			require.Len(t, notifier.alerts, 1)
+			alert := notifier.alerts[0]
+			require.Equal(t, alerts.SeverityOK, alert.Severity)
+			require.Equal(t, alerts.TemplateDataReceived, alert.Template)
+			require.Equal(t, "server details", alert.Data["SourceName"])
+			require.InDelta(t, 10*time.Minute, alert.Data["Downtime"], float64(time.Minute), "the downtime is how long the source was silent")
+		})
+	}
+}
@@ -3,15 +3,23 @@ package watcher
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/Jacobbrewer1/goredis"
 	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
 	redisgo "github.com/gomodule/redigo/redis"
 )
 
 func (s *service) Start() error {
+	s.startedAt = time.Now().UTC()
+
 	go s.watchServerInfo(s.ctx)
 	go s.watchServerDetails(s.ctx)
+	go s.watchStaleness(s.ctx)
+	go s.watchServerVersion(s.ctx)
+	go s.watchServerLogs(s.ctx)
+	go s.watchSaveRetention(s.ctx)
 
 	<-s.ctx.Done()
 
@@ -34,6 +42,8 @@ func (s *service) watchServerInfo(ctx context.Context) {
 				continue
 			}
 
+			s.received(ctx, state.SourceDockerInfo)
+
 			if err := s.processInfoMessage(got[1]); err != nil {
 				slog.Error("Error processing info message", slog.String(logging.KeyError, err.Error()))
 			}
@@ -59,6 +69,8 @@ func (s *service) watchServerDetails(ctx context.Context) {
 				continue
 			}
 
+			s.received(ctx, state.SourceServerDetails)
+
 			if err := s.processDetailsMessage(got[1]); err != nil {
 				slog.Error("Error processing details message", slog.String(logging.KeyError, err.Error()))
 			}
@@ -0,0 +1,165 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/alerts"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	"github.com/Jacobbrewer1/satisfactory/pkg/state"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// defaultVersionPollInterval is how often the query API is polled for the server build when no interval is set.
+	defaultVersionPollInterval = time.Minute
+)
+
+var (
+	// buildBannerPatterns match the lines of the server log banner that contain the build changelist.
+	buildBannerPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`LogInit: Net CL: (\d+)`),
+		regexp.MustCompile(`LogInit: Build: \S*-CL-(\d+)`),
+	}
+)
+
+// parseBuildFromLog returns the build changelist if the log line is part of the server log banner.
+func parseBuildFromLog(line string) (int, bool) {
+	for _, p := range buildBannerPatterns {
+		m := p.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		build, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+
+		return build, true
+	}
+
+	return 0, false
+}
+
+// recordVersion records the observed build and announces it if it changed.
+func (s *service) recordVersion(ctx context.Context, build int, source string) error {
+	change, err := state.RecordServerVersion(ctx, build, source, time.Now())
+	if err != nil {
+		return fmt.Errorf("record server version: %w", err)
+	} else if change == nil {
+		return nil
+	}
+
+	slog.Info("Server build changed", slog.Int("from", change.From), slog.Int("to", change.To), slog.String("source", source))
+	alert := alerts.NewTemplatedAlert(alerts.SeverityInfo, alerts.TemplateServerUpdated, map[string]any{
+		"From": change.From,
+		"To":   change.To,
+	}).WithCategory(alerts.CategoryVersion)
+	if err := s.alertManager.Notify(ctx, alert); err != nil {
+		return fmt.Errorf("send alert: %w", err)
+	}
+
+	return nil
+}
+
+// watchServerVersion polls the query API for the server build.
+func (s *service) watchServerVersion(ctx context.Context) {
+	if s.queryClient == nil {
+		slog.Debug("No query client configured, not polling server version")
+		return
+	}
+
+	ticker := time.NewTicker(s.versionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.pollServerVersion(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Debug("Context done")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *service) pollServerVersion(ctx context.Context) {
+	pollCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	got, err := s.queryClient.PollServerState(pollCtx)
+	if err != nil {
+		slog.Error("Error polling server state", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	if err := s.recordVersion(ctx, int(got.NetCL), state.VersionSourceQuery); err != nil {
+		slog.Error("Error recording server version", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// watchServerLogs reads the server log lines pushed by vector.
+func (s *service) watchServerLogs(ctx context.Context) {
+	if s.serverLogsListName == "" {
+		slog.Debug("No server logs list configured, not watching server logs")
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Debug("Context done")
+			return
+		default:
+			got, err := redisgo.ByteSlices(goredis.DoCtx(ctx, "BLPOP", s.serverLogsListName, 0))
+			if err != nil {
+				slog.Error("Error getting message from redis logs list", slog.String(logging.KeyError, err.Error()))
+				continue
+			} else if got == nil {
+				slog.Debug("No message to process")
+				continue
+			}
+
+			if err := s.processLogMessage(ctx, got[1]); err != nil {
+				slog.Error("Error processing log message", slog.String(logging.KeyError, err.Error()))
+			}
+		}
+	}
+}
+
+func (s *service) processLogMessage(ctx context.Context, msg []byte) error {
+	vecMsg := new(vectorMessage)
+	if err := json.Unmarshal(msg, vecMsg); err != nil {
+		return fmt.Errorf("unmarshal vector message: %w", err)
+	}
+
+	// Log lines are sent as JSON strings, but fall back to the raw message if they are not.
+	var line string
+	if err := json.Unmarshal(vecMsg.Message, &line); err != nil {
+		line = string(vecMsg.Message)
+	}
+
+	if build, ok := parseBuildFromLog(line); ok {
+		if err := s.recordVersion(ctx, build, state.VersionSourceLog); err != nil {
+			return fmt.Errorf("record version: %w", err)
+		}
+	}
+
+	at := vecMsg.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if err := trackPlayers(ctx, line, at); err != nil {
+		return fmt.Errorf("track players: %w", err)
+	}
+
+	return nil
+}
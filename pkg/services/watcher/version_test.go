@@ -0,0 +1,42 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBuildFromLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantBuild int
+		wantOk    bool
+	}{
+		{
+			name:      "net cl",
+			line:      "[2024.09.10-18.22.01:123][  0]LogInit: Net CL: 365306",
+			wantBuild: 365306,
+			wantOk:    true,
+		},
+		{
+			name:      "build",
+			line:      "[2024.09.10-18.22.01:123][  0]LogInit: Build: ++FactoryGame+rel-main-1.0.0-CL-365306",
+			wantBuild: 365306,
+			wantOk:    true,
+		},
+		{
+			name:   "other line",
+			line:   "[2024.09.10-18.22.01:123][  0]LogNet: Join succeeded: Pioneer",
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build, ok := parseBuildFromLog(tt.line)
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.wantBuild, build)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// Change describes a single field that changed between two observations.
+type Change struct {
+	// Name is the name of the field.
+	Name string
+
+	// Kind is the type of the new value.
+	Kind Kind
+
+	// Old is the previously recorded value.
+	Old any
+
+	// New is the newly observed value.
+	New any
+
+	// PreviousChangedAt is when the old value was first recorded.
+	PreviousChangedAt time.Time
+
+	// ChangedAt is when the change was observed.
+	ChangedAt time.Time
+}
+
+// String returns a human-readable description of the change.
+func (c *Change) String() string {
+	return fmt.Sprintf("%s changed from %v to %v", c.Name, c.Old, c.New)
+}
+
+// Diff is the result of recording a new observation in a Store.
+type Diff struct {
+	// FirstObservation is true when nothing was previously recorded for the store. No changes are reported for the
+	// first observation.
+	FirstObservation bool
+
+	// Changes are the fields that changed, in the order returned by the snapshot.
+	Changes []*Change
+}
+
+// Changed reports whether anything changed.
+func (d *Diff) Changed() bool {
+	return len(d.Changes) > 0
+}
+
+// Change returns the change for the given field, if the field changed.
+func (d *Diff) Change(name string) (*Change, bool) {
+	for _, c := range d.Changes {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// diffFields compares the observed fields with the previously stored fields. It returns the diff and the fields to
+// record, carrying over the last changed time of any field that did not change.
+func diffFields(prev map[string]*StoredField, fields []*Field, now time.Time) (*Diff, []*StoredField) {
+	diff := &Diff{
+		FirstObservation: len(prev) == 0,
+		Changes:          make([]*Change, 0),
+	}
+
+	next := make([]*StoredField, len(fields))
+	for i, f := range fields {
+		next[i] = &StoredField{
+			Field:     *f,
+			ChangedAt: now,
+		}
+
+		if diff.FirstObservation {
+			continue
+		}
+
+		old, ok := prev[f.Name]
+		if ok && old.Kind == f.Kind && old.Value == f.Value {
+			next[i].ChangedAt = old.ChangedAt
+			continue
+		}
+
+		change := &Change{
+			Name:      f.Name,
+			Kind:      f.Kind,
+			New:       f.Value,
+			ChangedAt: now,
+		}
+		if ok {
+			change.Old = old.Value
+			change.PreviousChangedAt = old.ChangedAt
+		}
+
+		diff.Changes = append(diff.Changes, change)
+	}
+
+	return diff, next
+}
@@ -0,0 +1,103 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffFields_FirstObservation(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	diff, next := diffFields(nil, []*Field{
+		StringField("State", "running"),
+		BoolField("IsGameRunning", true),
+	}, now)
+
+	require.True(t, diff.FirstObservation)
+	require.False(t, diff.Changed())
+	require.Len(t, next, 2)
+	for _, f := range next {
+		require.Equal(t, now, f.ChangedAt)
+	}
+}
+
+func TestDiffFields_Changes(t *testing.T) {
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := before.Add(time.Hour)
+
+	prev := map[string]*StoredField{
+		"State": {
+			Field:     *StringField("State", "running"),
+			ChangedAt: before,
+		},
+		"NumConnectedPlayers": {
+			Field:     *IntField("NumConnectedPlayers", 2),
+			ChangedAt: before,
+		},
+	}
+
+	diff, next := diffFields(prev, []*Field{
+		StringField("State", "exited"),
+		IntField("NumConnectedPlayers", 2),
+		FloatField("AverageTickRate", 30),
+	}, now)
+
+	require.False(t, diff.FirstObservation)
+	require.Len(t, diff.Changes, 2)
+
+	state, ok := diff.Change("State")
+	require.True(t, ok)
+	require.Equal(t, &Change{
+		Name:              "State",
+		Kind:              KindString,
+		Old:               "running",
+		New:               "exited",
+		PreviousChangedAt: before,
+		ChangedAt:         now,
+	}, state)
+
+	tick, ok := diff.Change("AverageTickRate")
+	require.True(t, ok)
+	require.Nil(t, tick.Old)
+	require.Equal(t, 30.0, tick.New)
+
+	_, ok = diff.Change("NumConnectedPlayers")
+	require.False(t, ok)
+
+	require.Equal(t, now, next[0].ChangedAt)
+	require.Equal(t, before, next[1].ChangedAt)
+	require.Equal(t, now, next[2].ChangedAt)
+}
+
+func TestEncodeDecodeValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    Kind
+		value   any
+		encoded string
+	}{
+		{name: "string", kind: KindString, value: "running", encoded: "running"},
+		{name: "int", kind: KindInt, value: 4, encoded: "4"},
+		{name: "float", kind: KindFloat, value: 29.5, encoded: "29.5"},
+		{name: "bool", kind: KindBool, value: true, encoded: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeValue(tt.kind, tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.encoded, got)
+
+			decoded, err := decodeValue(tt.kind, got)
+			require.NoError(t, err)
+			require.Equal(t, tt.value, decoded)
+		})
+	}
+}
+
+func TestEncodeValue_KindMismatch(t *testing.T) {
+	_, err := encodeValue(KindInt, "4")
+	require.Error(t, err)
+}
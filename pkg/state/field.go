@@ -0,0 +1,117 @@
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Kind is the type of value held by a field.
+type Kind string
+
+const (
+	KindString Kind = "string"
+	KindInt    Kind = "int"
+	KindFloat  Kind = "float"
+	KindBool   Kind = "bool"
+)
+
+// Field is a single typed value of an observed snapshot.
+type Field struct {
+	// Name is the name of the field. This is also the key in the redis hash.
+	Name string
+
+	// Kind is the type of the value.
+	Kind Kind
+
+	// Value is the typed value of the field. The dynamic type always matches the Kind.
+	Value any
+}
+
+// StringField returns a string field.
+func StringField(name, value string) *Field {
+	return &Field{Name: name, Kind: KindString, Value: value}
+}
+
+// IntField returns an int field.
+func IntField(name string, value int) *Field {
+	return &Field{Name: name, Kind: KindInt, Value: value}
+}
+
+// FloatField returns a float field.
+func FloatField(name string, value float64) *Field {
+	return &Field{Name: name, Kind: KindFloat, Value: value}
+}
+
+// BoolField returns a bool field.
+func BoolField(name string, value bool) *Field {
+	return &Field{Name: name, Kind: KindBool, Value: value}
+}
+
+// Observable is implemented by any snapshot that can be held in a Store.
+type Observable interface {
+	// StateFields returns the typed fields of the snapshot in a stable order.
+	StateFields() []*Field
+}
+
+// StoredField is a field as it was last recorded in the store.
+type StoredField struct {
+	Field
+
+	// ChangedAt is the time the value last changed.
+	ChangedAt time.Time
+}
+
+// fieldMeta is the metadata stored alongside each field value.
+type fieldMeta struct {
+	Kind      Kind      `json:"kind"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// encodeValue returns the string representation of the value that is stored in redis.
+func encodeValue(kind Kind, value any) (string, error) {
+	switch kind {
+	case KindString:
+		v, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("expected string value, got %T", value)
+		}
+		return v, nil
+	case KindInt:
+		v, ok := value.(int)
+		if !ok {
+			return "", fmt.Errorf("expected int value, got %T", value)
+		}
+		return strconv.Itoa(v), nil
+	case KindFloat:
+		v, ok := value.(float64)
+		if !ok {
+			return "", fmt.Errorf("expected float64 value, got %T", value)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case KindBool:
+		v, ok := value.(bool)
+		if !ok {
+			return "", fmt.Errorf("expected bool value, got %T", value)
+		}
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("unknown kind: %s", kind)
+	}
+}
+
+// decodeValue parses the stored string representation of a value of the given kind.
+func decodeValue(kind Kind, value string) (any, error) {
+	switch kind {
+	case KindString:
+		return value, nil
+	case KindInt:
+		return strconv.Atoi(value)
+	case KindFloat:
+		return strconv.ParseFloat(value, 64)
+	case KindBool:
+		return strconv.ParseBool(value)
+	default:
+		return nil, fmt.Errorf("unknown kind: %s", kind)
+	}
+}
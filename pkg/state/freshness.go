@@ -0,0 +1,107 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// SourceDockerInfo is the ingest source of the docker container info.
+	SourceDockerInfo = "docker_info"
+
+	// SourceServerDetails is the ingest source of the dedicated server details.
+	SourceServerDetails = "server_details"
+)
+
+const (
+	// lastSeenKey is the redis hash holding the time each source was last received.
+	lastSeenKey = "ingest_last_seen"
+
+	// staleKey is the redis hash holding the time each stale source was flagged as stale.
+	staleKey = "ingest_stale"
+)
+
+// Freshness describes how recently data was received from an ingest source.
+type Freshness struct {
+	// Source is the name of the ingest source.
+	Source string
+
+	// LastSeen is when data was last received. It is zero if nothing has been received.
+	LastSeen time.Time
+
+	// Stale is true if the source missed its deadline.
+	Stale bool
+
+	// StaleSince is when the source was flagged as stale.
+	StaleSince time.Time
+}
+
+// Age returns how old the data from the source is at the given time.
+func (f *Freshness) Age(now time.Time) time.Duration {
+	if f.LastSeen.IsZero() {
+		return 0
+	}
+	return now.Sub(f.LastSeen)
+}
+
+// MarkSeen records that data was received from the source.
+func MarkSeen(ctx context.Context, source string, at time.Time) error {
+	if _, err := goredis.DoCtx(ctx, "HSET", lastSeenKey, source, at.UTC().Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("store last seen: %w", err)
+	}
+	return nil
+}
+
+// SetStale flags the source as stale.
+func SetStale(ctx context.Context, source string, since time.Time) error {
+	if _, err := goredis.DoCtx(ctx, "HSET", staleKey, source, since.UTC().Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("store stale flag: %w", err)
+	}
+	return nil
+}
+
+// ClearStale removes the stale flag from the source. It returns true if the source was stale.
+func ClearStale(ctx context.Context, source string) (bool, error) {
+	n, err := redisgo.Int(goredis.DoCtx(ctx, "HDEL", staleKey, source))
+	if err != nil {
+		return false, fmt.Errorf("remove stale flag: %w", err)
+	}
+	return n > 0, nil
+}
+
+// GetFreshness returns the freshness of the source.
+func GetFreshness(ctx context.Context, source string) (*Freshness, error) {
+	f := &Freshness{
+		Source: source,
+	}
+
+	lastSeen, err := redisgo.String(goredis.DoCtx(ctx, "HGET", lastSeenKey, source))
+	switch {
+	case err == nil:
+		f.LastSeen, err = time.Parse(time.RFC3339Nano, lastSeen)
+		if err != nil {
+			return nil, fmt.Errorf("parse last seen: %w", err)
+		}
+	case !errors.Is(err, redisgo.ErrNil):
+		return nil, fmt.Errorf("get last seen: %w", err)
+	}
+
+	staleSince, err := redisgo.String(goredis.DoCtx(ctx, "HGET", staleKey, source))
+	switch {
+	case err == nil:
+		f.Stale = true
+		f.StaleSince, err = time.Parse(time.RFC3339Nano, staleSince)
+		if err != nil {
+			return nil, fmt.Errorf("parse stale since: %w", err)
+		}
+	case !errors.Is(err, redisgo.ErrNil):
+		return nil, fmt.Errorf("get stale flag: %w", err)
+	}
+
+	return f, nil
+}
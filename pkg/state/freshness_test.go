@@ -0,0 +1,90 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// nilReply makes the mock pool return a nil reply, as redis does for a missing key.
+func nilReply(context.Context, string, ...any) (any, error) {
+	return nil, nil
+}
+
+// mockPool makes the mock the redis pool.
+func mockPool(t *testing.T) *goredis.MockPool {
+	pool := goredis.NewMockPool(t)
+	require.NoError(t, goredis.NewPool(
+		goredis.WithInitializedPool(pool),
+		goredis.WithAddress("localhost:6379"),
+		goredis.WithNetwork("tcp"),
+	))
+	return pool
+}
+
+func TestMarkSeen(t *testing.T) {
+	pool := mockPool(t)
+	at := time.Date(2024, 1, 1, 12, 0, 0, 5, time.FixedZone("BST", 3600))
+	pool.On("DoCtx", mock.Anything, "HSET", lastSeenKey, SourceDockerInfo, "2024-01-01T11:00:00.000000005Z").
+		Return(int64(1), nil)
+
+	require.NoError(t, MarkSeen(context.Background(), SourceDockerInfo, at))
+}
+
+func TestSetStale(t *testing.T) {
+	pool := mockPool(t)
+	since := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pool.On("DoCtx", mock.Anything, "HSET", staleKey, SourceServerDetails, "2024-01-01T12:00:00Z").
+		Return(int64(1), nil)
+
+	require.NoError(t, SetStale(context.Background(), SourceServerDetails, since))
+}
+
+func TestClearStale(t *testing.T) {
+	pool := mockPool(t)
+	pool.On("DoCtx", mock.Anything, "HDEL", staleKey, SourceDockerInfo).Return(int64(1), nil).Once()
+	pool.On("DoCtx", mock.Anything, "HDEL", staleKey, SourceDockerInfo).Return(int64(0), nil).Once()
+
+	wasStale, err := ClearStale(context.Background(), SourceDockerInfo)
+	require.NoError(t, err)
+	require.True(t, wasStale)
+
+	wasStale, err = ClearStale(context.Background(), SourceDockerInfo)
+	require.NoError(t, err)
+	require.False(t, wasStale, "a source that wasn't stale is not reported as recovered")
+}
+
+func TestGetFreshness(t *testing.T) {
+	pool := mockPool(t)
+	pool.On("DoCtx", mock.Anything, "HGET", lastSeenKey, SourceDockerInfo).Return([]byte("2024-01-01T12:00:00Z"), nil)
+	pool.On("DoCtx", mock.Anything, "HGET", staleKey, SourceDockerInfo).Return([]byte("2024-01-01T12:05:00Z"), nil)
+	pool.On("DoCtx", mock.Anything, "HGET", lastSeenKey, SourceServerDetails).Return(nilReply, nil)
+	pool.On("DoCtx", mock.Anything, "HGET", staleKey, SourceServerDetails).Return(nilReply, nil)
+
+	f, err := GetFreshness(context.Background(), SourceDockerInfo)
+	require.NoError(t, err)
+	require.Equal(t, &Freshness{
+		Source:     SourceDockerInfo,
+		LastSeen:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Stale:      true,
+		StaleSince: time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC),
+	}, f)
+
+	f, err = GetFreshness(context.Background(), SourceServerDetails)
+	require.NoError(t, err)
+	require.Equal(t, &Freshness{Source: SourceServerDetails}, f)
+}
+
+func TestFreshness_Age(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	f := &Freshness{LastSeen: now.Add(-90 * time.Second)}
+	require.Equal(t, 90*time.Second, f.Age(now))
+
+	f = new(Freshness)
+	require.Zero(t, f.Age(now), "a source never seen has no age")
+}
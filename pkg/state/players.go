@@ -0,0 +1,233 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// onlinePlayersKey is the redis hash holding the sessions of the players online, by name.
+	onlinePlayersKey = "players:online"
+
+	// playerIDsKey is the redis hash holding the names of the players connected, by their unique ID. Disconnections
+	// are only logged with the unique ID.
+	playerIDsKey = "players:ids"
+
+	// recentPlayersKey is the redis list holding the sessions of the players that left, newest first.
+	recentPlayersKey = "players:recent"
+
+	// maxRecentPlayers is the number of ended sessions that are kept.
+	maxRecentPlayers = 50
+)
+
+// PlayerSession is the time a player spent on the server.
+type PlayerSession struct {
+	// Name is the name of the player.
+	Name string `json:"name"`
+
+	// JoinedAt is when the player joined.
+	JoinedAt time.Time `json:"joined_at"`
+
+	// LeftAt is when the player left. It is zero while the player is online.
+	LeftAt time.Time `json:"left_at,omitempty"`
+}
+
+// Duration returns how long the player was on for, or has been on for at the given time while they are online.
+func (p *PlayerSession) Duration(now time.Time) time.Duration {
+	if !p.LeftAt.IsZero() {
+		return p.LeftAt.Sub(p.JoinedAt)
+	}
+	return now.Sub(p.JoinedAt)
+}
+
+// RecordPlayerLogin records the name of the player logging in with the unique ID, so their disconnection can be
+// attributed to them.
+func RecordPlayerLogin(ctx context.Context, uniqueID, name string) error {
+	if _, err := goredis.DoCtx(ctx, "HSET", playerIDsKey, uniqueID, name); err != nil {
+		return fmt.Errorf("store player id: %w", err)
+	}
+	return nil
+}
+
+// PlayerJoined records that the player joined. A player that is already online keeps their session.
+func PlayerJoined(ctx context.Context, name string, at time.Time) error {
+	raw, err := json.Marshal(&PlayerSession{
+		Name:     name,
+		JoinedAt: at.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal player session: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "HSETNX", onlinePlayersKey, name, raw); err != nil {
+		return fmt.Errorf("store player session: %w", err)
+	}
+
+	return nil
+}
+
+// PlayerDisconnected records that the player with the unique ID left. It returns the session that ended, or nil if
+// the player isn't known.
+func PlayerDisconnected(ctx context.Context, uniqueID string, at time.Time) (*PlayerSession, error) {
+	name, err := redisgo.String(goredis.DoCtx(ctx, "HGET", playerIDsKey, uniqueID))
+	if errors.Is(err, redisgo.ErrNil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get player name: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "HDEL", playerIDsKey, uniqueID); err != nil {
+		return nil, fmt.Errorf("remove player id: %w", err)
+	}
+
+	return PlayerLeft(ctx, name, at)
+}
+
+// PlayerLeft records that the player left, moving their session to the recent players. It returns the session that
+// ended, or nil if the player wasn't online.
+func PlayerLeft(ctx context.Context, name string, at time.Time) (*PlayerSession, error) {
+	raw, err := redisgo.Bytes(goredis.DoCtx(ctx, "HGET", onlinePlayersKey, name))
+	if errors.Is(err, redisgo.ErrNil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get player session: %w", err)
+	}
+
+	session := new(PlayerSession)
+	if err := json.Unmarshal(raw, session); err != nil {
+		return nil, fmt.Errorf("unmarshal player session: %w", err)
+	}
+	session.LeftAt = at.UTC()
+
+	if err := endPlayerSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ClearOnlinePlayers ends the session of every player online, such as when the server reports nobody is connected.
+func ClearOnlinePlayers(ctx context.Context, at time.Time) error {
+	online, err := OnlinePlayers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range online {
+		session.LeftAt = at.UTC()
+		if err := endPlayerSession(ctx, session); err != nil {
+			return err
+		}
+	}
+
+	if _, err := goredis.DoCtx(ctx, "DEL", playerIDsKey); err != nil {
+		return fmt.Errorf("remove player ids: %w", err)
+	}
+
+	return nil
+}
+
+// ReconcileOnlinePlayers ends the sessions of the players without a known connection while more players are online
+// than the server reports connected, such as when their leaving was not logged or not attributed to them. It returns
+// the sessions that ended.
+func ReconcileOnlinePlayers(ctx context.Context, connected int, at time.Time) ([]*PlayerSession, error) {
+	online, err := OnlinePlayers(ctx)
+	if err != nil {
+		return nil, err
+	} else if len(online) <= connected {
+		return nil, nil
+	}
+
+	names, err := redisgo.Strings(goredis.DoCtx(ctx, "HVALS", playerIDsKey))
+	if err != nil {
+		return nil, fmt.Errorf("get connected players: %w", err)
+	}
+
+	ended := make([]*PlayerSession, 0, len(online)-connected)
+	for _, session := range online {
+		if len(online)-len(ended) <= connected {
+			break
+		} else if slices.Contains(names, session.Name) {
+			continue
+		}
+
+		session.LeftAt = at.UTC()
+		if err := endPlayerSession(ctx, session); err != nil {
+			return nil, err
+		}
+		ended = append(ended, session)
+	}
+
+	return ended, nil
+}
+
+// endPlayerSession moves the ended session from the online players to the recent players.
+func endPlayerSession(ctx context.Context, session *PlayerSession) error {
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("marshal player session: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "HDEL", onlinePlayersKey, session.Name); err != nil {
+		return fmt.Errorf("remove player session: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "LPUSH", recentPlayersKey, raw); err != nil {
+		return fmt.Errorf("store player session: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "LTRIM", recentPlayersKey, 0, maxRecentPlayers-1); err != nil {
+		return fmt.Errorf("trim recent players: %w", err)
+	}
+
+	return nil
+}
+
+// OnlinePlayers returns the sessions of the players online, longest first.
+func OnlinePlayers(ctx context.Context) ([]*PlayerSession, error) {
+	raw, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", onlinePlayersKey))
+	if err != nil {
+		return nil, fmt.Errorf("get online players: %w", err)
+	}
+
+	sessions := make([]*PlayerSession, 0, len(raw))
+	for _, r := range raw {
+		session := new(PlayerSession)
+		if err := json.Unmarshal([]byte(r), session); err != nil {
+			return nil, fmt.Errorf("unmarshal player session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	slices.SortFunc(sessions, func(a, b *PlayerSession) int {
+		return a.JoinedAt.Compare(b.JoinedAt)
+	})
+
+	return sessions, nil
+}
+
+// RecentPlayers returns up to limit of the sessions of the players that left, newest first.
+func RecentPlayers(ctx context.Context, limit int) ([]*PlayerSession, error) {
+	raw, err := redisgo.ByteSlices(goredis.DoCtx(ctx, "LRANGE", recentPlayersKey, 0, limit-1))
+	if err != nil {
+		return nil, fmt.Errorf("get recent players: %w", err)
+	}
+
+	sessions := make([]*PlayerSession, 0, len(raw))
+	for _, r := range raw {
+		session := new(PlayerSession)
+		if err := json.Unmarshal(r, session); err != nil {
+			return nil, fmt.Errorf("unmarshal player session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
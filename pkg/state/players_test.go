@@ -0,0 +1,47 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileOnlinePlayers(t *testing.T) {
+	joined := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := joined.Add(time.Hour)
+
+	online := map[string]*PlayerSession{
+		"Pioneer One": {Name: "Pioneer One", JoinedAt: joined},
+		"Pioneer Two": {Name: "Pioneer Two", JoinedAt: joined.Add(time.Minute)},
+	}
+	hash := make([]any, 0)
+	for name, session := range online {
+		raw, err := json.Marshal(session)
+		require.NoError(t, err)
+		hash = append(hash, []byte(name), raw)
+	}
+
+	pool := mockPool(t)
+	pool.On("DoCtx", mock.Anything, "HGETALL", onlinePlayersKey).Return(hash, nil)
+	pool.On("DoCtx", mock.Anything, "HVALS", playerIDsKey).Return([]any{[]byte("Pioneer Two")}, nil)
+
+	// Pioneer One has no connection, so their session ends once the server reports one player connected.
+	pool.On("DoCtx", mock.Anything, "HDEL", onlinePlayersKey, "Pioneer One").Return(int64(1), nil).Once()
+	pool.On("DoCtx", mock.Anything, "LPUSH", recentPlayersKey, mock.Anything).Return(int64(1), nil).Once()
+	pool.On("DoCtx", mock.Anything, "LTRIM", recentPlayersKey, 0, maxRecentPlayers-1).Return("OK", nil).Once()
+
+	ended, err := ReconcileOnlinePlayers(context.Background(), 1, now)
+	require.NoError(t, err)
+	require.Len(t, ended, 1)
+	require.Equal(t, "Pioneer One", ended[0].Name)
+	require.Equal(t, time.Hour, ended[0].Duration(now))
+
+	// Nothing is ended while the server agrees with the online players.
+	ended, err = ReconcileOnlinePlayers(context.Background(), 2, now)
+	require.NoError(t, err)
+	require.Empty(t, ended)
+}
@@ -0,0 +1,164 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	"github.com/Jacobbrewer1/satisfactory/pkg/logging"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// metaSuffix is appended to the store key to get the key of the hash holding the field metadata.
+	metaSuffix = ":meta"
+)
+
+// Store records typed snapshots in redis and reports what changed between observations.
+//
+// The values are kept in a plain redis hash under the store key so that they can still be read with HGETALL, and the
+// kind and last changed time of every field is kept in a second hash under the key with a ":meta" suffix.
+type Store[T Observable] interface {
+	// Update records the snapshot and returns the diff against the previous observation.
+	Update(ctx context.Context, snapshot T) (*Diff, error)
+
+	// Fields returns the currently recorded fields, keyed by name.
+	Fields(ctx context.Context) (map[string]*StoredField, error)
+}
+
+type store[T Observable] struct {
+	// key is the redis key of the hash holding the values.
+	key string
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewStore creates a new Store that records snapshots under the given redis key.
+func NewStore[T Observable](key string) Store[T] {
+	return &store[T]{
+		key: key,
+		now: time.Now,
+	}
+}
+
+func (s *store[T]) Update(ctx context.Context, snapshot T) (*Diff, error) {
+	prev, err := s.Fields(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get previous state: %w", err)
+	}
+
+	diff, next := diffFields(prev, snapshot.StateFields(), s.now().UTC())
+
+	if err := s.write(ctx, next); err != nil {
+		return nil, fmt.Errorf("store state: %w", err)
+	}
+
+	return diff, nil
+}
+
+func (s *store[T]) Fields(ctx context.Context) (map[string]*StoredField, error) {
+	values, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", s.key))
+	if err != nil {
+		return nil, fmt.Errorf("get values: %w", err)
+	}
+
+	metas, err := redisgo.StringMap(goredis.DoCtx(ctx, "HGETALL", s.key+metaSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("get metadata: %w", err)
+	}
+
+	fields := make(map[string]*StoredField, len(metas))
+	for name, rawMeta := range metas {
+		raw, ok := values[name]
+		if !ok {
+			continue
+		}
+
+		meta := new(fieldMeta)
+		if err := json.Unmarshal([]byte(rawMeta), meta); err != nil {
+			slog.Warn("Ignoring field with invalid metadata",
+				slog.String("key", s.key),
+				slog.String("field", name),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			continue
+		}
+
+		value, err := decodeValue(meta.Kind, raw)
+		if err != nil {
+			slog.Warn("Ignoring field with invalid value",
+				slog.String("key", s.key),
+				slog.String("field", name),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			continue
+		}
+
+		fields[name] = &StoredField{
+			Field: Field{
+				Name:  name,
+				Kind:  meta.Kind,
+				Value: value,
+			},
+			ChangedAt: meta.ChangedAt,
+		}
+	}
+
+	return fields, nil
+}
+
+// write stores the values and metadata of all fields in a single transaction.
+func (s *store[T]) write(ctx context.Context, fields []*StoredField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	values := redisgo.Args{}.Add(s.key)
+	metas := redisgo.Args{}.Add(s.key + metaSuffix)
+	for _, f := range fields {
+		value, err := encodeValue(f.Kind, f.Value)
+		if err != nil {
+			return fmt.Errorf("encode field %s: %w", f.Name, err)
+		}
+
+		meta, err := json.Marshal(&fieldMeta{
+			Kind:      f.Kind,
+			ChangedAt: f.ChangedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("marshal metadata for field %s: %w", f.Name, err)
+		}
+
+		values = values.Add(f.Name, value)
+		metas = metas.Add(f.Name, meta)
+	}
+
+	conn := goredis.Conn()
+	if conn == nil {
+		return goredis.ErrRedisNotInitialised
+	}
+	defer func(conn redisgo.Conn) {
+		if err := conn.Close(); err != nil {
+			slog.Error("Error closing redis connection", slog.String(logging.KeyError, err.Error()))
+		}
+	}(conn)
+
+	if err := conn.Send("MULTI"); err != nil {
+		return fmt.Errorf("start transaction: %w", err)
+	}
+	if err := conn.Send("HSET", values...); err != nil {
+		return fmt.Errorf("queue values: %w", err)
+	}
+	if err := conn.Send("HSET", metas...); err != nil {
+		return fmt.Errorf("queue metadata: %w", err)
+	}
+	if _, err := redisgo.DoContext(conn, ctx, "EXEC"); err != nil {
+		return fmt.Errorf("execute transaction: %w", err)
+	}
+
+	return nil
+}
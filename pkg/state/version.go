@@ -0,0 +1,144 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jacobbrewer1/goredis"
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// VersionSourceQuery is used when the build was read from the lightweight query API.
+	VersionSourceQuery = "query_api"
+
+	// VersionSourceLog is used when the build was read from the server log banner.
+	VersionSourceLog = "log"
+)
+
+const (
+	// serverVersionKey is the redis key holding the current server build.
+	serverVersionKey = "server_version"
+
+	// versionHistoryKey is the redis list holding the server build changes, newest first.
+	versionHistoryKey = "server_version_history"
+
+	// maxVersionHistory is the number of build changes that are kept.
+	maxVersionHistory = 50
+)
+
+// Version is the build of the dedicated server.
+type Version struct {
+	// Build is the changelist number of the build.
+	Build int `json:"build"`
+
+	// Source is where the build was read from.
+	Source string `json:"source"`
+
+	// DetectedAt is when the build was first seen.
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// VersionChange is a recorded change of the server build.
+type VersionChange struct {
+	// From is the previous build.
+	From int `json:"from"`
+
+	// To is the new build.
+	To int `json:"to"`
+
+	// Source is where the new build was read from.
+	Source string `json:"source"`
+
+	// DetectedAt is when the new build was first seen.
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// GetServerVersion returns the current server build. It returns nil if the build has not been seen.
+func GetServerVersion(ctx context.Context) (*Version, error) {
+	raw, err := redisgo.Bytes(goredis.DoCtx(ctx, "GET", serverVersionKey))
+	if errors.Is(err, redisgo.ErrNil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get server version: %w", err)
+	}
+
+	v := new(Version)
+	if err := json.Unmarshal(raw, v); err != nil {
+		return nil, fmt.Errorf("unmarshal server version: %w", err)
+	}
+
+	return v, nil
+}
+
+// RecordServerVersion records the observed server build. It returns the change if the build differs from the one
+// previously recorded, and nil if the build is unchanged or seen for the first time.
+func RecordServerVersion(ctx context.Context, build int, source string, at time.Time) (*VersionChange, error) {
+	current, err := GetServerVersion(ctx)
+	if err != nil {
+		return nil, err
+	} else if current != nil && current.Build == build {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(&Version{
+		Build:      build,
+		Source:     source,
+		DetectedAt: at.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal server version: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "SET", serverVersionKey, raw); err != nil {
+		return nil, fmt.Errorf("store server version: %w", err)
+	}
+
+	if current == nil {
+		return nil, nil
+	}
+
+	change := &VersionChange{
+		From:       current.Build,
+		To:         build,
+		Source:     source,
+		DetectedAt: at.UTC(),
+	}
+
+	rawChange, err := json.Marshal(change)
+	if err != nil {
+		return nil, fmt.Errorf("marshal version change: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "LPUSH", versionHistoryKey, rawChange); err != nil {
+		return nil, fmt.Errorf("store version change: %w", err)
+	}
+
+	if _, err := goredis.DoCtx(ctx, "LTRIM", versionHistoryKey, 0, maxVersionHistory-1); err != nil {
+		return nil, fmt.Errorf("trim version history: %w", err)
+	}
+
+	return change, nil
+}
+
+// GetVersionHistory returns up to limit of the most recent build changes, newest first.
+func GetVersionHistory(ctx context.Context, limit int) ([]*VersionChange, error) {
+	raw, err := redisgo.ByteSlices(goredis.DoCtx(ctx, "LRANGE", versionHistoryKey, 0, limit-1))
+	if err != nil {
+		return nil, fmt.Errorf("get version history: %w", err)
+	}
+
+	changes := make([]*VersionChange, 0, len(raw))
+	for _, r := range raw {
+		c := new(VersionChange)
+		if err := json.Unmarshal(r, c); err != nil {
+			return nil, fmt.Errorf("unmarshal version change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, nil
+}